@@ -76,6 +76,10 @@ func init() {
 	backfillCmd.PersistentFlags().Int("backfill-frequency", 15, "how often to search for new gaps (in seconds; default 15)")
 	backfillCmd.PersistentFlags().Int("backfill-batch-size", 2, "batch size for http requests")
 	backfillCmd.PersistentFlags().Int("backfill-workers", 4, "number of worker goroutines to concurrently make and process http requests")
+	backfillCmd.PersistentFlags().Int("backfill-max-queue-mb", 0, "max total size of the fetched payloads held by the workers (in MB)")
+	backfillCmd.PersistentFlags().Bool("backfill-auto-tune", false, "turn on automatic tuning of the number of active workers")
+	backfillCmd.PersistentFlags().Int("backfill-min-workers", 1, "min number of active workers when auto tuning")
+	backfillCmd.PersistentFlags().Int("backfill-max-workers", 0, "max number of active workers when auto tuning")
 	backfillCmd.PersistentFlags().Int("backfill-timeout", 15, "timeout used for backfill http requests (in seconds)")
 	backfillCmd.PersistentFlags().Int("backfill-validation-level", 1, "data validated less than this amount will be backfilled")
 	backfillCmd.PersistentFlags().String("eth-http-path", "", "http url for ethereum node")
@@ -84,6 +88,10 @@ func init() {
 	viper.BindPFlag("backfill.frequency", backfillCmd.PersistentFlags().Lookup("backfill-frequency"))
 	viper.BindPFlag("backfill.batchSize", backfillCmd.PersistentFlags().Lookup("backfill-batch-size"))
 	viper.BindPFlag("backfill.workers", backfillCmd.PersistentFlags().Lookup("backfill-workers"))
+	viper.BindPFlag("backfill.maxQueueMB", backfillCmd.PersistentFlags().Lookup("backfill-max-queue-mb"))
+	viper.BindPFlag("backfill.autoTune", backfillCmd.PersistentFlags().Lookup("backfill-auto-tune"))
+	viper.BindPFlag("backfill.minWorkers", backfillCmd.PersistentFlags().Lookup("backfill-min-workers"))
+	viper.BindPFlag("backfill.maxWorkers", backfillCmd.PersistentFlags().Lookup("backfill-max-workers"))
 	viper.BindPFlag("backfill.timeout", backfillCmd.PersistentFlags().Lookup("backfill-timeout"))
 	viper.BindPFlag("backfill.validationLevel", backfillCmd.PersistentFlags().Lookup("backfill-validation-level"))
 	viper.BindPFlag("ethereum.httpPath", backfillCmd.PersistentFlags().Lookup("eth-http-path"))
@@ -0,0 +1,85 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// backfillCodeCmd represents the backfill-code command
+var backfillCodeCmd = &cobra.Command{
+	Use:   "backfill-code",
+	Short: "Publish contract code that is referenced by indexed state accounts but missing from public.blocks",
+	Long: `Use this command to find the code hashes in eth.state_accounts that have no code stored in public.blocks,
+e.g. for data indexed before code was streamed alongside the statediffs, and fetch their code with debug_accountRange
+The code is fetched for an indexed state account holding the code hash, by its state leaf key, at the height it was indexed with that code hash,
+and is only published if it hashes to that code hash`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		backfillCode()
+	},
+}
+
+func backfillCode() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("ethereum.httpPath", shared.ETH_HTTP_PATH)
+	viper.BindEnv("code.batchSize", "CODE_BATCH_SIZE")
+	viper.BindEnv("code.timeout", shared.HTTP_TIMEOUT)
+	batchSize := uint64(viper.GetInt64("code.batchSize"))
+	timeout := viper.GetInt("code.timeout")
+	if timeout < 5 {
+		timeout = 5
+	}
+
+	nodeInfo, client, err := shared.GetEthNodeAndClient(fmt.Sprintf("http://%s", viper.GetString("ethereum.httpPath")))
+	if err != nil {
+		logWithCommand.Fatal(err)
+	}
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, nodeInfo, false)
+	if err := eth.NewCodeBackfiller(&db, client, time.Second*time.Duration(timeout), batchSize).Backfill(); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("code backfill finished")
+}
+
+func init() {
+	rootCmd.AddCommand(backfillCodeCmd)
+
+	// flags
+	backfillCodeCmd.PersistentFlags().Int("code-batch-size", 0, "number of code hashes to check, and max number of codes to fetch, in each batch")
+	backfillCodeCmd.PersistentFlags().Int("code-timeout", 15, "timeout used for debug_accountRange http requests (in seconds)")
+	backfillCodeCmd.PersistentFlags().String("eth-http-path", "", "http url for ethereum node")
+
+	// and their .toml config bindings
+	viper.BindPFlag("code.batchSize", backfillCodeCmd.PersistentFlags().Lookup("code-batch-size"))
+	viper.BindPFlag("code.timeout", backfillCodeCmd.PersistentFlags().Lookup("code-timeout"))
+	viper.BindPFlag("ethereum.httpPath", backfillCodeCmd.PersistentFlags().Lookup("eth-http-path"))
+}
@@ -0,0 +1,70 @@
+// Copyright © 2021 Vulcanize, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// backfillMetaDataCmd represents the backfill-metadata command
+var backfillMetaDataCmd = &cobra.Command{
+	Use:   "backfill-metadata",
+	Short: "Fill in the decoded header, uncle, transaction, receipt, and storage columns of previously indexed rows",
+	Long: `Use this command to fill in the coinbase, difficulty, gas limit, gas used, extra data, mix digest, and nonce columns
+of eth.header_cids and eth.uncle_cids, the base fee and burnt fees columns of eth.header_cids,
+the gas, gas price, value, nonce, type, fee cap, effective gas price, and priority fee columns of eth.transaction_cids
+the gas used, cumulative gas used, and logs bloom columns of eth.receipt_cids, and the value column of the leaf rows of eth.storage_cids
+for rows that were indexed before these columns existed,
+and to index the eth.contracts rows of previously indexed contract creation receipts and the eth.address_transactions links of previously indexed txs
+The values are decoded from the IPLDs already published in public.blocks, so no ethereum node is required`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		backfillMetaData()
+	},
+}
+
+func backfillMetaData() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("metadata.batchSize", "METADATA_BATCH_SIZE")
+	batchSize := uint64(viper.GetInt64("metadata.batchSize"))
+
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	if err := eth.NewMetaDataBackfiller(&db, batchSize).Backfill(); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("metadata backfill finished")
+}
+
+func init() {
+	rootCmd.AddCommand(backfillMetaDataCmd)
+
+	// flags
+	backfillMetaDataCmd.PersistentFlags().Int("metadata-batch-size", 0, "number of rows to decode and update, or of blocks whose gas used and fees to derive, in each db tx")
+
+	// and their .toml config bindings
+	viper.BindPFlag("metadata.batchSize", backfillMetaDataCmd.PersistentFlags().Lookup("metadata-batch-size"))
+}
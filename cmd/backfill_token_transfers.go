@@ -0,0 +1,68 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// backfillTokenTransfersCmd represents the backfill-token-transfers command
+var backfillTokenTransfersCmd = &cobra.Command{
+	Use:   "backfill-token-transfers",
+	Short: "Extract the token transfers of previously indexed logs into eth.token_transfers",
+	Long: `Use this command to extract the ERC-20 and ERC-721 Transfer and ERC-1155 TransferSingle and TransferBatch events
+from the logs in eth.log_cids, e.g. for logs indexed before tokens.transfers was turned on
+Transfers that were already extracted are rewritten in place
+No ethereum node is required`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		backfillTokenTransfers()
+	},
+}
+
+func backfillTokenTransfers() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("tokens.batchSize", "TOKENS_BATCH_SIZE")
+	batchSize := uint64(viper.GetInt64("tokens.batchSize"))
+
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	if err := eth.NewTokenTransferBackfiller(&db, batchSize).Backfill(); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("token transfer backfill finished")
+}
+
+func init() {
+	rootCmd.AddCommand(backfillTokenTransfersCmd)
+
+	// flags
+	backfillTokenTransfersCmd.PersistentFlags().Int("tokens-batch-size", 0, "number of logs to check for transfers in each db tx")
+
+	// and their .toml config bindings
+	viper.BindPFlag("tokens.batchSize", backfillTokenTransfersCmd.PersistentFlags().Lookup("tokens-batch-size"))
+}
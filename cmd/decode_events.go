@@ -0,0 +1,77 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// decodeEventsCmd represents the decode-events command
+var decodeEventsCmd = &cobra.Command{
+	Use:   "decode-events",
+	Short: "Decode the previously indexed logs of contracts with a registered ABI into eth.decoded_events",
+	Long: `Use this command to decode the logs in eth.log_cids emitted by the contracts with an ABI in events.abiDir,
+e.g. for logs indexed before their contract's ABI was registered or after it was changed
+Decoded events are upserted, and the previously decoded events of logs that no longer match their contract's ABI are deleted
+No ethereum node is required`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		decodeEvents()
+	},
+}
+
+func decodeEvents() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("events.abiDir", shared.EVENTS_ABI_DIR)
+	viper.BindEnv("events.batchSize", "EVENTS_BATCH_SIZE")
+	batchSize := uint64(viper.GetInt64("events.batchSize"))
+
+	events, err := eth.NewEventDecoder(viper.GetString("events.abiDir"))
+	if err != nil {
+		logWithCommand.Fatal(err)
+	}
+	if events == nil {
+		logWithCommand.Fatal("no events.abiDir to load contract ABIs from")
+	}
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	if err := eth.NewEventBackfiller(&db, events, batchSize).Backfill(); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("event decoding finished")
+}
+
+func init() {
+	rootCmd.AddCommand(decodeEventsCmd)
+
+	// flags
+	decodeEventsCmd.PersistentFlags().Int("events-batch-size", 0, "number of logs to decode in each db tx")
+
+	// and their .toml config bindings
+	viper.BindPFlag("events.batchSize", decodeEventsCmd.PersistentFlags().Lookup("events-batch-size"))
+}
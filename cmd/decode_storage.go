@@ -0,0 +1,79 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// decodeStorageCmd represents the decode-storage command
+var decodeStorageCmd = &cobra.Command{
+	Use:   "decode-storage",
+	Short: "Decode the previously indexed storage of contracts with a registered storage layout into eth.decoded_storage",
+	Long: `Use this command to decode the storage leaves in eth.storage_cids and the cleared slots in eth.storage_removals
+of the contracts with a storage layout in storage.layoutDir,
+e.g. for storage indexed before their contract's layout was registered or after it was changed
+The previously decoded variables of each slot are replaced
+Storage leaves are decoded from their slot value, so backfill-metadata needs to have filled in the values of storage indexed before they were recorded
+No ethereum node is required`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		decodeStorage()
+	},
+}
+
+func decodeStorage() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("storage.layoutDir", shared.STORAGE_LAYOUT_DIR)
+	viper.BindEnv("storage.batchSize", "STORAGE_BATCH_SIZE")
+	batchSize := uint64(viper.GetInt64("storage.batchSize"))
+
+	storage, err := eth.NewStorageDecoder(viper.GetString("storage.layoutDir"))
+	if err != nil {
+		logWithCommand.Fatal(err)
+	}
+	if storage == nil {
+		logWithCommand.Fatal("no storage.layoutDir to load contract storage layouts from")
+	}
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	if err := eth.NewStorageBackfiller(&db, storage, batchSize).Backfill(); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("storage decoding finished")
+}
+
+func init() {
+	rootCmd.AddCommand(decodeStorageCmd)
+
+	// flags
+	decodeStorageCmd.PersistentFlags().Int("storage-batch-size", 0, "number of storage slots to decode in each db tx")
+
+	// and their .toml config bindings
+	viper.BindPFlag("storage.batchSize", decodeStorageCmd.PersistentFlags().Lookup("storage-batch-size"))
+}
@@ -0,0 +1,75 @@
+// Copyright © 2021 Vulcanize, Inc
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program. If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// pruneCmd represents the prune command
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Prune old block partitions",
+	Long: `Use this command to remove the header, state, and storage partitions that lie entirely below a block height
+By default the partitions are dropped along with their IPLDs and the uncle, transaction, receipt, and account rows
+that reference them. With --prune-detach the partitions are only detached from their parent tables and kept as
+eth.<table>_<lower bound>_detached tables, so that they can be archived or dropped later`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		prune()
+	},
+}
+
+func prune() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	viper.BindEnv("prune.before", "PRUNE_BEFORE")
+	viper.BindEnv("prune.detach", "PRUNE_DETACH")
+	before := uint64(viper.GetInt64("prune.before"))
+	detach := viper.GetBool("prune.detach")
+	if before == 0 {
+		logWithCommand.Fatal("prune requires a block height to prune before")
+	}
+
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	pruned, err := eth.NewDBCleaner(&db).Prune(before, detach)
+	if err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Infof("pruned %d partitions below block %d", len(pruned), before)
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	// flags
+	pruneCmd.PersistentFlags().Int("prune-before", 0, "remove the partitions that lie entirely below this block height")
+	pruneCmd.PersistentFlags().Bool("prune-detach", false, "if true, only detach the partitions instead of dropping them")
+
+	// and their .toml config bindings
+	viper.BindPFlag("prune.before", pruneCmd.PersistentFlags().Lookup("prune-before"))
+	viper.BindPFlag("prune.detach", pruneCmd.PersistentFlags().Lookup("prune-detach"))
+}
@@ -0,0 +1,58 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/node"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/utils"
+	v "github.com/vulcanize/ipld-eth-indexer/version"
+)
+
+// rebuildLatestStateCmd represents the rebuild-latest-state command
+var rebuildLatestStateCmd = &cobra.Command{
+	Use:   "rebuild-latest-state",
+	Short: "Rebuild the eth.latest_accounts and eth.latest_storage tables from the indexed history",
+	Long: `Use this command to recompute the latest canonical state of every account and storage slot
+from the state and storage diffs in eth.state_cids and eth.storage_cids, e.g. before turning on state.latest for a database
+that was indexed without it, or after the indexed history was cleared or pruned
+No ethereum node is required`,
+	Run: func(cmd *cobra.Command, args []string) {
+		subCommand = cmd.CalledAs()
+		logWithCommand = *log.WithField("SubCommand", subCommand)
+		rebuildLatestState()
+	},
+}
+
+func rebuildLatestState() {
+	logWithCommand.Infof("running ipld-eth-indexer version: %s", v.VersionWithMeta)
+	dbConfig := postgres.Config{}
+	dbConfig.Init()
+	db := utils.LoadPostgres(dbConfig, node.Info{}, false)
+	if err := eth.RebuildLatestState(&db); err != nil {
+		logWithCommand.Fatal(err)
+	}
+	logWithCommand.Info("latest state rebuild finished")
+}
+
+func init() {
+	rootCmd.AddCommand(rebuildLatestStateCmd)
+}
@@ -65,13 +65,18 @@ func init() {
 	rootCmd.AddCommand(resyncCmd)
 
 	// flags
-	resyncCmd.PersistentFlags().String("resync-type", "", "which type of data to resync (full|headers|uncles|transactions|receipts|state|storage)")
+	resyncCmd.PersistentFlags().String("resync-type", "", "which type of data to resync (full|headers|uncles|transactions|receipts|state|storage|rewards)")
 	resyncCmd.PersistentFlags().Int("resync-start", 0, "block height to start resync")
 	resyncCmd.PersistentFlags().Int("resync-stop", 0, "block height to stop resync")
 	resyncCmd.PersistentFlags().Int("resync-batch-size", 0, "batch size for http requests")
 	resyncCmd.PersistentFlags().Int("resync-workers", 0, "number of worker goroutines to concurrently make and process http requests")
+	resyncCmd.PersistentFlags().Int("resync-max-queue-mb", 0, "max total size of the fetched payloads held by the workers (in MB)")
+	resyncCmd.PersistentFlags().Bool("resync-auto-tune", false, "turn on automatic tuning of the number of active workers")
+	resyncCmd.PersistentFlags().Int("resync-min-workers", 1, "min number of active workers when auto tuning")
+	resyncCmd.PersistentFlags().Int("resync-max-workers", 0, "max number of active workers when auto tuning")
 	resyncCmd.PersistentFlags().Bool("resync-clear-old-cache", false, "if true, clear out old data of the provided type within the resync range before resyncing (warning: clearing out data will delete any rows that FK reference it")
 	resyncCmd.PersistentFlags().Bool("resync-reset-validation", false, "if true, reset times_validated of headers in this range to 0")
+	resyncCmd.PersistentFlags().Bool("resync-force-reindex", false, "if true, rewrite all rows for blocks that are already completely indexed instead of only incrementing their times_validated")
 	resyncCmd.PersistentFlags().Int("resync-timeout", 15, "timeout used for resync http requests (in seconds)")
 	resyncCmd.PersistentFlags().String("eth-http-path", "", "http url for ethereum node")
 
@@ -81,8 +86,13 @@ func init() {
 	viper.BindPFlag("resync.stop", resyncCmd.PersistentFlags().Lookup("resync-stop"))
 	viper.BindPFlag("resync.batchSize", resyncCmd.PersistentFlags().Lookup("resync-batch-size"))
 	viper.BindPFlag("resync.workers", resyncCmd.PersistentFlags().Lookup("resync-workers"))
+	viper.BindPFlag("resync.maxQueueMB", resyncCmd.PersistentFlags().Lookup("resync-max-queue-mb"))
+	viper.BindPFlag("resync.autoTune", resyncCmd.PersistentFlags().Lookup("resync-auto-tune"))
+	viper.BindPFlag("resync.minWorkers", resyncCmd.PersistentFlags().Lookup("resync-min-workers"))
+	viper.BindPFlag("resync.maxWorkers", resyncCmd.PersistentFlags().Lookup("resync-max-workers"))
 	viper.BindPFlag("resync.clearOldCache", resyncCmd.PersistentFlags().Lookup("resync-clear-old-cache"))
 	viper.BindPFlag("resync.resetValidation", resyncCmd.PersistentFlags().Lookup("resync-reset-validation"))
+	viper.BindPFlag("resync.forceReindex", resyncCmd.PersistentFlags().Lookup("resync-force-reindex"))
 	viper.BindPFlag("resync.timeout", resyncCmd.PersistentFlags().Lookup("resync-timeout"))
 	viper.BindPFlag("ethereum.httpPath", resyncCmd.PersistentFlags().Lookup("eth-http-path"))
 }
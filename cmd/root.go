@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/vulcanize/ipld-eth-indexer/pkg/prom"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
 )
 
 var (
@@ -69,6 +70,11 @@ func initFuncs(cmd *cobra.Command, args []string) {
 		prom.Init()
 	}
 
+	viper.BindEnv("ipld.cacheSize", "IPLD_CACHE_SIZE")
+	if err := shared.InitKeyCache(viper.GetInt("ipld.cacheSize")); err != nil {
+		log.Fatal("Could not initialize ipld key cache: ", err)
+	}
+
 	if viper.GetBool("prom.http") {
 		addr := fmt.Sprintf(
 			"%s:%s",
@@ -115,6 +121,7 @@ func init() {
 	rootCmd.PersistentFlags().String("eth-genesis-block", "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", "eth genesis block hash")
 	rootCmd.PersistentFlags().String("eth-network-id", "1", "eth network id")
 	rootCmd.PersistentFlags().String("eth-chain-id", "1", "eth chain id")
+	rootCmd.PersistentFlags().String("eth-chain-config", "", "genesis or chain config .json file for chains other than mainnet, ropsten, rinkeby, and goerli")
 
 	rootCmd.PersistentFlags().Bool("prom-http", false, "enable prometheus http service")
 	rootCmd.PersistentFlags().String("prom-http-addr", "127.0.0.1", "prometheus http host")
@@ -122,6 +129,16 @@ func init() {
 
 	rootCmd.PersistentFlags().Bool("metrics", false, "enable metrics")
 
+	rootCmd.PersistentFlags().Int("ipld-cache-size", 0, "number of recently published IPLD keys to remember so that they are not re-sent to Postgres (0 disables the cache)")
+
+	rootCmd.PersistentFlags().Bool("state-latest", false, "keep the eth.latest_accounts and eth.latest_storage tables up to date while indexing")
+
+	rootCmd.PersistentFlags().String("events-abi-dir", "", "directory of contract ABI .json files to decode logs into eth.decoded_events with")
+
+	rootCmd.PersistentFlags().Bool("token-transfers", false, "extract the ERC-20, ERC-721, and ERC-1155 transfer events into eth.token_transfers while indexing")
+
+	rootCmd.PersistentFlags().String("storage-layout-dir", "", "directory of contract storage layout .json files to decode storage into eth.decoded_storage with")
+
 	// and their .toml config bindings
 	viper.BindPFlag("database.name", rootCmd.PersistentFlags().Lookup("database-name"))
 	viper.BindPFlag("database.port", rootCmd.PersistentFlags().Lookup("database-port"))
@@ -137,12 +154,23 @@ func init() {
 	viper.BindPFlag("ethereum.genesisBlock", rootCmd.PersistentFlags().Lookup("eth-genesis-block"))
 	viper.BindPFlag("ethereum.networkID", rootCmd.PersistentFlags().Lookup("eth-network-id"))
 	viper.BindPFlag("ethereum.chainID", rootCmd.PersistentFlags().Lookup("eth-chain-id"))
+	viper.BindPFlag("ethereum.chainConfig", rootCmd.PersistentFlags().Lookup("eth-chain-config"))
 
 	viper.BindPFlag("prom.http", rootCmd.PersistentFlags().Lookup("prom-http"))
 	viper.BindPFlag("prom.http.addr", rootCmd.PersistentFlags().Lookup("prom-http-addr"))
 	viper.BindPFlag("prom.http.port", rootCmd.PersistentFlags().Lookup("prom-http-port"))
 
 	viper.BindPFlag("metrics", rootCmd.PersistentFlags().Lookup("metrics"))
+
+	viper.BindPFlag("ipld.cacheSize", rootCmd.PersistentFlags().Lookup("ipld-cache-size"))
+
+	viper.BindPFlag("state.latest", rootCmd.PersistentFlags().Lookup("state-latest"))
+
+	viper.BindPFlag("events.abiDir", rootCmd.PersistentFlags().Lookup("events-abi-dir"))
+
+	viper.BindPFlag("tokens.transfers", rootCmd.PersistentFlags().Lookup("token-transfers"))
+
+	viper.BindPFlag("storage.layoutDir", rootCmd.PersistentFlags().Lookup("storage-layout-dir"))
 }
 
 func initConfig() {
@@ -78,9 +78,17 @@ func init() {
 
 	// flags
 	syncCmd.PersistentFlags().Int("sync-workers", 0, "how many worker goroutines to publish and index data")
+	syncCmd.PersistentFlags().Int("sync-max-queue-mb", 0, "max total size of the statediff payloads waiting to be processed (in MB)")
+	syncCmd.PersistentFlags().Bool("sync-auto-tune", false, "turn on automatic tuning of the number of active workers")
+	syncCmd.PersistentFlags().Int("sync-min-workers", 1, "min number of active workers when auto tuning")
+	syncCmd.PersistentFlags().Int("sync-max-workers", 0, "max number of active workers when auto tuning")
 	syncCmd.PersistentFlags().String("eth-ws-path", "", "ws url for ethereum node")
 
 	// and their .toml config bindings
 	viper.BindPFlag("sync.workers", syncCmd.PersistentFlags().Lookup("sync-workers"))
+	viper.BindPFlag("sync.maxQueueMB", syncCmd.PersistentFlags().Lookup("sync-max-queue-mb"))
+	viper.BindPFlag("sync.autoTune", syncCmd.PersistentFlags().Lookup("sync-auto-tune"))
+	viper.BindPFlag("sync.minWorkers", syncCmd.PersistentFlags().Lookup("sync-min-workers"))
+	viper.BindPFlag("sync.maxWorkers", syncCmd.PersistentFlags().Lookup("sync-max-workers"))
 	viper.BindPFlag("ethereum.wsPath", syncCmd.PersistentFlags().Lookup("eth-ws-path"))
 }
@@ -0,0 +1,613 @@
+-- +goose Up
+-- header_cids, state_cids, and storage_cids are recreated as tables partitioned by ranges of block number
+-- state_cids and storage_cids carry the block_number so that they can be partitioned alongside their headers
+-- uncle_cids, transaction_cids, and state_accounts can not reference a partitioned table by id alone, so their foreign keys are dropped
+-- and the cleaner removes their rows explicitly
+
+-- +goose StatementBegin
+-- returns the number of blocks covered by each header, state, and storage partition
+CREATE FUNCTION eth.block_partition_width() RETURNS BIGINT AS $$
+SELECT 100000::BIGINT;
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns the lower bound of the partition that holds the provided block number
+CREATE FUNCTION eth.block_partition_lower(height BIGINT) RETURNS BIGINT AS $$
+SELECT height - height % eth.block_partition_width();
+$$ LANGUAGE SQL IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- creates the header, state, and storage partitions that hold the provided block number if they do not exist yet
+CREATE FUNCTION eth.create_block_partitions(height BIGINT) RETURNS VOID AS $$
+DECLARE
+  lower_bound BIGINT = eth.block_partition_lower(height);
+  upper_bound BIGINT = lower_bound + eth.block_partition_width();
+  parent TEXT;
+BEGIN
+  -- serialize writers racing to create the same partitions
+  PERFORM pg_advisory_xact_lock(hashtext('eth.create_block_partitions'));
+  FOREACH parent IN ARRAY ARRAY['header_cids', 'state_cids', 'storage_cids'] LOOP
+    EXECUTE format('CREATE TABLE IF NOT EXISTS eth.%I PARTITION OF eth.%I FOR VALUES FROM (%s) TO (%s)',
+                   parent || '_' || lower_bound, parent, lower_bound, upper_bound);
+  END LOOP;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- detaches the header, state, and storage partitions that hold the provided block number
+-- the detached tables lose their foreign keys and are either dropped or renamed with a _detached suffix so they can be archived
+-- returns false if there were no partitions to detach
+CREATE FUNCTION eth.detach_block_partitions(height BIGINT, drop_tables BOOLEAN) RETURNS BOOLEAN AS $$
+DECLARE
+  lower_bound BIGINT = eth.block_partition_lower(height);
+  parent TEXT;
+  partition_name TEXT;
+  fk_name TEXT;
+  detached BOOLEAN = FALSE;
+BEGIN
+  -- detach the referencing partitions first
+  FOREACH parent IN ARRAY ARRAY['storage_cids', 'state_cids', 'header_cids'] LOOP
+    partition_name = parent || '_' || lower_bound;
+    IF to_regclass(format('eth.%I', partition_name)) IS NULL THEN
+      CONTINUE;
+    END IF;
+    EXECUTE format('ALTER TABLE eth.%I DETACH PARTITION eth.%I', parent, partition_name);
+    FOR fk_name IN SELECT conname FROM pg_constraint
+                   WHERE conrelid = format('eth.%I', partition_name)::regclass AND contype = 'f' LOOP
+      EXECUTE format('ALTER TABLE eth.%I DROP CONSTRAINT %I', partition_name, fk_name);
+    END LOOP;
+    IF drop_tables THEN
+      EXECUTE format('DROP TABLE eth.%I', partition_name);
+    ELSE
+      EXECUTE format('ALTER TABLE eth.%I RENAME TO %I', partition_name, partition_name || '_detached');
+    END IF;
+    detached = TRUE;
+  END LOOP;
+  RETURN detached;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- objects that depend on the eth.header_cids row type are recreated below
+DROP FUNCTION canonical_header_id;
+DROP FUNCTION canonical_header_from_array;
+DROP FUNCTION has_child;
+DROP TYPE child_result;
+
+ALTER TABLE eth.uncle_cids DROP CONSTRAINT uncle_cids_header_id_fkey;
+ALTER TABLE eth.transaction_cids DROP CONSTRAINT transaction_cids_header_id_fkey;
+ALTER TABLE eth.state_accounts DROP CONSTRAINT state_accounts_state_id_fkey;
+
+ALTER TABLE eth.header_cids RENAME TO header_cids_old;
+ALTER TABLE eth.header_cids_old RENAME CONSTRAINT header_cids_pkey TO header_cids_old_pkey;
+ALTER TABLE eth.header_cids_old RENAME CONSTRAINT header_cids_block_number_block_hash_key TO header_cids_old_block_number_block_hash_key;
+ALTER SEQUENCE eth.header_cids_id_seq OWNED BY NONE;
+
+ALTER TABLE eth.state_cids RENAME TO state_cids_old;
+ALTER TABLE eth.state_cids_old RENAME CONSTRAINT state_cids_pkey TO state_cids_old_pkey;
+ALTER TABLE eth.state_cids_old RENAME CONSTRAINT state_cids_header_id_state_path_key TO state_cids_old_header_id_state_path_key;
+ALTER SEQUENCE eth.state_cids_id_seq OWNED BY NONE;
+
+ALTER TABLE eth.storage_cids RENAME TO storage_cids_old;
+ALTER TABLE eth.storage_cids_old RENAME CONSTRAINT storage_cids_pkey TO storage_cids_old_pkey;
+ALTER TABLE eth.storage_cids_old RENAME CONSTRAINT storage_cids_state_id_storage_path_key TO storage_cids_old_state_id_storage_path_key;
+ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY NONE;
+
+CREATE TABLE eth.header_cids (
+  id                    INTEGER NOT NULL DEFAULT nextval('eth.header_cids_id_seq'),
+  block_number          BIGINT NOT NULL,
+  block_hash            VARCHAR(66) NOT NULL,
+  parent_hash           VARCHAR(66) NOT NULL,
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  td                    NUMERIC NOT NULL,
+  node_id               INTEGER NOT NULL REFERENCES nodes (id) ON DELETE CASCADE,
+  reward                NUMERIC NOT NULL,
+  state_root            VARCHAR(66) NOT NULL,
+  tx_root               VARCHAR(66) NOT NULL,
+  receipt_root          VARCHAR(66) NOT NULL,
+  uncle_root            VARCHAR(66) NOT NULL,
+  bloom                 BYTEA NOT NULL,
+  timestamp             NUMERIC NOT NULL,
+  times_validated       INTEGER NOT NULL DEFAULT 1,
+  PRIMARY KEY (id, block_number),
+  UNIQUE (block_number, block_hash)
+) PARTITION BY RANGE (block_number);
+ALTER SEQUENCE eth.header_cids_id_seq OWNED BY eth.header_cids.id;
+
+CREATE TABLE eth.state_cids (
+  id                    BIGINT NOT NULL DEFAULT nextval('eth.state_cids_id_seq'),
+  header_id             INTEGER NOT NULL,
+  block_number          BIGINT NOT NULL,
+  state_leaf_key        VARCHAR(66),
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  state_path            BYTEA,
+  node_type             INTEGER NOT NULL,
+  diff                  BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (id, block_number),
+  UNIQUE (header_id, state_path, block_number),
+  FOREIGN KEY (header_id, block_number) REFERENCES eth.header_cids (id, block_number) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+) PARTITION BY RANGE (block_number);
+ALTER SEQUENCE eth.state_cids_id_seq OWNED BY eth.state_cids.id;
+
+CREATE TABLE eth.storage_cids (
+  id                    BIGINT NOT NULL DEFAULT nextval('eth.storage_cids_id_seq'),
+  state_id              BIGINT NOT NULL,
+  block_number          BIGINT NOT NULL,
+  storage_leaf_key      VARCHAR(66),
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  storage_path          BYTEA,
+  node_type             INTEGER NOT NULL,
+  diff                  BOOLEAN NOT NULL DEFAULT FALSE,
+  PRIMARY KEY (id, block_number),
+  UNIQUE (state_id, storage_path, block_number),
+  FOREIGN KEY (state_id, block_number) REFERENCES eth.state_cids (id, block_number) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED
+) PARTITION BY RANGE (block_number);
+ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY eth.storage_cids.id;
+
+-- +goose StatementBegin
+-- create partitions for the data that already exists
+DO $$
+DECLARE
+  lower_bound BIGINT;
+BEGIN
+  FOR lower_bound IN SELECT DISTINCT eth.block_partition_lower(block_number) FROM eth.header_cids_old LOOP
+    PERFORM eth.create_block_partitions(lower_bound);
+  END LOOP;
+END
+$$;
+-- +goose StatementEnd
+
+INSERT INTO eth.header_cids (id, block_number, block_hash, parent_hash, cid, mh_key, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, times_validated)
+SELECT id, block_number, block_hash, parent_hash, cid, mh_key, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, times_validated
+FROM eth.header_cids_old;
+
+INSERT INTO eth.state_cids (id, header_id, block_number, state_leaf_key, cid, mh_key, state_path, node_type, diff)
+SELECT state_cids_old.id, header_id, block_number, state_leaf_key, state_cids_old.cid, state_cids_old.mh_key, state_path, node_type, diff
+FROM eth.state_cids_old
+INNER JOIN eth.header_cids_old ON (state_cids_old.header_id = header_cids_old.id);
+
+INSERT INTO eth.storage_cids (id, state_id, block_number, storage_leaf_key, cid, mh_key, storage_path, node_type, diff)
+SELECT storage_cids_old.id, state_id, block_number, storage_leaf_key, storage_cids_old.cid, storage_cids_old.mh_key, storage_path, storage_cids_old.node_type, storage_cids_old.diff
+FROM eth.storage_cids_old
+INNER JOIN eth.state_cids ON (storage_cids_old.state_id = state_cids.id);
+
+DROP TABLE eth.storage_cids_old;
+DROP TABLE eth.state_cids_old;
+DROP TABLE eth.header_cids_old;
+
+-- header indexes
+CREATE INDEX block_number_index ON eth.header_cids USING brin (block_number);
+
+CREATE INDEX block_hash_index ON eth.header_cids USING btree (block_hash);
+
+CREATE INDEX header_cid_index ON eth.header_cids USING btree (cid);
+
+CREATE INDEX header_mh_index ON eth.header_cids USING btree (mh_key);
+
+CREATE INDEX state_root_index ON eth.header_cids USING btree (state_root);
+
+CREATE INDEX timestamp_index ON eth.header_cids USING brin (timestamp);
+
+-- state node indexes
+CREATE INDEX state_header_id_index ON eth.state_cids USING btree (header_id);
+
+CREATE INDEX state_leaf_key_index ON eth.state_cids USING btree (state_leaf_key);
+
+CREATE INDEX state_cid_index ON eth.state_cids USING btree (cid);
+
+CREATE INDEX state_mh_index ON eth.state_cids USING btree (mh_key);
+
+CREATE INDEX state_path_index ON eth.state_cids USING btree (state_path);
+
+-- storage node indexes
+CREATE INDEX storage_state_id_index ON eth.storage_cids USING btree (state_id);
+
+CREATE INDEX storage_leaf_key_index ON eth.storage_cids USING btree (storage_leaf_key);
+
+CREATE INDEX storage_cid_index ON eth.storage_cids USING btree (cid);
+
+CREATE INDEX storage_mh_index ON eth.storage_cids USING btree (mh_key);
+
+CREATE INDEX storage_path_index ON eth.storage_cids USING btree (storage_path);
+
+COMMENT ON TABLE eth.header_cids IS E'@name EthHeaderCids';
+COMMENT ON COLUMN eth.header_cids.node_id IS E'@name EthNodeID';
+
+CREATE TRIGGER header_cids_ai
+    after INSERT ON eth.header_cids
+    for each row
+    execute procedure eth.graphql_subscription('header_cids', 'id');
+
+CREATE TRIGGER state_cids_ai
+    after INSERT ON eth.state_cids
+    for each row
+    execute procedure eth.graphql_subscription('state_cids', 'id');
+
+CREATE TRIGGER storage_cids_ai
+    after INSERT ON eth.storage_cids
+    for each row
+    execute procedure eth.graphql_subscription('storage_cids', 'id');
+
+-- +goose StatementBegin
+CREATE TYPE child_result AS (
+    has_child BOOLEAN,
+    children eth.header_cids[]
+);
+
+CREATE OR REPLACE FUNCTION has_child(hash VARCHAR(66), height BIGINT) RETURNS child_result AS
+$BODY$
+DECLARE
+child_height INT;
+  temp_child eth.header_cids;
+  new_child_result child_result;
+BEGIN
+  child_height = height + 1;
+  -- short circuit if there are no children
+SELECT exists(SELECT 1
+              FROM eth.header_cids
+              WHERE parent_hash = hash
+                AND block_number = child_height
+              LIMIT 1)
+INTO new_child_result.has_child;
+-- collect all the children for this header
+IF new_child_result.has_child THEN
+    FOR temp_child IN
+SELECT * FROM eth.header_cids WHERE parent_hash = hash AND block_number = child_height
+    LOOP
+      new_child_result.children = array_append(new_child_result.children, temp_child);
+END LOOP;
+END IF;
+RETURN new_child_result;
+END
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_from_array(headers eth.header_cids[]) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+canonical_header eth.header_cids;
+  canonical_child eth.header_cids;
+  header eth.header_cids;
+  current_child_result child_result;
+  child_headers eth.header_cids[];
+  current_header_with_child eth.header_cids;
+  has_children_count INT DEFAULT 0;
+BEGIN
+  -- for each header in the provided set
+  FOREACH header IN ARRAY headers
+  LOOP
+    -- check if it has any children
+    current_child_result = has_child(header.block_hash, header.block_number);
+    IF current_child_result.has_child THEN
+      -- if it does, take note
+      has_children_count = has_children_count + 1;
+      current_header_with_child = header;
+      -- and add the children to the growing set of child headers
+      child_headers = array_cat(child_headers, current_child_result.children);
+END IF;
+END LOOP;
+  -- if none of the headers had children, none is more canonical than the other
+  IF has_children_count = 0 THEN
+    -- return the first one selected
+SELECT * INTO canonical_header FROM unnest(headers) LIMIT 1;
+-- if only one header had children, it can be considered the heaviest/canonical header of the set
+ELSIF has_children_count = 1 THEN
+    -- return the only header with a child
+    canonical_header = current_header_with_child;
+  -- if there are multiple headers with children
+ELSE
+    -- find the canonical header from the child set
+    canonical_child = canonical_header_from_array(child_headers);
+    -- the header that is parent to this header, is the canonical header at this level
+SELECT * INTO canonical_header FROM unnest(headers)
+WHERE block_hash = canonical_child.parent_hash;
+END IF;
+RETURN canonical_header;
+END
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_id(height BIGINT) RETURNS INTEGER AS
+$BODY$
+DECLARE
+canonical_header eth.header_cids;
+  headers eth.header_cids[];
+  header_count INT;
+  temp_header eth.header_cids;
+BEGIN
+  -- collect all headers at this height
+FOR temp_header IN
+SELECT * FROM eth.header_cids WHERE block_number = height
+    LOOP
+    headers = array_append(headers, temp_header);
+END LOOP;
+  -- count the number of headers collected
+  header_count = array_length(headers, 1);
+  -- if we have less than 1 header, return NULL
+  IF header_count IS NULL OR header_count < 1 THEN
+    RETURN NULL;
+  -- if we have one header, return its id
+  ELSIF header_count = 1 THEN
+    RETURN headers[1].id;
+  -- if we have multiple headers we need to determine which one is canonical
+ELSE
+    canonical_header = canonical_header_from_array(headers);
+RETURN canonical_header.id;
+END IF;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION canonical_header_id;
+DROP FUNCTION canonical_header_from_array;
+DROP FUNCTION has_child;
+DROP TYPE child_result;
+
+ALTER TABLE eth.header_cids RENAME TO header_cids_partitioned;
+ALTER TABLE eth.header_cids_partitioned RENAME CONSTRAINT header_cids_pkey TO header_cids_partitioned_pkey;
+ALTER TABLE eth.header_cids_partitioned RENAME CONSTRAINT header_cids_block_number_block_hash_key TO header_cids_partitioned_block_number_block_hash_key;
+ALTER SEQUENCE eth.header_cids_id_seq OWNED BY NONE;
+
+ALTER TABLE eth.state_cids RENAME TO state_cids_partitioned;
+ALTER TABLE eth.state_cids_partitioned RENAME CONSTRAINT state_cids_pkey TO state_cids_partitioned_pkey;
+ALTER TABLE eth.state_cids_partitioned RENAME CONSTRAINT state_cids_header_id_state_path_block_number_key TO state_cids_partitioned_header_id_state_path_block_number_key;
+ALTER SEQUENCE eth.state_cids_id_seq OWNED BY NONE;
+
+ALTER TABLE eth.storage_cids RENAME TO storage_cids_partitioned;
+ALTER TABLE eth.storage_cids_partitioned RENAME CONSTRAINT storage_cids_pkey TO storage_cids_partitioned_pkey;
+ALTER TABLE eth.storage_cids_partitioned RENAME CONSTRAINT storage_cids_state_id_storage_path_block_number_key TO storage_cids_partitioned_state_id_storage_path_block_number_key;
+ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY NONE;
+
+DROP INDEX eth.storage_path_index;
+DROP INDEX eth.storage_mh_index;
+DROP INDEX eth.storage_cid_index;
+DROP INDEX eth.storage_leaf_key_index;
+DROP INDEX eth.storage_state_id_index;
+DROP INDEX eth.state_path_index;
+DROP INDEX eth.state_mh_index;
+DROP INDEX eth.state_cid_index;
+DROP INDEX eth.state_leaf_key_index;
+DROP INDEX eth.state_header_id_index;
+DROP INDEX eth.timestamp_index;
+DROP INDEX eth.state_root_index;
+DROP INDEX eth.header_mh_index;
+DROP INDEX eth.header_cid_index;
+DROP INDEX eth.block_hash_index;
+DROP INDEX eth.block_number_index;
+
+CREATE TABLE eth.header_cids (
+  id                    INTEGER PRIMARY KEY DEFAULT nextval('eth.header_cids_id_seq'),
+  block_number          BIGINT NOT NULL,
+  block_hash            VARCHAR(66) NOT NULL,
+  parent_hash           VARCHAR(66) NOT NULL,
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  td                    NUMERIC NOT NULL,
+  node_id               INTEGER NOT NULL REFERENCES nodes (id) ON DELETE CASCADE,
+  reward                NUMERIC NOT NULL,
+  state_root            VARCHAR(66) NOT NULL,
+  tx_root               VARCHAR(66) NOT NULL,
+  receipt_root          VARCHAR(66) NOT NULL,
+  uncle_root            VARCHAR(66) NOT NULL,
+  bloom                 BYTEA NOT NULL,
+  timestamp             NUMERIC NOT NULL,
+  times_validated       INTEGER NOT NULL DEFAULT 1,
+  UNIQUE (block_number, block_hash)
+);
+ALTER SEQUENCE eth.header_cids_id_seq OWNED BY eth.header_cids.id;
+
+CREATE TABLE eth.state_cids (
+  id                    BIGINT PRIMARY KEY DEFAULT nextval('eth.state_cids_id_seq'),
+  header_id             INTEGER NOT NULL REFERENCES eth.header_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  state_leaf_key        VARCHAR(66),
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  state_path            BYTEA,
+  node_type             INTEGER NOT NULL,
+  diff                  BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (header_id, state_path)
+);
+ALTER SEQUENCE eth.state_cids_id_seq OWNED BY eth.state_cids.id;
+
+CREATE TABLE eth.storage_cids (
+  id                    BIGINT PRIMARY KEY DEFAULT nextval('eth.storage_cids_id_seq'),
+  state_id              BIGINT NOT NULL REFERENCES eth.state_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  storage_leaf_key      VARCHAR(66),
+  cid                   TEXT NOT NULL,
+  mh_key                TEXT NOT NULL REFERENCES public.blocks (key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  storage_path          BYTEA,
+  node_type             INTEGER NOT NULL,
+  diff                  BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (state_id, storage_path)
+);
+ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY eth.storage_cids.id;
+
+INSERT INTO eth.header_cids (id, block_number, block_hash, parent_hash, cid, mh_key, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, times_validated)
+SELECT id, block_number, block_hash, parent_hash, cid, mh_key, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, times_validated
+FROM eth.header_cids_partitioned;
+
+INSERT INTO eth.state_cids (id, header_id, state_leaf_key, cid, mh_key, state_path, node_type, diff)
+SELECT id, header_id, state_leaf_key, cid, mh_key, state_path, node_type, diff
+FROM eth.state_cids_partitioned;
+
+INSERT INTO eth.storage_cids (id, state_id, storage_leaf_key, cid, mh_key, storage_path, node_type, diff)
+SELECT id, state_id, storage_leaf_key, cid, mh_key, storage_path, node_type, diff
+FROM eth.storage_cids_partitioned;
+
+DROP TABLE eth.storage_cids_partitioned;
+DROP TABLE eth.state_cids_partitioned;
+DROP TABLE eth.header_cids_partitioned;
+
+ALTER TABLE eth.uncle_cids ADD CONSTRAINT uncle_cids_header_id_fkey
+  FOREIGN KEY (header_id) REFERENCES eth.header_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE eth.transaction_cids ADD CONSTRAINT transaction_cids_header_id_fkey
+  FOREIGN KEY (header_id) REFERENCES eth.header_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE eth.state_accounts ADD CONSTRAINT state_accounts_state_id_fkey
+  FOREIGN KEY (state_id) REFERENCES eth.state_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+CREATE INDEX block_number_index ON eth.header_cids USING brin (block_number);
+CREATE INDEX block_hash_index ON eth.header_cids USING btree (block_hash);
+CREATE INDEX header_cid_index ON eth.header_cids USING btree (cid);
+CREATE INDEX header_mh_index ON eth.header_cids USING btree (mh_key);
+CREATE INDEX state_root_index ON eth.header_cids USING btree (state_root);
+CREATE INDEX timestamp_index ON eth.header_cids USING brin (timestamp);
+CREATE INDEX state_header_id_index ON eth.state_cids USING btree (header_id);
+CREATE INDEX state_leaf_key_index ON eth.state_cids USING btree (state_leaf_key);
+CREATE INDEX state_cid_index ON eth.state_cids USING btree (cid);
+CREATE INDEX state_mh_index ON eth.state_cids USING btree (mh_key);
+CREATE INDEX state_path_index ON eth.state_cids USING btree (state_path);
+CREATE INDEX storage_state_id_index ON eth.storage_cids USING btree (state_id);
+CREATE INDEX storage_leaf_key_index ON eth.storage_cids USING btree (storage_leaf_key);
+CREATE INDEX storage_cid_index ON eth.storage_cids USING btree (cid);
+CREATE INDEX storage_mh_index ON eth.storage_cids USING btree (mh_key);
+CREATE INDEX storage_path_index ON eth.storage_cids USING btree (storage_path);
+
+COMMENT ON TABLE eth.header_cids IS E'@name EthHeaderCids';
+COMMENT ON COLUMN eth.header_cids.node_id IS E'@name EthNodeID';
+
+CREATE TRIGGER header_cids_ai
+    after INSERT ON eth.header_cids
+    for each row
+    execute procedure eth.graphql_subscription('header_cids', 'id');
+
+CREATE TRIGGER state_cids_ai
+    after INSERT ON eth.state_cids
+    for each row
+    execute procedure eth.graphql_subscription('state_cids', 'id');
+
+CREATE TRIGGER storage_cids_ai
+    after INSERT ON eth.storage_cids
+    for each row
+    execute procedure eth.graphql_subscription('storage_cids', 'id');
+
+-- +goose StatementBegin
+CREATE TYPE child_result AS (
+    has_child BOOLEAN,
+    children eth.header_cids[]
+);
+
+CREATE OR REPLACE FUNCTION has_child(hash VARCHAR(66), height BIGINT) RETURNS child_result AS
+$BODY$
+DECLARE
+child_height INT;
+  temp_child eth.header_cids;
+  new_child_result child_result;
+BEGIN
+  child_height = height + 1;
+  -- short circuit if there are no children
+SELECT exists(SELECT 1
+              FROM eth.header_cids
+              WHERE parent_hash = hash
+                AND block_number = child_height
+              LIMIT 1)
+INTO new_child_result.has_child;
+-- collect all the children for this header
+IF new_child_result.has_child THEN
+    FOR temp_child IN
+SELECT * FROM eth.header_cids WHERE parent_hash = hash AND block_number = child_height
+    LOOP
+      new_child_result.children = array_append(new_child_result.children, temp_child);
+END LOOP;
+END IF;
+RETURN new_child_result;
+END
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_from_array(headers eth.header_cids[]) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+canonical_header eth.header_cids;
+  canonical_child eth.header_cids;
+  header eth.header_cids;
+  current_child_result child_result;
+  child_headers eth.header_cids[];
+  current_header_with_child eth.header_cids;
+  has_children_count INT DEFAULT 0;
+BEGIN
+  -- for each header in the provided set
+  FOREACH header IN ARRAY headers
+  LOOP
+    -- check if it has any children
+    current_child_result = has_child(header.block_hash, header.block_number);
+    IF current_child_result.has_child THEN
+      -- if it does, take note
+      has_children_count = has_children_count + 1;
+      current_header_with_child = header;
+      -- and add the children to the growing set of child headers
+      child_headers = array_cat(child_headers, current_child_result.children);
+END IF;
+END LOOP;
+  -- if none of the headers had children, none is more canonical than the other
+  IF has_children_count = 0 THEN
+    -- return the first one selected
+SELECT * INTO canonical_header FROM unnest(headers) LIMIT 1;
+-- if only one header had children, it can be considered the heaviest/canonical header of the set
+ELSIF has_children_count = 1 THEN
+    -- return the only header with a child
+    canonical_header = current_header_with_child;
+  -- if there are multiple headers with children
+ELSE
+    -- find the canonical header from the child set
+    canonical_child = canonical_header_from_array(child_headers);
+    -- the header that is parent to this header, is the canonical header at this level
+SELECT * INTO canonical_header FROM unnest(headers)
+WHERE block_hash = canonical_child.parent_hash;
+END IF;
+RETURN canonical_header;
+END
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_id(height BIGINT) RETURNS INTEGER AS
+$BODY$
+DECLARE
+canonical_header eth.header_cids;
+  headers eth.header_cids[];
+  header_count INT;
+  temp_header eth.header_cids;
+BEGIN
+  -- collect all headers at this height
+FOR temp_header IN
+SELECT * FROM eth.header_cids WHERE block_number = height
+    LOOP
+    headers = array_append(headers, temp_header);
+END LOOP;
+  -- count the number of headers collected
+  header_count = array_length(headers, 1);
+  -- if we have less than 1 header, return NULL
+  IF header_count IS NULL OR header_count < 1 THEN
+    RETURN NULL;
+  -- if we have one header, return its id
+  ELSIF header_count = 1 THEN
+    RETURN headers[1].id;
+  -- if we have multiple headers we need to determine which one is canonical
+ELSE
+    canonical_header = canonical_header_from_array(headers);
+RETURN canonical_header.id;
+END IF;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+DROP FUNCTION eth.detach_block_partitions;
+DROP FUNCTION eth.create_block_partitions;
+DROP FUNCTION eth.block_partition_lower;
+DROP FUNCTION eth.block_partition_width;
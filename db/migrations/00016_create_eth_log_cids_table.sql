@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE eth.log_cids (
+  id                    SERIAL PRIMARY KEY,
+  receipt_id            INTEGER NOT NULL REFERENCES eth.receipt_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  log_index             INTEGER NOT NULL,
+  address               VARCHAR(66) NOT NULL,
+  topic0                VARCHAR(66),
+  topic1                VARCHAR(66),
+  topic2                VARCHAR(66),
+  topic3                VARCHAR(66),
+  log_data              BYTEA,
+  UNIQUE (receipt_id, log_index)
+);
+
+CREATE INDEX log_rct_id_index ON eth.log_cids USING btree (receipt_id);
+
+CREATE INDEX log_address_index ON eth.log_cids USING btree (address);
+
+CREATE INDEX log_topic0_index ON eth.log_cids USING btree (topic0);
+
+CREATE INDEX log_topic1_index ON eth.log_cids USING btree (topic1);
+
+CREATE INDEX log_topic2_index ON eth.log_cids USING btree (topic2);
+
+CREATE INDEX log_topic3_index ON eth.log_cids USING btree (topic3);
+
+COMMENT ON TABLE eth.log_cids IS E'@name EthLogCids';
+
+CREATE TRIGGER log_cids_ai
+    after INSERT ON eth.log_cids
+    for each row
+    execute procedure eth.graphql_subscription('log_cids', 'id');
+
+-- +goose Down
+DROP TRIGGER log_cids_ai ON eth.log_cids;
+DROP TABLE eth.log_cids;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- these columns are NULL for rows indexed before they were added, until they are filled in with the backfill-metadata command
+ALTER TABLE eth.transaction_cids
+ADD COLUMN gas BIGINT,
+ADD COLUMN gas_price NUMERIC,
+ADD COLUMN value NUMERIC,
+ADD COLUMN nonce BIGINT,
+ADD COLUMN tx_type INTEGER;
+
+ALTER TABLE eth.receipt_cids
+ADD COLUMN gas_used BIGINT,
+ADD COLUMN cumulative_gas_used BIGINT,
+ADD COLUMN log_bloom BYTEA;
+
+-- +goose Down
+ALTER TABLE eth.receipt_cids
+DROP COLUMN log_bloom,
+DROP COLUMN cumulative_gas_used,
+DROP COLUMN gas_used;
+
+ALTER TABLE eth.transaction_cids
+DROP COLUMN tx_type,
+DROP COLUMN nonce,
+DROP COLUMN value,
+DROP COLUMN gas_price,
+DROP COLUMN gas;
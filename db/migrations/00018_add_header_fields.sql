@@ -0,0 +1,42 @@
+-- +goose Up
+-- these columns are NULL for rows indexed before they were added, until they are filled in with the backfill-metadata command
+ALTER TABLE eth.header_cids
+ADD COLUMN coinbase VARCHAR(66),
+ADD COLUMN difficulty NUMERIC,
+ADD COLUMN gas_limit BIGINT,
+ADD COLUMN gas_used BIGINT,
+ADD COLUMN extra_data BYTEA,
+ADD COLUMN mix_digest VARCHAR(66),
+ADD COLUMN nonce NUMERIC;
+
+ALTER TABLE eth.uncle_cids
+ADD COLUMN coinbase VARCHAR(66),
+ADD COLUMN difficulty NUMERIC,
+ADD COLUMN gas_limit BIGINT,
+ADD COLUMN gas_used BIGINT,
+ADD COLUMN extra_data BYTEA,
+ADD COLUMN mix_digest VARCHAR(66),
+ADD COLUMN nonce NUMERIC;
+
+CREATE INDEX coinbase_index ON eth.header_cids USING btree (coinbase);
+
+-- +goose Down
+DROP INDEX eth.coinbase_index;
+
+ALTER TABLE eth.uncle_cids
+DROP COLUMN nonce,
+DROP COLUMN mix_digest,
+DROP COLUMN extra_data,
+DROP COLUMN gas_used,
+DROP COLUMN gas_limit,
+DROP COLUMN difficulty,
+DROP COLUMN coinbase;
+
+ALTER TABLE eth.header_cids
+DROP COLUMN nonce,
+DROP COLUMN mix_digest,
+DROP COLUMN extra_data,
+DROP COLUMN gas_used,
+DROP COLUMN gas_limit,
+DROP COLUMN difficulty,
+DROP COLUMN coinbase;
@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE eth.access_list_elements (
+  id                    SERIAL PRIMARY KEY,
+  tx_id                 INTEGER NOT NULL REFERENCES eth.transaction_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  index                 INTEGER NOT NULL,
+  address               VARCHAR(66) NOT NULL,
+  storage_keys          VARCHAR(66)[],
+  UNIQUE (tx_id, index)
+);
+
+CREATE INDEX access_list_element_tx_id_index ON eth.access_list_elements USING btree (tx_id);
+
+CREATE INDEX access_list_element_address_index ON eth.access_list_elements USING btree (address);
+
+CREATE INDEX access_list_storage_keys_index ON eth.access_list_elements USING gin (storage_keys);
+
+COMMENT ON TABLE eth.access_list_elements IS E'@name EthAccessListElements';
+
+CREATE TRIGGER access_list_elements_ai
+    after INSERT ON eth.access_list_elements
+    for each row
+    execute procedure eth.graphql_subscription('access_list_elements', 'id');
+
+-- +goose Down
+DROP TRIGGER access_list_elements_ai ON eth.access_list_elements;
+DROP TABLE eth.access_list_elements;
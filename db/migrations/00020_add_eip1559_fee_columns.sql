@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE eth.header_cids
+ADD COLUMN base_fee NUMERIC,
+ADD COLUMN burnt_fees NUMERIC;
+
+ALTER TABLE eth.transaction_cids
+ADD COLUMN max_fee_per_gas NUMERIC,
+ADD COLUMN max_priority_fee_per_gas NUMERIC,
+ADD COLUMN effective_gas_price NUMERIC,
+ADD COLUMN priority_fee NUMERIC;
+
+COMMENT ON COLUMN eth.header_cids.burnt_fees IS E'Base fee multiplied by the gas used by the block, burnt under EIP-1559';
+COMMENT ON COLUMN eth.transaction_cids.priority_fee IS E'Total fee paid to the miner, the effective gas price less the base fee multiplied by the gas used';
+
+-- +goose Down
+ALTER TABLE eth.transaction_cids
+DROP COLUMN priority_fee,
+DROP COLUMN effective_gas_price,
+DROP COLUMN max_priority_fee_per_gas,
+DROP COLUMN max_fee_per_gas;
+
+ALTER TABLE eth.header_cids
+DROP COLUMN burnt_fees,
+DROP COLUMN base_fee;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE eth.contracts (
+  id                    SERIAL PRIMARY KEY,
+  tx_id                 INTEGER NOT NULL REFERENCES eth.transaction_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  block_number          BIGINT NOT NULL,
+  address               VARCHAR(66) NOT NULL,
+  creator               VARCHAR(66) NOT NULL,
+  code_hash             BYTEA,
+  code_mh_key           TEXT,
+  UNIQUE (tx_id, address)
+);
+
+CREATE INDEX contract_address_index ON eth.contracts USING btree (address);
+
+CREATE INDEX contract_creator_index ON eth.contracts USING btree (creator);
+
+CREATE INDEX contract_block_number_index ON eth.contracts USING brin (block_number);
+
+CREATE INDEX contract_code_hash_index ON eth.contracts USING btree (code_hash);
+
+COMMENT ON TABLE eth.contracts IS E'@name EthContracts';
+
+CREATE TRIGGER contracts_ai
+    after INSERT ON eth.contracts
+    for each row
+    execute procedure eth.graphql_subscription('contracts', 'id');
+
+-- +goose Down
+DROP TRIGGER contracts_ai ON eth.contracts;
+DROP TABLE eth.contracts;
@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX account_code_hash_index ON eth.state_accounts USING btree (code_hash);
+
+-- +goose Down
+DROP INDEX eth.account_code_hash_index;
@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE eth.address_transactions (
+  id                    SERIAL PRIMARY KEY,
+  address               VARCHAR(66) NOT NULL,
+  tx_id                 INTEGER NOT NULL REFERENCES eth.transaction_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  role                  INTEGER NOT NULL,
+  UNIQUE (address, tx_id, role)
+);
+
+CREATE INDEX address_transaction_tx_id_index ON eth.address_transactions USING btree (tx_id);
+
+COMMENT ON TABLE eth.address_transactions IS E'@name EthAddressTransactions';
+COMMENT ON COLUMN eth.address_transactions.role IS E'0 = sender, 1 = recipient, 2 = created contract, 3 = log emitter';
+
+CREATE TRIGGER address_transactions_ai
+    after INSERT ON eth.address_transactions
+    for each row
+    execute procedure eth.graphql_subscription('address_transactions', 'id');
+
+-- +goose Down
+DROP TRIGGER address_transactions_ai ON eth.address_transactions;
+DROP TABLE eth.address_transactions;
@@ -0,0 +1,72 @@
+-- +goose Up
+-- a brin index can't serve ordered lookups, so replace it with a btree index
+DROP INDEX eth.timestamp_index;
+CREATE INDEX timestamp_index ON eth.header_cids USING btree (timestamp, block_number);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_at_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+BEGIN
+  -- walk back from the latest header at or before the timestamp
+  -- canonical timestamps increase with height, so the first canonical header we find is the one we want
+FOR candidate IN
+SELECT * FROM eth.header_cids WHERE timestamp <= ts
+ORDER BY timestamp DESC, block_number DESC
+    LOOP
+    IF canonical_header_id(candidate.block_number) = candidate.id THEN
+      RETURN candidate;
+END IF;
+END LOOP;
+RETURN NULL;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_after_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+BEGIN
+  -- walk forward from the earliest header at or after the timestamp
+FOR candidate IN
+SELECT * FROM eth.header_cids WHERE timestamp >= ts
+ORDER BY timestamp ASC, block_number ASC
+    LOOP
+    IF canonical_header_id(candidate.block_number) = candidate.id THEN
+      RETURN candidate;
+END IF;
+END LOOP;
+RETURN NULL;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_block_range(start_ts NUMERIC, stop_ts NUMERIC, OUT start BIGINT, OUT stop BIGINT) AS
+$BODY$
+BEGIN
+  -- the range spans the first canonical block at or after the start of the window
+  -- to the last canonical block at or before the end of it
+SELECT block_number INTO start FROM canonical_header_after_time(start_ts);
+SELECT block_number INTO stop FROM canonical_header_at_time(stop_ts);
+  -- if no block falls inside the window, there is no range
+  IF start IS NULL OR stop IS NULL OR start > stop THEN
+    start = NULL;
+    stop = NULL;
+END IF;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION canonical_block_range;
+DROP FUNCTION canonical_header_after_time;
+DROP FUNCTION canonical_header_at_time;
+DROP INDEX eth.timestamp_index;
+CREATE INDEX timestamp_index ON eth.header_cids USING brin (timestamp);
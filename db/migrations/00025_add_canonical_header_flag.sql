@@ -0,0 +1,76 @@
+-- +goose Up
+ALTER TABLE eth.header_cids ADD COLUMN canonical BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX canonical_index ON eth.header_cids USING btree (block_number) WHERE canonical;
+
+COMMENT ON COLUMN eth.header_cids.canonical IS E'Whether the header is part of the chain with the most total difficulty, maintained by the indexer';
+
+-- mark the existing canonical chain in one update, starting from the heaviest header and following parent hashes down
+-- when a parent is missing, the chain continues from the heaviest header at the next height below the gap
+WITH RECURSIVE canonical_chain AS (
+  (SELECT id, block_number, parent_hash FROM eth.header_cids
+  ORDER BY td DESC, block_number DESC LIMIT 1)
+  UNION ALL
+  SELECT parent.id, parent.block_number, parent.parent_hash FROM canonical_chain
+  CROSS JOIN LATERAL (
+    SELECT candidates.id, candidates.block_number, candidates.parent_hash FROM (
+      SELECT id, block_number, parent_hash, 0 AS priority FROM eth.header_cids
+      WHERE block_number = canonical_chain.block_number - 1 AND block_hash = canonical_chain.parent_hash
+      UNION ALL
+      (SELECT id, block_number, parent_hash, 1 AS priority FROM eth.header_cids
+      WHERE block_number < canonical_chain.block_number
+      ORDER BY block_number DESC, td DESC LIMIT 1)
+    ) candidates
+    ORDER BY candidates.priority LIMIT 1
+  ) parent
+)
+UPDATE eth.header_cids SET canonical = TRUE
+FROM canonical_chain
+WHERE header_cids.block_number = canonical_chain.block_number AND header_cids.id = canonical_chain.id;
+
+-- +goose StatementBegin
+-- the canonical header is now flagged by the indexer, so it no longer needs to be derived by counting descendants
+CREATE OR REPLACE FUNCTION canonical_header_id(height BIGINT) RETURNS INTEGER AS
+$BODY$
+SELECT id FROM eth.header_cids WHERE block_number = height AND canonical;
+$BODY$
+LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_id(height BIGINT) RETURNS INTEGER AS
+$BODY$
+DECLARE
+canonical_header eth.header_cids;
+  headers eth.header_cids[];
+  header_count INT;
+  temp_header eth.header_cids;
+BEGIN
+  -- collect all headers at this height
+FOR temp_header IN
+SELECT * FROM eth.header_cids WHERE block_number = height
+    LOOP
+    headers = array_append(headers, temp_header);
+END LOOP;
+  -- count the number of headers collected
+  header_count = array_length(headers, 1);
+  -- if we have less than 1 header, return NULL
+  IF header_count IS NULL OR header_count < 1 THEN
+    RETURN NULL;
+  -- if we have one header, return its id
+  ELSIF header_count = 1 THEN
+    RETURN headers[1].id;
+  -- if we have multiple headers we need to determine which one is canonical
+ELSE
+    canonical_header = canonical_header_from_array(headers);
+RETURN canonical_header.id;
+END IF;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+DROP INDEX eth.canonical_index;
+
+ALTER TABLE eth.header_cids DROP COLUMN canonical;
@@ -0,0 +1,124 @@
+-- +goose Up
+CREATE TABLE eth.latest_accounts (
+  state_leaf_key VARCHAR(66) PRIMARY KEY,
+  block_number   BIGINT NOT NULL,
+  header_id      INTEGER NOT NULL,
+  state_id       BIGINT NOT NULL,
+  state_path     BYTEA,
+  balance        NUMERIC NOT NULL,
+  nonce          INTEGER NOT NULL,
+  code_hash      BYTEA NOT NULL,
+  storage_root   VARCHAR(66) NOT NULL
+);
+
+CREATE TABLE eth.latest_storage (
+  state_leaf_key   VARCHAR(66) NOT NULL,
+  storage_leaf_key VARCHAR(66) NOT NULL,
+  block_number     BIGINT NOT NULL,
+  header_id        INTEGER NOT NULL,
+  storage_path     BYTEA,
+  cid              TEXT NOT NULL,
+  mh_key           TEXT NOT NULL,
+  PRIMARY KEY (state_leaf_key, storage_leaf_key)
+);
+
+CREATE INDEX latest_account_block_number_index ON eth.latest_accounts USING brin (block_number);
+
+CREATE INDEX latest_storage_block_number_index ON eth.latest_storage USING brin (block_number);
+
+COMMENT ON TABLE eth.latest_accounts IS E'@name EthLatestAccounts';
+COMMENT ON TABLE eth.latest_storage IS E'@name EthLatestStorage';
+COMMENT ON COLUMN eth.latest_accounts.block_number IS E'Height of the canonical block that last wrote the account';
+COMMENT ON COLUMN eth.latest_storage.block_number IS E'Height of the canonical block that last wrote the storage slot';
+
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot touched by a header at or above the provided height
+-- the latest state of a key is its highest canonical leaf, unless a canonical removed node has since replaced that leaf at its path
+-- called with 0, it rebuilds both tables from the whole history
+CREATE FUNCTION eth.refresh_latest_state(from_height BIGINT) RETURNS VOID AS $$
+BEGIN
+  -- accounts written at or above the height, and accounts that have sat at a path removed at or above the height
+  CREATE TEMP TABLE touched_accounts ON COMMIT DROP AS
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height) AND node_type = 2
+  UNION
+  SELECT leaf.state_leaf_key FROM eth.state_cids removed
+  INNER JOIN eth.state_cids leaf ON (leaf.state_path = removed.state_path)
+  WHERE removed.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2;
+
+  -- slots written at or above the height, slots that have sat at a storage path removed at or above the height,
+  -- and the slots of accounts that have sat at a state path removed at or above the height
+  CREATE TEMP TABLE touched_storage ON COMMIT DROP AS
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE state_cids.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height) AND storage_cids.node_type = 2
+  UNION
+  SELECT leaf_state.state_leaf_key, leaf.storage_leaf_key FROM eth.storage_cids removed
+  INNER JOIN eth.state_cids removed_state ON (removed.state_id = removed_state.id AND removed.block_number = removed_state.block_number)
+  INNER JOIN eth.state_cids leaf_state ON (leaf_state.state_leaf_key = removed_state.state_leaf_key)
+  INNER JOIN eth.storage_cids leaf ON (leaf.state_id = leaf_state.id AND leaf.block_number = leaf_state.block_number AND leaf.storage_path = removed.storage_path)
+  WHERE removed_state.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2
+  UNION
+  SELECT leaf_state.state_leaf_key, leaf.storage_leaf_key FROM eth.state_cids removed
+  INNER JOIN eth.state_cids leaf_state ON (leaf_state.state_path = removed.state_path)
+  INNER JOIN eth.storage_cids leaf ON (leaf.state_id = leaf_state.id AND leaf.block_number = leaf_state.block_number)
+  WHERE removed.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2;
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_cids removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.node_type = 3
+                    AND removed.state_path = latest.state_path AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been removed from the account's storage trie since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_cids removed
+                    INNER JOIN eth.state_cids removed_state ON (removed.state_id = removed_state.id AND removed.block_number = removed_state.block_number)
+                    INNER JOIN eth.header_cids ON (removed_state.header_id = header_cids.id AND removed_state.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.node_type = 3 AND removed_state.state_leaf_key = latest.state_leaf_key
+                    AND removed.storage_path = latest.storage_path AND removed.block_number > latest.block_number)
+  -- and the account has not been removed from the state trie since, at the path it held when it was removed
+  AND NOT EXISTS (SELECT 1 FROM eth.state_cids removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.node_type = 3 AND removed.block_number > latest.block_number
+                  AND removed.state_path = (SELECT leaf.state_path FROM eth.state_cids leaf
+                                            INNER JOIN eth.header_cids leaf_header ON (leaf.header_id = leaf_header.id AND leaf.block_number = leaf_header.block_number)
+                                            WHERE leaf_header.canonical AND leaf.node_type = 2 AND leaf.state_leaf_key = latest.state_leaf_key
+                                            AND leaf.block_number < removed.block_number
+                                            ORDER BY leaf.block_number DESC LIMIT 1));
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION eth.refresh_latest_state;
+DROP TABLE eth.latest_storage;
+DROP TABLE eth.latest_accounts;
@@ -0,0 +1,380 @@
+-- +goose Up
+CREATE TABLE eth.state_removals (
+  id             SERIAL PRIMARY KEY,
+  header_id      INTEGER NOT NULL,
+  block_number   BIGINT NOT NULL,
+  state_path     BYTEA NOT NULL,
+  state_leaf_key VARCHAR(66),
+  destroyed      BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (header_id, state_path)
+);
+
+CREATE TABLE eth.storage_removals (
+  id               SERIAL PRIMARY KEY,
+  header_id        INTEGER NOT NULL,
+  block_number     BIGINT NOT NULL,
+  state_leaf_key   VARCHAR(66) NOT NULL,
+  storage_path     BYTEA NOT NULL,
+  storage_leaf_key VARCHAR(66),
+  cleared          BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (header_id, state_leaf_key, storage_path)
+);
+
+CREATE INDEX state_removal_path_index ON eth.state_removals USING btree (state_path, block_number);
+
+CREATE INDEX state_removal_destroyed_index ON eth.state_removals USING btree (state_leaf_key, block_number) WHERE destroyed;
+
+CREATE INDEX storage_removal_path_index ON eth.storage_removals USING btree (storage_path, block_number);
+
+CREATE INDEX storage_removal_cleared_index ON eth.storage_removals USING btree (state_leaf_key, storage_leaf_key, block_number) WHERE cleared;
+
+COMMENT ON TABLE eth.state_removals IS E'@name EthStateRemovals';
+COMMENT ON TABLE eth.storage_removals IS E'@name EthStorageRemovals';
+COMMENT ON COLUMN eth.state_removals.destroyed IS E'True if the removed node was the leaf of an account that is not written anywhere else in the same block';
+COMMENT ON COLUMN eth.storage_removals.cleared IS E'True if the removed node was the leaf of a storage slot that is not written anywhere else in the same block';
+
+CREATE TRIGGER state_removals_ai
+    after INSERT ON eth.state_removals
+    for each row
+    execute procedure eth.graphql_subscription('state_removals', 'id');
+
+CREATE TRIGGER storage_removals_ai
+    after INSERT ON eth.storage_removals
+    for each row
+    execute procedure eth.graphql_subscription('storage_removals', 'id');
+
+-- move the removed nodes out of the cid tables
+INSERT INTO eth.state_removals (header_id, block_number, state_path, state_leaf_key, destroyed)
+SELECT removed.header_id, removed.block_number, removed.state_path, removed.state_leaf_key,
+  COALESCE(removed.state_leaf_key, '') <> '' AND NOT EXISTS (SELECT 1 FROM eth.state_cids leaf
+                                                           WHERE leaf.header_id = removed.header_id AND leaf.block_number = removed.block_number
+                                                           AND leaf.state_leaf_key = removed.state_leaf_key AND leaf.node_type = 2)
+FROM eth.state_cids removed
+WHERE removed.node_type = 3;
+
+INSERT INTO eth.storage_removals (header_id, block_number, state_leaf_key, storage_path, storage_leaf_key, cleared)
+SELECT state_cids.header_id, removed.block_number, state_cids.state_leaf_key, removed.storage_path, removed.storage_leaf_key,
+  COALESCE(removed.storage_leaf_key, '') <> '' AND NOT EXISTS (SELECT 1 FROM eth.storage_cids leaf
+                                                             WHERE leaf.state_id = removed.state_id AND leaf.block_number = removed.block_number
+                                                             AND leaf.storage_leaf_key = removed.storage_leaf_key AND leaf.node_type = 2)
+FROM eth.storage_cids removed
+INNER JOIN eth.state_cids ON (removed.state_id = state_cids.id AND removed.block_number = state_cids.block_number)
+WHERE removed.node_type = 3;
+
+DELETE FROM eth.storage_cids WHERE node_type = 3;
+DELETE FROM eth.state_cids WHERE node_type = 3;
+
+-- every removed node referenced the same placeholder IPLD, the keccak256 hash of an empty value
+DELETE FROM public.blocks WHERE key = '/blocks/DMQMLUSGAGDPOIZ4SJ7H3MW4Y4B4BZIAWZJ4VARHHN57VWAELWC2I4A';
+
+-- +goose StatementBegin
+-- returns if a storage node at the provided path was removed in the range > the provided height and <= the provided block hash
+CREATE OR REPLACE FUNCTION was_storage_removed(path BYTEA, height BIGINT, hash VARCHAR(66)) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.storage_removals
+              WHERE storage_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+              LIMIT 1);
+$$ LANGUAGE SQL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns if a state node at the provided path was removed in the range > the provided height and <= the provided block hash
+CREATE OR REPLACE FUNCTION was_state_removed(path BYTEA, height BIGINT, hash VARCHAR(66)) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.state_removals
+              WHERE state_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+              LIMIT 1);
+$$ LANGUAGE SQL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns if the account with the provided leaf key exists in the canonical state at the provided height,
+-- i.e. it was written at or below the height and has not been destroyed since
+CREATE OR REPLACE FUNCTION account_exists_at(key VARCHAR(66), height BIGINT) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.state_cids
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+              WHERE state_cids.state_leaf_key = key
+                AND state_cids.node_type = 2
+                AND state_cids.block_number <= height
+                AND header_cids.canonical
+                AND NOT exists(SELECT 1
+                               FROM eth.state_removals
+                                        INNER JOIN eth.header_cids removal_header ON (state_removals.header_id = removal_header.id AND state_removals.block_number = removal_header.block_number)
+                               WHERE state_removals.state_leaf_key = key
+                                 AND state_removals.destroyed
+                                 AND state_removals.block_number > state_cids.block_number
+                                 AND state_removals.block_number <= height
+                                 AND removal_header.canonical)
+              LIMIT 1);
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns if the storage slot with the provided leaf key exists in the storage of the account with the provided leaf key
+-- in the canonical state at the provided height, i.e. it was written at or below the height and neither the slot has
+-- been cleared nor the account destroyed since
+CREATE OR REPLACE FUNCTION storage_exists_at(state_key VARCHAR(66), storage_key VARCHAR(66), height BIGINT) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.storage_cids
+                       INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+              WHERE state_cids.state_leaf_key = state_key
+                AND storage_cids.storage_leaf_key = storage_key
+                AND storage_cids.node_type = 2
+                AND storage_cids.block_number <= height
+                AND header_cids.canonical
+                AND NOT exists(SELECT 1
+                               FROM eth.storage_removals
+                                        INNER JOIN eth.header_cids removal_header ON (storage_removals.header_id = removal_header.id AND storage_removals.block_number = removal_header.block_number)
+                               WHERE storage_removals.state_leaf_key = state_key
+                                 AND storage_removals.storage_leaf_key = storage_key
+                                 AND storage_removals.cleared
+                                 AND storage_removals.block_number > storage_cids.block_number
+                                 AND storage_removals.block_number <= height
+                                 AND removal_header.canonical)
+                AND NOT exists(SELECT 1
+                               FROM eth.state_removals
+                                        INNER JOIN eth.header_cids removal_header ON (state_removals.header_id = removal_header.id AND state_removals.block_number = removal_header.block_number)
+                               WHERE state_removals.state_leaf_key = state_key
+                                 AND state_removals.destroyed
+                                 AND state_removals.block_number > storage_cids.block_number
+                                 AND state_removals.block_number <= height
+                                 AND removal_header.canonical)
+              LIMIT 1);
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot touched by a header at or above the provided height
+-- the latest state of a key is its highest canonical leaf, unless the key has since been destroyed or cleared in a canonical block
+-- called with 0, it rebuilds both tables from the whole history
+CREATE OR REPLACE FUNCTION eth.refresh_latest_state(from_height BIGINT) RETURNS VOID AS $$
+BEGIN
+  -- accounts written or destroyed at or above the height
+  CREATE TEMP TABLE touched_accounts ON COMMIT DROP AS
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE block_number >= from_height AND node_type = 2
+  UNION
+  SELECT state_leaf_key FROM eth.state_removals
+  WHERE block_number >= from_height AND destroyed;
+
+  -- slots written or cleared at or above the height, and every slot of the accounts destroyed at or above the height
+  CREATE TEMP TABLE touched_storage ON COMMIT DROP AS
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.block_number >= from_height AND storage_cids.node_type = 2
+  UNION
+  SELECT state_leaf_key, storage_leaf_key FROM eth.storage_removals
+  WHERE block_number >= from_height AND cleared
+  UNION
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.node_type = 2
+  AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM eth.state_removals WHERE block_number >= from_height AND destroyed);
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.destroyed
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been cleared since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.cleared
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.storage_leaf_key = latest.storage_leaf_key
+                    AND removed.block_number > latest.block_number)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot touched by a header at or above the provided height
+-- the latest state of a key is its highest canonical leaf, unless a canonical removed node has since replaced that leaf at its path
+-- called with 0, it rebuilds both tables from the whole history
+CREATE OR REPLACE FUNCTION eth.refresh_latest_state(from_height BIGINT) RETURNS VOID AS $$
+BEGIN
+  -- accounts written at or above the height, and accounts that have sat at a path removed at or above the height
+  CREATE TEMP TABLE touched_accounts ON COMMIT DROP AS
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height) AND node_type = 2
+  UNION
+  SELECT leaf.state_leaf_key FROM eth.state_cids removed
+  INNER JOIN eth.state_cids leaf ON (leaf.state_path = removed.state_path)
+  WHERE removed.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2;
+
+  -- slots written at or above the height, slots that have sat at a storage path removed at or above the height,
+  -- and the slots of accounts that have sat at a state path removed at or above the height
+  CREATE TEMP TABLE touched_storage ON COMMIT DROP AS
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE state_cids.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height) AND storage_cids.node_type = 2
+  UNION
+  SELECT leaf_state.state_leaf_key, leaf.storage_leaf_key FROM eth.storage_cids removed
+  INNER JOIN eth.state_cids removed_state ON (removed.state_id = removed_state.id AND removed.block_number = removed_state.block_number)
+  INNER JOIN eth.state_cids leaf_state ON (leaf_state.state_leaf_key = removed_state.state_leaf_key)
+  INNER JOIN eth.storage_cids leaf ON (leaf.state_id = leaf_state.id AND leaf.block_number = leaf_state.block_number AND leaf.storage_path = removed.storage_path)
+  WHERE removed_state.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2
+  UNION
+  SELECT leaf_state.state_leaf_key, leaf.storage_leaf_key FROM eth.state_cids removed
+  INNER JOIN eth.state_cids leaf_state ON (leaf_state.state_path = removed.state_path)
+  INNER JOIN eth.storage_cids leaf ON (leaf.state_id = leaf_state.id AND leaf.block_number = leaf_state.block_number)
+  WHERE removed.header_id IN (SELECT id FROM eth.header_cids WHERE block_number >= from_height)
+  AND removed.node_type = 3 AND leaf.node_type = 2;
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_cids removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.node_type = 3
+                    AND removed.state_path = latest.state_path AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been removed from the account's storage trie since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_cids removed
+                    INNER JOIN eth.state_cids removed_state ON (removed.state_id = removed_state.id AND removed.block_number = removed_state.block_number)
+                    INNER JOIN eth.header_cids ON (removed_state.header_id = header_cids.id AND removed_state.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.node_type = 3 AND removed_state.state_leaf_key = latest.state_leaf_key
+                    AND removed.storage_path = latest.storage_path AND removed.block_number > latest.block_number)
+  -- and the account has not been removed from the state trie since, at the path it held when it was removed
+  AND NOT EXISTS (SELECT 1 FROM eth.state_cids removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.node_type = 3 AND removed.block_number > latest.block_number
+                  AND removed.state_path = (SELECT leaf.state_path FROM eth.state_cids leaf
+                                            INNER JOIN eth.header_cids leaf_header ON (leaf.header_id = leaf_header.id AND leaf.block_number = leaf_header.block_number)
+                                            WHERE leaf_header.canonical AND leaf.node_type = 2 AND leaf.state_leaf_key = latest.state_leaf_key
+                                            AND leaf.block_number < removed.block_number
+                                            ORDER BY leaf.block_number DESC LIMIT 1));
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns if a storage node at the provided path was removed in the range > the provided height and <= the provided block hash
+CREATE OR REPLACE FUNCTION was_storage_removed(path BYTEA, height BIGINT, hash VARCHAR(66)) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.storage_cids
+                       INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id)
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+              WHERE storage_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+                AND storage_cids.node_type = 3
+              LIMIT 1);
+$$ LANGUAGE SQL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- returns if a state node at the provided path was removed in the range > the provided height and <= the provided block hash
+CREATE OR REPLACE FUNCTION was_state_removed(path BYTEA, height BIGINT, hash VARCHAR(66)) RETURNS BOOLEAN
+AS $$
+SELECT exists(SELECT 1
+              FROM eth.state_cids
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
+              WHERE state_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+                AND state_cids.node_type = 3
+              LIMIT 1);
+$$ LANGUAGE SQL;
+-- +goose StatementEnd
+
+DROP FUNCTION storage_exists_at;
+DROP FUNCTION account_exists_at;
+
+-- move the removed nodes back into the cid tables, along with their placeholder IPLD
+INSERT INTO public.blocks (key, data) VALUES ('/blocks/DMQMLUSGAGDPOIZ4SJ7H3MW4Y4B4BZIAWZJ4VARHHN57VWAELWC2I4A', '')
+ON CONFLICT (key) DO NOTHING;
+
+INSERT INTO eth.state_cids (header_id, state_leaf_key, cid, state_path, node_type, diff, mh_key, block_number)
+SELECT header_id, state_leaf_key, 'baglacgzayxjemamg64rtzet6pwznzrydydsqbnstzkbcoo337lmaixmfurya', state_path, 3, TRUE,
+  '/blocks/DMQMLUSGAGDPOIZ4SJ7H3MW4Y4B4BZIAWZJ4VARHHN57VWAELWC2I4A', block_number
+FROM eth.state_removals;
+
+INSERT INTO eth.storage_cids (state_id, storage_leaf_key, cid, storage_path, node_type, diff, mh_key, block_number)
+SELECT state_cids.id, storage_removals.storage_leaf_key, 'bagmacgzayxjemamg64rtzet6pwznzrydydsqbnstzkbcoo337lmaixmfurya', storage_removals.storage_path, 3, TRUE,
+  '/blocks/DMQMLUSGAGDPOIZ4SJ7H3MW4Y4B4BZIAWZJ4VARHHN57VWAELWC2I4A', storage_removals.block_number
+FROM eth.storage_removals
+INNER JOIN eth.state_cids ON (storage_removals.header_id = state_cids.header_id AND storage_removals.block_number = state_cids.block_number
+                              AND storage_removals.state_leaf_key = state_cids.state_leaf_key AND state_cids.node_type = 2);
+
+DROP TRIGGER storage_removals_ai ON eth.storage_removals;
+DROP TRIGGER state_removals_ai ON eth.state_removals;
+DROP TABLE eth.storage_removals;
+DROP TABLE eth.state_removals;
@@ -0,0 +1,55 @@
+-- +goose Up
+CREATE TABLE eth.account_changes (
+  id                   SERIAL PRIMARY KEY,
+  header_id            INTEGER NOT NULL,
+  block_number         BIGINT NOT NULL,
+  state_leaf_key       VARCHAR(66) NOT NULL,
+  prev_balance         NUMERIC NOT NULL,
+  balance              NUMERIC NOT NULL,
+  prev_nonce           INTEGER NOT NULL,
+  nonce                INTEGER NOT NULL,
+  code_hash            BYTEA NOT NULL,
+  storage_root         VARCHAR(66) NOT NULL,
+  code_changed         BOOLEAN NOT NULL,
+  storage_root_changed BOOLEAN NOT NULL,
+  UNIQUE (header_id, state_leaf_key)
+);
+
+CREATE INDEX account_change_state_leaf_key_index ON eth.account_changes USING btree (state_leaf_key, block_number);
+
+COMMENT ON TABLE eth.account_changes IS E'@name EthAccountChanges';
+COMMENT ON COLUMN eth.account_changes.prev_balance IS E'Balance of the account in its latest snapshot in a lower canonical block, 0 if it did not exist there';
+COMMENT ON COLUMN eth.account_changes.prev_nonce IS E'Nonce of the account in its latest snapshot in a lower canonical block, 0 if it did not exist there';
+
+CREATE TRIGGER account_changes_ai
+    after INSERT ON eth.account_changes
+    for each row
+    execute procedure eth.graphql_subscription('account_changes', 'id');
+
+-- derive the changes of the canonical blocks already indexed from their account snapshots and destructions
+INSERT INTO eth.account_changes (header_id, block_number, state_leaf_key, prev_balance, balance, prev_nonce, nonce, code_hash, storage_root, code_changed, storage_root_changed)
+SELECT header_id, block_number, state_leaf_key,
+  COALESCE(LAG(balance) OVER w, 0), balance,
+  COALESCE(LAG(nonce) OVER w, 0), nonce,
+  code_hash, storage_root,
+  code_hash <> COALESCE(LAG(code_hash) OVER w, '\xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470'::BYTEA),
+  storage_root <> COALESCE(LAG(storage_root) OVER w, '0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421')
+FROM (
+  SELECT state_cids.header_id, state_cids.block_number, state_cids.state_leaf_key,
+    state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+  FROM eth.state_accounts
+  INNER JOIN eth.state_cids ON (state_accounts.state_id = state_cids.id)
+  INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+  WHERE header_cids.canonical
+  UNION ALL
+  SELECT state_removals.header_id, state_removals.block_number, state_removals.state_leaf_key,
+    0, 0, '\xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470'::BYTEA, '0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421'
+  FROM eth.state_removals
+  INNER JOIN eth.header_cids ON (state_removals.header_id = header_cids.id AND state_removals.block_number = header_cids.block_number)
+  WHERE header_cids.canonical AND state_removals.destroyed
+) AS snapshots
+WINDOW w AS (PARTITION BY state_leaf_key ORDER BY block_number);
+
+-- +goose Down
+DROP TRIGGER account_changes_ai ON eth.account_changes;
+DROP TABLE eth.account_changes;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE eth.storage_cids ADD COLUMN value BYTEA;
+
+COMMENT ON COLUMN eth.storage_cids.value IS E'Value of the storage slot held by a leaf node, left padded to 32 bytes';
+
+-- +goose Down
+ALTER TABLE eth.storage_cids DROP COLUMN value;
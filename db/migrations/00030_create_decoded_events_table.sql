@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE eth.decoded_events (
+  id                    SERIAL PRIMARY KEY,
+  log_id                INTEGER NOT NULL REFERENCES eth.log_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  address               VARCHAR(66) NOT NULL,
+  event_name            VARCHAR(128) NOT NULL,
+  signature             TEXT NOT NULL,
+  args                  JSONB NOT NULL,
+  UNIQUE (log_id)
+);
+
+CREATE INDEX decoded_event_address_index ON eth.decoded_events USING btree (address, event_name);
+
+CREATE INDEX decoded_event_args_index ON eth.decoded_events USING gin (args);
+
+COMMENT ON TABLE eth.decoded_events IS E'@name EthDecodedEvents';
+COMMENT ON COLUMN eth.decoded_events.signature IS E'Canonical signature of the event, e.g. Transfer(address,address,uint256)';
+COMMENT ON COLUMN eth.decoded_events.args IS E'Arguments of the event by name, with integers as JSON numbers and addresses, hashes, and bytes as hex strings; indexed dynamic arguments hold the hash in their topic';
+
+CREATE TRIGGER decoded_events_ai
+    after INSERT ON eth.decoded_events
+    for each row
+    execute procedure eth.graphql_subscription('decoded_events', 'id');
+
+-- +goose Down
+DROP TRIGGER decoded_events_ai ON eth.decoded_events;
+DROP TABLE eth.decoded_events;
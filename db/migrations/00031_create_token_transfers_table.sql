@@ -0,0 +1,41 @@
+-- +goose Up
+CREATE TABLE eth.token_transfers (
+  id                    SERIAL PRIMARY KEY,
+  log_id                INTEGER NOT NULL REFERENCES eth.log_cids (id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED,
+  batch_index           INTEGER NOT NULL,
+  tx_id                 INTEGER NOT NULL,
+  block_number          BIGINT NOT NULL,
+  log_index             INTEGER NOT NULL,
+  token                 VARCHAR(66) NOT NULL,
+  standard              INTEGER NOT NULL,
+  operator              VARCHAR(66) NOT NULL,
+  src                   VARCHAR(66) NOT NULL,
+  dst                   VARCHAR(66) NOT NULL,
+  token_id              NUMERIC,
+  amount                NUMERIC NOT NULL,
+  UNIQUE (log_id, batch_index)
+);
+
+CREATE INDEX token_transfer_token_index ON eth.token_transfers USING btree (token, block_number);
+
+CREATE INDEX token_transfer_src_index ON eth.token_transfers USING btree (src, block_number);
+
+CREATE INDEX token_transfer_dst_index ON eth.token_transfers USING btree (dst, block_number);
+
+CREATE INDEX token_transfer_tx_id_index ON eth.token_transfers USING btree (tx_id);
+
+COMMENT ON TABLE eth.token_transfers IS E'@name EthTokenTransfers';
+COMMENT ON COLUMN eth.token_transfers.batch_index IS E'Position of the transfer within an ERC-1155 TransferBatch event, 0 for every other event';
+COMMENT ON COLUMN eth.token_transfers.standard IS E'20 = ERC-20, 721 = ERC-721, 1155 = ERC-1155';
+COMMENT ON COLUMN eth.token_transfers.operator IS E'Operator of an ERC-1155 transfer, empty for ERC-20 and ERC-721 transfers';
+COMMENT ON COLUMN eth.token_transfers.token_id IS E'Token id of an ERC-721 or ERC-1155 transfer, NULL for ERC-20 transfers';
+COMMENT ON COLUMN eth.token_transfers.amount IS E'Amount transferred, 1 for ERC-721 transfers';
+
+CREATE TRIGGER token_transfers_ai
+    after INSERT ON eth.token_transfers
+    for each row
+    execute procedure eth.graphql_subscription('token_transfers', 'id');
+
+-- +goose Down
+DROP TRIGGER token_transfers_ai ON eth.token_transfers;
+DROP TABLE eth.token_transfers;
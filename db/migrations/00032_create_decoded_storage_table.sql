@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE eth.decoded_storage (
+  id                    SERIAL PRIMARY KEY,
+  header_id             INTEGER NOT NULL,
+  block_number          BIGINT NOT NULL,
+  state_leaf_key        VARCHAR(66) NOT NULL,
+  storage_leaf_key      VARCHAR(66) NOT NULL,
+  contract              VARCHAR(66) NOT NULL,
+  variable              TEXT NOT NULL,
+  keys                  TEXT[] NOT NULL,
+  type                  TEXT NOT NULL,
+  value                 JSONB NOT NULL,
+  UNIQUE (header_id, state_leaf_key, storage_leaf_key, variable)
+);
+
+CREATE INDEX decoded_storage_contract_index ON eth.decoded_storage USING btree (contract, variable, block_number);
+
+COMMENT ON TABLE eth.decoded_storage IS E'@name EthDecodedStorage';
+COMMENT ON COLUMN eth.decoded_storage.variable IS E'Name of the variable held in the slot, with the path to struct members and array elements, e.g. config.owner or values[2]';
+COMMENT ON COLUMN eth.decoded_storage.keys IS E'Mapping keys leading to the variable, outermost first';
+COMMENT ON COLUMN eth.decoded_storage.type IS E'Solidity type of the variable, as labelled in the storage layout';
+COMMENT ON COLUMN eth.decoded_storage.value IS E'Decoded value, {"length": n} for strings and bytes too long to be held in the slot';
+
+CREATE TRIGGER decoded_storage_ai
+    after INSERT ON eth.decoded_storage
+    for each row
+    execute procedure eth.graphql_subscription('decoded_storage', 'id');
+
+-- +goose Down
+DROP TRIGGER decoded_storage_ai ON eth.decoded_storage;
+DROP TABLE eth.decoded_storage;
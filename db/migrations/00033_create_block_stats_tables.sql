@@ -0,0 +1,166 @@
+-- +goose Up
+CREATE TABLE eth.block_stats (
+  id                 SERIAL PRIMARY KEY,
+  header_id          INTEGER NOT NULL UNIQUE,
+  block_number       BIGINT NOT NULL,
+  timestamp          NUMERIC NOT NULL,
+  canonical          BOOLEAN NOT NULL DEFAULT FALSE,
+  tx_count           INTEGER NOT NULL,
+  gas_used           BIGINT NOT NULL,
+  gas_limit          BIGINT NOT NULL,
+  total_fees         NUMERIC NOT NULL,
+  reward             NUMERIC NOT NULL,
+  uncle_count        INTEGER NOT NULL,
+  state_node_count   INTEGER NOT NULL,
+  storage_node_count INTEGER NOT NULL
+);
+
+CREATE TABLE eth.hourly_block_stats (
+  period_start       NUMERIC PRIMARY KEY,
+  block_count        BIGINT NOT NULL,
+  tx_count           BIGINT NOT NULL,
+  gas_used           NUMERIC NOT NULL,
+  gas_limit          NUMERIC NOT NULL,
+  total_fees         NUMERIC NOT NULL,
+  reward             NUMERIC NOT NULL,
+  uncle_count        BIGINT NOT NULL,
+  state_node_count   BIGINT NOT NULL,
+  storage_node_count BIGINT NOT NULL
+);
+
+CREATE TABLE eth.daily_block_stats (
+  period_start       NUMERIC PRIMARY KEY,
+  block_count        BIGINT NOT NULL,
+  tx_count           BIGINT NOT NULL,
+  gas_used           NUMERIC NOT NULL,
+  gas_limit          NUMERIC NOT NULL,
+  total_fees         NUMERIC NOT NULL,
+  reward             NUMERIC NOT NULL,
+  uncle_count        BIGINT NOT NULL,
+  state_node_count   BIGINT NOT NULL,
+  storage_node_count BIGINT NOT NULL
+);
+
+CREATE INDEX block_stats_block_number_index ON eth.block_stats USING btree (block_number);
+
+COMMENT ON TABLE eth.block_stats IS E'@name EthBlockStats';
+COMMENT ON TABLE eth.hourly_block_stats IS E'@name EthHourlyBlockStats';
+COMMENT ON TABLE eth.daily_block_stats IS E'@name EthDailyBlockStats';
+COMMENT ON COLUMN eth.block_stats.canonical IS E'Mirrors the canonical flag of the header, only canonical blocks are counted in the hourly and daily rollups';
+COMMENT ON COLUMN eth.block_stats.total_fees IS E'Fees paid by the transactions of the block, including the fees burnt by EIP-1559';
+COMMENT ON COLUMN eth.block_stats.reward IS E'Reward paid to the miner of the block, as in eth.header_cids';
+COMMENT ON COLUMN eth.block_stats.state_node_count IS E'State nodes written or removed by the block';
+COMMENT ON COLUMN eth.block_stats.storage_node_count IS E'Storage nodes written or removed by the block';
+COMMENT ON COLUMN eth.hourly_block_stats.period_start IS E'Unix timestamp of the start of the hour, in UTC';
+COMMENT ON COLUMN eth.daily_block_stats.period_start IS E'Unix timestamp of the start of the day, in UTC';
+
+CREATE TRIGGER block_stats_ai
+    after INSERT ON eth.block_stats
+    for each row
+    execute procedure eth.graphql_subscription('block_stats', 'id');
+
+-- +goose StatementBegin
+-- upserts the stats of the header with the provided id and height from its indexed rows
+-- the canonical flag is only set on insert, it is kept in sync with the header by eth.header_cids_canonical_block_stats
+CREATE FUNCTION eth.index_block_stats(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    (SELECT COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) FROM eth.transaction_cids
+      INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- adds (direction = 1) or subtracts (direction = -1) the stats of a block to or from the hourly and daily rollups of its timestamp
+-- rollup rows are removed once they no longer count any block
+CREATE FUNCTION eth.rollup_block_stats(stats eth.block_stats, direction INTEGER) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.hourly_block_stats AS rollup (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+  VALUES (stats.timestamp - MOD(stats.timestamp, 3600), direction, direction * stats.tx_count, direction * stats.gas_used, direction * stats.gas_limit, direction * stats.total_fees,
+          direction * stats.reward, direction * stats.uncle_count, direction * stats.state_node_count, direction * stats.storage_node_count)
+  ON CONFLICT (period_start) DO UPDATE SET (block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (rollup.block_count + EXCLUDED.block_count, rollup.tx_count + EXCLUDED.tx_count, rollup.gas_used + EXCLUDED.gas_used, rollup.gas_limit + EXCLUDED.gas_limit,
+       rollup.total_fees + EXCLUDED.total_fees, rollup.reward + EXCLUDED.reward, rollup.uncle_count + EXCLUDED.uncle_count,
+       rollup.state_node_count + EXCLUDED.state_node_count, rollup.storage_node_count + EXCLUDED.storage_node_count);
+  DELETE FROM eth.hourly_block_stats WHERE period_start = stats.timestamp - MOD(stats.timestamp, 3600) AND block_count = 0;
+
+  INSERT INTO eth.daily_block_stats AS rollup (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+  VALUES (stats.timestamp - MOD(stats.timestamp, 86400), direction, direction * stats.tx_count, direction * stats.gas_used, direction * stats.gas_limit, direction * stats.total_fees,
+          direction * stats.reward, direction * stats.uncle_count, direction * stats.state_node_count, direction * stats.storage_node_count)
+  ON CONFLICT (period_start) DO UPDATE SET (block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (rollup.block_count + EXCLUDED.block_count, rollup.tx_count + EXCLUDED.tx_count, rollup.gas_used + EXCLUDED.gas_used, rollup.gas_limit + EXCLUDED.gas_limit,
+       rollup.total_fees + EXCLUDED.total_fees, rollup.reward + EXCLUDED.reward, rollup.uncle_count + EXCLUDED.uncle_count,
+       rollup.state_node_count + EXCLUDED.state_node_count, rollup.storage_node_count + EXCLUDED.storage_node_count);
+  DELETE FROM eth.daily_block_stats WHERE period_start = stats.timestamp - MOD(stats.timestamp, 86400) AND block_count = 0;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- keeps the rollups in step with the canonical blocks: the previous stats of a canonical block are subtracted and its new stats added
+CREATE FUNCTION eth.block_stats_rollup() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.canonical THEN
+    PERFORM eth.rollup_block_stats(OLD, -1);
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.canonical THEN
+    PERFORM eth.rollup_block_stats(NEW, 1);
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- mirrors the canonical flag of a header onto its stats, so that a reorg moves the stats of the blocks it replaces out of the rollups
+CREATE FUNCTION eth.header_cids_canonical_block_stats() RETURNS TRIGGER AS $$
+BEGIN
+  UPDATE eth.block_stats SET canonical = NEW.canonical WHERE header_id = NEW.id;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER block_stats_rollup
+    after INSERT OR UPDATE OR DELETE ON eth.block_stats
+    for each row
+    execute procedure eth.block_stats_rollup();
+
+CREATE TRIGGER header_cids_canonical_block_stats
+    after UPDATE OF canonical ON eth.header_cids
+    for each row
+    when (OLD.canonical IS DISTINCT FROM NEW.canonical)
+    execute procedure eth.header_cids_canonical_block_stats();
+
+-- derive the stats of the blocks already indexed, which rolls up the canonical ones
+SELECT eth.index_block_stats(id, block_number) FROM eth.header_cids;
+
+-- +goose Down
+DROP TRIGGER header_cids_canonical_block_stats ON eth.header_cids;
+DROP TRIGGER block_stats_rollup ON eth.block_stats;
+DROP TRIGGER block_stats_ai ON eth.block_stats;
+DROP FUNCTION eth.header_cids_canonical_block_stats;
+DROP FUNCTION eth.block_stats_rollup;
+DROP FUNCTION eth.rollup_block_stats;
+DROP FUNCTION eth.index_block_stats;
+DROP TABLE eth.daily_block_stats;
+DROP TABLE eth.hourly_block_stats;
+DROP TABLE eth.block_stats;
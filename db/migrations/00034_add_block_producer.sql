@@ -0,0 +1,80 @@
+-- +goose Up
+-- the producer is the coinbase of the header, except on proof-of-authority (Clique) chains where it is the signer recovered from the seal in the extra data
+-- it is NULL for rows indexed before it was added, until it is filled in by a resync of type rewards
+ALTER TABLE eth.header_cids
+ADD COLUMN producer VARCHAR(66);
+
+ALTER TABLE eth.block_stats
+ADD COLUMN producer VARCHAR(66);
+
+CREATE INDEX producer_index ON eth.header_cids USING btree (producer);
+
+COMMENT ON COLUMN eth.header_cids.producer IS E'Address that produced the block: the coinbase, or the signer on Clique chains';
+COMMENT ON COLUMN eth.block_stats.producer IS E'Address that produced the block, as in eth.header_cids';
+
+-- +goose StatementBegin
+-- upserts the stats of the header with the provided id and height from its indexed rows
+-- the canonical flag is only set on insert, it is kept in sync with the header by eth.header_cids_canonical_block_stats
+CREATE OR REPLACE FUNCTION eth.index_block_stats(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               producer, uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    (SELECT COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) FROM eth.transaction_cids
+      INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward, header_cids.producer,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, producer, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.producer, EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- upserts the stats of the header with the provided id and height from its indexed rows
+-- the canonical flag is only set on insert, it is kept in sync with the header by eth.header_cids_canonical_block_stats
+CREATE OR REPLACE FUNCTION eth.index_block_stats(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    (SELECT COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) FROM eth.transaction_cids
+      INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP INDEX eth.producer_index;
+
+ALTER TABLE eth.block_stats
+DROP COLUMN producer;
+
+ALTER TABLE eth.header_cids
+DROP COLUMN producer;
@@ -0,0 +1,191 @@
+-- +goose Up
+-- +goose StatementBegin
+-- records every account and storage slot touched by a header within the provided block range, a NULL stop height leaves the range open
+-- the keys are collected into the touched_accounts and touched_storage temp tables, which live until eth.recompute_latest_state or the end of the transaction,
+-- so that a range can be touched before its rows are deleted and recomputed from whatever history remains afterwards
+CREATE OR REPLACE FUNCTION eth.touch_latest_state(start_height BIGINT, stop_height BIGINT) RETURNS VOID AS $$
+DECLARE
+  stop BIGINT := COALESCE(stop_height, 9223372036854775807);
+BEGIN
+  CREATE TEMP TABLE IF NOT EXISTS touched_accounts (
+    state_leaf_key VARCHAR(66) PRIMARY KEY
+  ) ON COMMIT DROP;
+  CREATE TEMP TABLE IF NOT EXISTS touched_storage (
+    state_leaf_key   VARCHAR(66) NOT NULL,
+    storage_leaf_key VARCHAR(66) NOT NULL,
+    PRIMARY KEY (state_leaf_key, storage_leaf_key)
+  ) ON COMMIT DROP;
+
+  -- accounts written or destroyed within the range
+  INSERT INTO touched_accounts (state_leaf_key)
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE block_number BETWEEN start_height AND stop AND node_type = 2
+  UNION
+  SELECT state_leaf_key FROM eth.state_removals
+  WHERE block_number BETWEEN start_height AND stop AND destroyed
+  ON CONFLICT DO NOTHING;
+
+  -- slots written or cleared within the range, and every slot of the accounts destroyed within the range
+  INSERT INTO touched_storage (state_leaf_key, storage_leaf_key)
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.block_number BETWEEN start_height AND stop AND storage_cids.node_type = 2
+  UNION
+  SELECT state_leaf_key, storage_leaf_key FROM eth.storage_removals
+  WHERE block_number BETWEEN start_height AND stop AND cleared
+  UNION
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.node_type = 2
+  AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM eth.state_removals
+                                    WHERE block_number BETWEEN start_height AND stop AND destroyed)
+  ON CONFLICT DO NOTHING;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot recorded by eth.touch_latest_state, and forgets the recorded keys
+-- the latest state of a key is its highest canonical leaf, unless the key has since been destroyed or cleared in a canonical block
+CREATE OR REPLACE FUNCTION eth.recompute_latest_state() RETURNS VOID AS $$
+BEGIN
+  IF to_regclass('pg_temp.touched_accounts') IS NULL THEN
+    RETURN;
+  END IF;
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.destroyed
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been cleared since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.cleared
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.storage_leaf_key = latest.storage_leaf_key
+                    AND removed.block_number > latest.block_number)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot touched by a header at or above the provided height
+-- called with 0, it rebuilds both tables from the whole history
+CREATE OR REPLACE FUNCTION eth.refresh_latest_state(from_height BIGINT) RETURNS VOID AS $$
+BEGIN
+  PERFORM eth.touch_latest_state(from_height, NULL);
+  PERFORM eth.recompute_latest_state();
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- recomputes the latest state of every account and storage slot touched by a header at or above the provided height
+-- the latest state of a key is its highest canonical leaf, unless the key has since been destroyed or cleared in a canonical block
+-- called with 0, it rebuilds both tables from the whole history
+CREATE OR REPLACE FUNCTION eth.refresh_latest_state(from_height BIGINT) RETURNS VOID AS $$
+BEGIN
+  -- accounts written or destroyed at or above the height
+  CREATE TEMP TABLE touched_accounts ON COMMIT DROP AS
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE block_number >= from_height AND node_type = 2
+  UNION
+  SELECT state_leaf_key FROM eth.state_removals
+  WHERE block_number >= from_height AND destroyed;
+
+  -- slots written or cleared at or above the height, and every slot of the accounts destroyed at or above the height
+  CREATE TEMP TABLE touched_storage ON COMMIT DROP AS
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.block_number >= from_height AND storage_cids.node_type = 2
+  UNION
+  SELECT state_leaf_key, storage_leaf_key FROM eth.storage_removals
+  WHERE block_number >= from_height AND cleared
+  UNION
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.node_type = 2
+  AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM eth.state_removals WHERE block_number >= from_height AND destroyed);
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.destroyed
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been cleared since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.cleared
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.storage_leaf_key = latest.storage_leaf_key
+                    AND removed.block_number > latest.block_number)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP FUNCTION eth.recompute_latest_state;
+DROP FUNCTION eth.touch_latest_state;
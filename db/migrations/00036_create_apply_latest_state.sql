@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+-- applies the state and storage diffs of the canonical header with the provided id and height to the latest state tables
+-- only the block's own leaves and removals are read, so the cost does not grow with the chain
+-- blocks can be applied in any order, a key is only overwritten by a write at or above its current height,
+-- and it is not written at all if it has since been destroyed or cleared in a canonical block
+CREATE OR REPLACE FUNCTION eth.apply_latest_state(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  -- accounts destroyed by the block lose their earlier state and storage
+  DELETE FROM eth.latest_storage USING eth.state_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.destroyed
+  AND latest_storage.state_leaf_key = removed.state_leaf_key AND latest_storage.block_number < height;
+  DELETE FROM eth.latest_accounts USING eth.state_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.destroyed
+  AND latest_accounts.state_leaf_key = removed.state_leaf_key AND latest_accounts.block_number < height;
+
+  -- slots cleared by the block lose their earlier value
+  DELETE FROM eth.latest_storage USING eth.storage_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.cleared
+  AND latest_storage.state_leaf_key = removed.state_leaf_key AND latest_storage.storage_leaf_key = removed.storage_leaf_key
+  AND latest_storage.block_number < height;
+
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id, state_cids.id, state_cids.state_path,
+    state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+  FROM eth.state_cids
+  INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+  WHERE state_cids.header_id = header AND state_cids.block_number = height AND state_cids.node_type = 2
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.block_number > height)
+  ON CONFLICT (state_leaf_key) DO UPDATE SET (block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root) =
+    (EXCLUDED.block_number, EXCLUDED.header_id, EXCLUDED.state_id, EXCLUDED.state_path, EXCLUDED.balance, EXCLUDED.nonce, EXCLUDED.code_hash, EXCLUDED.storage_root)
+  WHERE latest_accounts.block_number <= EXCLUDED.block_number;
+
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number, state_cids.header_id,
+    storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+  FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE state_cids.header_id = header AND state_cids.block_number = height AND storage_cids.node_type = 2
+  -- the slot has not been cleared since
+  AND NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.cleared
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.storage_leaf_key = storage_cids.storage_leaf_key
+                  AND removed.block_number > height)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.block_number > height)
+  ON CONFLICT (state_leaf_key, storage_leaf_key) DO UPDATE SET (block_number, header_id, storage_path, cid, mh_key) =
+    (EXCLUDED.block_number, EXCLUDED.header_id, EXCLUDED.storage_path, EXCLUDED.cid, EXCLUDED.mh_key)
+  WHERE latest_storage.block_number <= EXCLUDED.block_number;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION eth.apply_latest_state;
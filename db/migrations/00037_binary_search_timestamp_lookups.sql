@@ -0,0 +1,141 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_at_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+  lo BIGINT;
+  hi BIGINT;
+  mid BIGINT;
+  probe_number BIGINT;
+  probe_ts NUMERIC;
+  match_number BIGINT;
+BEGIN
+  -- the latest header at or before the timestamp, found with timestamp_index, is the one we want if it is canonical
+  -- since every canonical header above it is later than it, and so later than the timestamp
+SELECT * INTO candidate FROM eth.header_cids WHERE timestamp <= ts
+ORDER BY timestamp DESC, block_number DESC LIMIT 1;
+IF NOT FOUND THEN
+    RETURN NULL;
+END IF;
+  IF candidate.canonical THEN
+    RETURN candidate;
+END IF;
+  -- otherwise binary search the canonical heights for the highest one at or before the timestamp
+  -- canonical timestamps increase with height, and each probe takes the first canonical header at or above the midpoint so gaps are skipped
+SELECT MIN(block_number), MAX(block_number) INTO lo, hi FROM eth.header_cids WHERE canonical;
+WHILE lo <= hi LOOP
+    mid = lo + (hi - lo) / 2;
+SELECT block_number, timestamp INTO probe_number, probe_ts FROM eth.header_cids
+WHERE canonical AND block_number BETWEEN mid AND hi
+ORDER BY block_number LIMIT 1;
+IF NOT FOUND THEN
+      hi = mid - 1;
+    ELSIF probe_ts <= ts THEN
+      match_number = probe_number;
+      lo = probe_number + 1;
+ELSE
+      hi = mid - 1;
+END IF;
+END LOOP;
+  IF match_number IS NULL THEN
+    RETURN NULL;
+END IF;
+SELECT * INTO candidate FROM eth.header_cids WHERE block_number = match_number AND canonical;
+RETURN candidate;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_after_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+  lo BIGINT;
+  hi BIGINT;
+  mid BIGINT;
+  probe_number BIGINT;
+  probe_ts NUMERIC;
+  match_number BIGINT;
+BEGIN
+  -- the earliest header at or after the timestamp, found with timestamp_index, is the one we want if it is canonical
+  -- since every canonical header below it is earlier than it, and so earlier than the timestamp
+SELECT * INTO candidate FROM eth.header_cids WHERE timestamp >= ts
+ORDER BY timestamp ASC, block_number ASC LIMIT 1;
+IF NOT FOUND THEN
+    RETURN NULL;
+END IF;
+  IF candidate.canonical THEN
+    RETURN candidate;
+END IF;
+  -- otherwise binary search the canonical heights for the lowest one at or after the timestamp
+SELECT MIN(block_number), MAX(block_number) INTO lo, hi FROM eth.header_cids WHERE canonical;
+WHILE lo <= hi LOOP
+    mid = lo + (hi - lo) / 2;
+SELECT block_number, timestamp INTO probe_number, probe_ts FROM eth.header_cids
+WHERE canonical AND block_number BETWEEN mid AND hi
+ORDER BY block_number LIMIT 1;
+IF NOT FOUND THEN
+      hi = mid - 1;
+    ELSIF probe_ts >= ts THEN
+      match_number = probe_number;
+      hi = mid - 1;
+ELSE
+      lo = probe_number + 1;
+END IF;
+END LOOP;
+  IF match_number IS NULL THEN
+    RETURN NULL;
+END IF;
+SELECT * INTO candidate FROM eth.header_cids WHERE block_number = match_number AND canonical;
+RETURN candidate;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_at_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+BEGIN
+  -- walk back from the latest header at or before the timestamp
+  -- canonical timestamps increase with height, so the first canonical header we find is the one we want
+FOR candidate IN
+SELECT * FROM eth.header_cids WHERE timestamp <= ts
+ORDER BY timestamp DESC, block_number DESC
+    LOOP
+    IF canonical_header_id(candidate.block_number) = candidate.id THEN
+      RETURN candidate;
+END IF;
+END LOOP;
+RETURN NULL;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION canonical_header_after_time(ts NUMERIC) RETURNS eth.header_cids AS
+$BODY$
+DECLARE
+  candidate eth.header_cids;
+BEGIN
+  -- walk forward from the earliest header at or after the timestamp
+FOR candidate IN
+SELECT * FROM eth.header_cids WHERE timestamp >= ts
+ORDER BY timestamp ASC, block_number ASC
+    LOOP
+    IF canonical_header_id(candidate.block_number) = candidate.id THEN
+      RETURN candidate;
+END IF;
+END LOOP;
+RETURN NULL;
+END;
+$BODY$
+LANGUAGE 'plpgsql';
+-- +goose StatementEnd
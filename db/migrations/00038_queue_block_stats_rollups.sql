@@ -0,0 +1,234 @@
+-- +goose Up
+-- the rollups are no longer updated by a trigger in the tx of every block, where concurrent workers updating the same hourly and daily rows
+-- serialized on them and could deadlock; instead each change to the stats of a canonical block only appends its timestamp to a queue,
+-- and eth.refresh_block_stats_rollups periodically recomputes the hours and days in the queue from eth.block_stats
+DROP TRIGGER block_stats_rollup ON eth.block_stats;
+DROP FUNCTION eth.block_stats_rollup;
+DROP FUNCTION eth.rollup_block_stats;
+
+-- the queue has no key, so that concurrent block txs appending to it never wait on each other
+CREATE TABLE eth.block_stats_rollup_queue (
+  timestamp NUMERIC NOT NULL
+);
+
+CREATE INDEX block_stats_timestamp_index ON eth.block_stats USING btree (timestamp);
+
+ALTER TABLE eth.block_stats
+ALTER COLUMN total_fees DROP NOT NULL;
+
+ALTER TABLE eth.hourly_block_stats
+ADD COLUMN fee_block_count BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE eth.daily_block_stats
+ADD COLUMN fee_block_count BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON TABLE eth.block_stats_rollup_queue IS E'Timestamps of the canonical blocks whose stats changed since the hourly and daily rollups were last refreshed';
+COMMENT ON COLUMN eth.block_stats.total_fees IS E'Fees paid by the transactions of the block, including the fees burnt by EIP-1559, NULL while any of its transactions has no effective gas price or receipt gas used recorded';
+COMMENT ON COLUMN eth.hourly_block_stats.total_fees IS E'Fees of the blocks with known fees, see fee_block_count';
+COMMENT ON COLUMN eth.hourly_block_stats.fee_block_count IS E'Blocks whose fees are known and summed in total_fees, fewer than block_count while some have NULL fees';
+COMMENT ON COLUMN eth.daily_block_stats.total_fees IS E'Fees of the blocks with known fees, see fee_block_count';
+COMMENT ON COLUMN eth.daily_block_stats.fee_block_count IS E'Blocks whose fees are known and summed in total_fees, fewer than block_count while some have NULL fees';
+
+-- +goose StatementBegin
+-- upserts the stats of the header with the provided id and height from its indexed rows
+-- every upsert queues the block for eth.refresh_block_stats_rollups through the block_stats_rollup_queue trigger
+-- the canonical flag is only set on insert, it is kept in sync with the header by eth.header_cids_canonical_block_stats
+CREATE OR REPLACE FUNCTION eth.index_block_stats(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               producer, uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    -- the fees are only known once every tx has its effective gas price and receipt gas used recorded, and are left NULL until then
+    (SELECT CASE WHEN COUNT(*) = COUNT(transaction_cids.effective_gas_price * receipt_cids.gas_used)
+                 THEN COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) END
+      FROM eth.transaction_cids
+      LEFT JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward, header_cids.producer,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, producer, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.producer, EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- queues the timestamps of the canonical blocks whose stats are added, changed, or removed, or that move into or out of the canonical chain
+CREATE FUNCTION eth.queue_block_stats_rollup() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.canonical THEN
+    INSERT INTO eth.block_stats_rollup_queue (timestamp) VALUES (OLD.timestamp);
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.canonical THEN
+    INSERT INTO eth.block_stats_rollup_queue (timestamp) VALUES (NEW.timestamp);
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- recomputes the hourly and daily rollups of the periods in the queue from the canonical block stats, and returns the number of hours recomputed
+-- queue entries appended by block txs that commit while it runs are left for the next refresh
+CREATE FUNCTION eth.refresh_block_stats_rollups() RETURNS BIGINT AS $$
+DECLARE
+  hours NUMERIC[];
+  days NUMERIC[];
+BEGIN
+  -- refreshes are serialized, so that two of them never recompute the same period at once
+  PERFORM pg_advisory_xact_lock(hashtext('eth.refresh_block_stats_rollups'));
+  WITH queued AS (DELETE FROM eth.block_stats_rollup_queue RETURNING timestamp)
+  SELECT array_agg(DISTINCT queued.timestamp - MOD(queued.timestamp, 3600)), array_agg(DISTINCT queued.timestamp - MOD(queued.timestamp, 86400))
+  INTO hours, days FROM queued;
+  IF hours IS NULL THEN
+    RETURN 0;
+  END IF;
+
+  DELETE FROM eth.hourly_block_stats WHERE period_start = ANY(hours);
+  INSERT INTO eth.hourly_block_stats (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, fee_block_count, reward,
+                                      uncle_count, state_node_count, storage_node_count)
+  SELECT period.start, COUNT(*), SUM(block_stats.tx_count), SUM(block_stats.gas_used), SUM(block_stats.gas_limit),
+    COALESCE(SUM(block_stats.total_fees), 0), COUNT(block_stats.total_fees), SUM(block_stats.reward),
+    SUM(block_stats.uncle_count), SUM(block_stats.state_node_count), SUM(block_stats.storage_node_count)
+  FROM unnest(hours) AS period(start)
+  INNER JOIN eth.block_stats ON (block_stats.timestamp >= period.start AND block_stats.timestamp < period.start + 3600)
+  WHERE block_stats.canonical
+  GROUP BY period.start;
+
+  DELETE FROM eth.daily_block_stats WHERE period_start = ANY(days);
+  INSERT INTO eth.daily_block_stats (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, fee_block_count, reward,
+                                     uncle_count, state_node_count, storage_node_count)
+  SELECT period.start, COUNT(*), SUM(block_stats.tx_count), SUM(block_stats.gas_used), SUM(block_stats.gas_limit),
+    COALESCE(SUM(block_stats.total_fees), 0), COUNT(block_stats.total_fees), SUM(block_stats.reward),
+    SUM(block_stats.uncle_count), SUM(block_stats.state_node_count), SUM(block_stats.storage_node_count)
+  FROM unnest(days) AS period(start)
+  INNER JOIN eth.block_stats ON (block_stats.timestamp >= period.start AND block_stats.timestamp < period.start + 86400)
+  WHERE block_stats.canonical
+  GROUP BY period.start;
+  RETURN array_length(hours, 1);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER block_stats_rollup_queue
+    after INSERT OR UPDATE OR DELETE ON eth.block_stats
+    for each row
+    execute procedure eth.queue_block_stats_rollup();
+
+-- rederive the stats of the blocks already indexed, which NULLs the fees that were summed over missing values, and roll them up again
+SELECT eth.index_block_stats(id, block_number) FROM eth.header_cids;
+SELECT eth.refresh_block_stats_rollups();
+
+-- +goose Down
+DROP TRIGGER block_stats_rollup_queue ON eth.block_stats;
+DROP FUNCTION eth.refresh_block_stats_rollups;
+DROP FUNCTION eth.queue_block_stats_rollup;
+
+-- +goose StatementBegin
+-- upserts the stats of the header with the provided id and height from its indexed rows
+-- the canonical flag is only set on insert, it is kept in sync with the header by eth.header_cids_canonical_block_stats
+CREATE OR REPLACE FUNCTION eth.index_block_stats(header INTEGER, height BIGINT) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               producer, uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    (SELECT COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) FROM eth.transaction_cids
+      INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward, header_cids.producer,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, producer, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.producer, EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+UPDATE eth.block_stats SET total_fees = 0 WHERE total_fees IS NULL;
+
+ALTER TABLE eth.block_stats
+ALTER COLUMN total_fees SET NOT NULL;
+
+ALTER TABLE eth.daily_block_stats
+DROP COLUMN fee_block_count;
+
+ALTER TABLE eth.hourly_block_stats
+DROP COLUMN fee_block_count;
+
+COMMENT ON COLUMN eth.block_stats.total_fees IS E'Fees paid by the transactions of the block, including the fees burnt by EIP-1559';
+COMMENT ON COLUMN eth.hourly_block_stats.total_fees IS NULL;
+COMMENT ON COLUMN eth.daily_block_stats.total_fees IS NULL;
+
+DROP INDEX eth.block_stats_timestamp_index;
+
+DROP TABLE eth.block_stats_rollup_queue;
+
+-- +goose StatementBegin
+-- adds (direction = 1) or subtracts (direction = -1) the stats of a block to or from the hourly and daily rollups of its timestamp
+-- rollup rows are removed once they no longer count any block
+CREATE FUNCTION eth.rollup_block_stats(stats eth.block_stats, direction INTEGER) RETURNS VOID AS $$
+BEGIN
+  INSERT INTO eth.hourly_block_stats AS rollup (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+  VALUES (stats.timestamp - MOD(stats.timestamp, 3600), direction, direction * stats.tx_count, direction * stats.gas_used, direction * stats.gas_limit, direction * stats.total_fees,
+          direction * stats.reward, direction * stats.uncle_count, direction * stats.state_node_count, direction * stats.storage_node_count)
+  ON CONFLICT (period_start) DO UPDATE SET (block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (rollup.block_count + EXCLUDED.block_count, rollup.tx_count + EXCLUDED.tx_count, rollup.gas_used + EXCLUDED.gas_used, rollup.gas_limit + EXCLUDED.gas_limit,
+       rollup.total_fees + EXCLUDED.total_fees, rollup.reward + EXCLUDED.reward, rollup.uncle_count + EXCLUDED.uncle_count,
+       rollup.state_node_count + EXCLUDED.state_node_count, rollup.storage_node_count + EXCLUDED.storage_node_count);
+  DELETE FROM eth.hourly_block_stats WHERE period_start = stats.timestamp - MOD(stats.timestamp, 3600) AND block_count = 0;
+
+  INSERT INTO eth.daily_block_stats AS rollup (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+  VALUES (stats.timestamp - MOD(stats.timestamp, 86400), direction, direction * stats.tx_count, direction * stats.gas_used, direction * stats.gas_limit, direction * stats.total_fees,
+          direction * stats.reward, direction * stats.uncle_count, direction * stats.state_node_count, direction * stats.storage_node_count)
+  ON CONFLICT (period_start) DO UPDATE SET (block_count, tx_count, gas_used, gas_limit, total_fees, reward, uncle_count, state_node_count, storage_node_count)
+    = (rollup.block_count + EXCLUDED.block_count, rollup.tx_count + EXCLUDED.tx_count, rollup.gas_used + EXCLUDED.gas_used, rollup.gas_limit + EXCLUDED.gas_limit,
+       rollup.total_fees + EXCLUDED.total_fees, rollup.reward + EXCLUDED.reward, rollup.uncle_count + EXCLUDED.uncle_count,
+       rollup.state_node_count + EXCLUDED.state_node_count, rollup.storage_node_count + EXCLUDED.storage_node_count);
+  DELETE FROM eth.daily_block_stats WHERE period_start = stats.timestamp - MOD(stats.timestamp, 86400) AND block_count = 0;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- keeps the rollups in step with the canonical blocks: the previous stats of a canonical block are subtracted and its new stats added
+CREATE FUNCTION eth.block_stats_rollup() RETURNS TRIGGER AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.canonical THEN
+    PERFORM eth.rollup_block_stats(OLD, -1);
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.canonical THEN
+    PERFORM eth.rollup_block_stats(NEW, 1);
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER block_stats_rollup
+    after INSERT OR UPDATE OR DELETE ON eth.block_stats
+    for each row
+    execute procedure eth.block_stats_rollup();
+
+-- roll up the stats of the canonical blocks again
+DELETE FROM eth.hourly_block_stats;
+DELETE FROM eth.daily_block_stats;
+SELECT eth.rollup_block_stats(block_stats, 1) FROM eth.block_stats WHERE canonical;
@@ -28,815 +28,2687 @@ SET default_tablespace = '';
 SET default_table_access_method = heap;
 
 --
--- Name: header_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: access_list_elements; Type: TABLE; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.header_cids (
+CREATE TABLE eth.access_list_elements (
+    id integer NOT NULL,
+    tx_id integer NOT NULL,
+    index integer NOT NULL,
+    address character varying(66) NOT NULL,
+    storage_keys character varying(66)[]
+);
+
+
+--
+-- Name: TABLE access_list_elements; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.access_list_elements IS '@name EthAccessListElements';
+
+
+--
+-- Name: access_list_elements_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.access_list_elements_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: access_list_elements_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.access_list_elements_id_seq OWNED BY eth.access_list_elements.id;
+
+
+--
+-- Name: account_changes; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.account_changes (
     id integer NOT NULL,
+    header_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    state_leaf_key character varying(66) NOT NULL,
+    prev_balance numeric NOT NULL,
+    balance numeric NOT NULL,
+    prev_nonce integer NOT NULL,
+    nonce integer NOT NULL,
+    code_hash bytea NOT NULL,
+    storage_root character varying(66) NOT NULL,
+    code_changed boolean NOT NULL,
+    storage_root_changed boolean NOT NULL
+);
+
+
+--
+-- Name: TABLE account_changes; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.account_changes IS '@name EthAccountChanges';
+
+
+--
+-- Name: COLUMN account_changes.prev_balance; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.account_changes.prev_balance IS 'Balance of the account in its latest snapshot in a lower canonical block, 0 if it did not exist there';
+
+
+--
+-- Name: COLUMN account_changes.prev_nonce; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.account_changes.prev_nonce IS 'Nonce of the account in its latest snapshot in a lower canonical block, 0 if it did not exist there';
+
+
+--
+-- Name: account_changes_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.account_changes_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: account_changes_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.account_changes_id_seq OWNED BY eth.account_changes.id;
+
+
+--
+-- Name: address_transactions; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.address_transactions (
+    id integer NOT NULL,
+    address character varying(66) NOT NULL,
+    tx_id integer NOT NULL,
+    role integer NOT NULL
+);
+
+
+--
+-- Name: TABLE address_transactions; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.address_transactions IS '@name EthAddressTransactions';
+
+
+--
+-- Name: COLUMN address_transactions.role; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.address_transactions.role IS '0 = sender, 1 = recipient, 2 = created contract, 3 = log emitter';
+
+
+--
+-- Name: address_transactions_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.address_transactions_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: address_transactions_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.address_transactions_id_seq OWNED BY eth.address_transactions.id;
+
+
+--
+-- Name: block_stats; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.block_stats (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
     block_number bigint NOT NULL,
-    block_hash character varying(66) NOT NULL,
-    parent_hash character varying(66) NOT NULL,
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    td numeric NOT NULL,
-    node_id integer NOT NULL,
-    reward numeric NOT NULL,
-    state_root character varying(66) NOT NULL,
-    tx_root character varying(66) NOT NULL,
-    receipt_root character varying(66) NOT NULL,
-    uncle_root character varying(66) NOT NULL,
-    bloom bytea NOT NULL,
     "timestamp" numeric NOT NULL,
-    times_validated integer DEFAULT 1 NOT NULL
+    canonical boolean DEFAULT false NOT NULL,
+    tx_count integer NOT NULL,
+    gas_used bigint NOT NULL,
+    gas_limit bigint NOT NULL,
+    total_fees numeric,
+    reward numeric NOT NULL,
+    uncle_count integer NOT NULL,
+    state_node_count integer NOT NULL,
+    storage_node_count integer NOT NULL,
+    producer character varying(66)
 );
 
 
 --
--- Name: TABLE header_cids; Type: COMMENT; Schema: eth; Owner: -
+-- Name: TABLE block_stats; Type: COMMENT; Schema: eth; Owner: -
 --
 
-COMMENT ON TABLE eth.header_cids IS '@name EthHeaderCids';
+COMMENT ON TABLE eth.block_stats IS '@name EthBlockStats';
 
 
 --
--- Name: COLUMN header_cids.node_id; Type: COMMENT; Schema: eth; Owner: -
+-- Name: COLUMN block_stats.canonical; Type: COMMENT; Schema: eth; Owner: -
 --
 
-COMMENT ON COLUMN eth.header_cids.node_id IS '@name EthNodeID';
+COMMENT ON COLUMN eth.block_stats.canonical IS 'Mirrors the canonical flag of the header, only canonical blocks are counted in the hourly and daily rollups';
 
 
 --
--- Name: child_result; Type: TYPE; Schema: public; Owner: -
+-- Name: COLUMN block_stats.total_fees; Type: COMMENT; Schema: eth; Owner: -
 --
 
-CREATE TYPE public.child_result AS (
-	has_child boolean,
-	children eth.header_cids[]
+COMMENT ON COLUMN eth.block_stats.total_fees IS 'Fees paid by the transactions of the block, including the fees burnt by EIP-1559, NULL while any of its transactions has no effective gas price or receipt gas used recorded';
+
+
+--
+-- Name: COLUMN block_stats.reward; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.block_stats.reward IS 'Reward paid to the miner of the block, as in eth.header_cids';
+
+
+--
+-- Name: COLUMN block_stats.state_node_count; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.block_stats.state_node_count IS 'State nodes written or removed by the block';
+
+
+--
+-- Name: COLUMN block_stats.storage_node_count; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.block_stats.storage_node_count IS 'Storage nodes written or removed by the block';
+
+
+--
+-- Name: COLUMN block_stats.producer; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.block_stats.producer IS 'Address that produced the block, as in eth.header_cids';
+
+
+--
+-- Name: block_stats_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.block_stats_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: block_stats_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.block_stats_id_seq OWNED BY eth.block_stats.id;
+
+
+--
+-- Name: block_stats_rollup_queue; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.block_stats_rollup_queue (
+    "timestamp" numeric NOT NULL
 );
 
 
 --
--- Name: graphql_subscription(); Type: FUNCTION; Schema: eth; Owner: -
+-- Name: TABLE block_stats_rollup_queue; Type: COMMENT; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION eth.graphql_subscription() RETURNS trigger
-    LANGUAGE plpgsql
-    AS $_$
-declare
-    table_name text = TG_ARGV[0];
-    attribute text = TG_ARGV[1];
-    id text;
-begin
-    execute 'select $1.' || quote_ident(attribute)
-        using new
-        into id;
-    perform pg_notify('postgraphile:' || table_name,
-                      json_build_object(
-                              '__node__', json_build_array(
-                              table_name,
-                              id
-                          )
-                          )::text
-        );
-    return new;
-end;
-$_$;
+COMMENT ON TABLE eth.block_stats_rollup_queue IS 'Timestamps of the canonical blocks whose stats changed since the hourly and daily rollups were last refreshed';
 
 
 --
--- Name: canonical_header_from_array(eth.header_cids[]); Type: FUNCTION; Schema: public; Owner: -
+-- Name: contracts; Type: TABLE; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION public.canonical_header_from_array(headers eth.header_cids[]) RETURNS eth.header_cids
-    LANGUAGE plpgsql
-    AS $$
-DECLARE
-canonical_header eth.header_cids;
-  canonical_child eth.header_cids;
-  header eth.header_cids;
-  current_child_result child_result;
-  child_headers eth.header_cids[];
-  current_header_with_child eth.header_cids;
-  has_children_count INT DEFAULT 0;
-BEGIN
-  -- for each header in the provided set
-  FOREACH header IN ARRAY headers
-  LOOP
-    -- check if it has any children
-    current_child_result = has_child(header.block_hash, header.block_number);
-    IF current_child_result.has_child THEN
-      -- if it does, take note
-      has_children_count = has_children_count + 1;
-      current_header_with_child = header;
-      -- and add the children to the growing set of child headers
-      child_headers = array_cat(child_headers, current_child_result.children);
-END IF;
-END LOOP;
-  -- if none of the headers had children, none is more canonical than the other
-  IF has_children_count = 0 THEN
-    -- return the first one selected
-SELECT * INTO canonical_header FROM unnest(headers) LIMIT 1;
--- if only one header had children, it can be considered the heaviest/canonical header of the set
-ELSIF has_children_count = 1 THEN
-    -- return the only header with a child
-    canonical_header = current_header_with_child;
-  -- if there are multiple headers with children
-ELSE
-    -- find the canonical header from the child set
-    canonical_child = canonical_header_from_array(child_headers);
-    -- the header that is parent to this header, is the canonical header at this level
-SELECT * INTO canonical_header FROM unnest(headers)
-WHERE block_hash = canonical_child.parent_hash;
-END IF;
-RETURN canonical_header;
-END
-$$;
+CREATE TABLE eth.contracts (
+    id integer NOT NULL,
+    tx_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    address character varying(66) NOT NULL,
+    creator character varying(66) NOT NULL,
+    code_hash bytea,
+    code_mh_key text
+);
 
 
 --
--- Name: canonical_header_id(bigint); Type: FUNCTION; Schema: public; Owner: -
+-- Name: TABLE contracts; Type: COMMENT; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION public.canonical_header_id(height bigint) RETURNS integer
-    LANGUAGE plpgsql
-    AS $$
-DECLARE
-canonical_header eth.header_cids;
-  headers eth.header_cids[];
-  header_count INT;
-  temp_header eth.header_cids;
-BEGIN
-  -- collect all headers at this height
-FOR temp_header IN
-SELECT * FROM eth.header_cids WHERE block_number = height
-    LOOP
-    headers = array_append(headers, temp_header);
-END LOOP;
-  -- count the number of headers collected
-  header_count = array_length(headers, 1);
-  -- if we have less than 1 header, return NULL
-  IF header_count IS NULL OR header_count < 1 THEN
-    RETURN NULL;
-  -- if we have one header, return its id
-  ELSIF header_count = 1 THEN
-    RETURN headers[1].id;
-  -- if we have multiple headers we need to determine which one is canonical
-ELSE
-    canonical_header = canonical_header_from_array(headers);
-RETURN canonical_header.id;
-END IF;
-END;
+COMMENT ON TABLE eth.contracts IS '@name EthContracts';
+
+
+--
+-- Name: contracts_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.contracts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: contracts_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.contracts_id_seq OWNED BY eth.contracts.id;
+
+
+--
+-- Name: daily_block_stats; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.daily_block_stats (
+    period_start numeric NOT NULL,
+    block_count bigint NOT NULL,
+    tx_count bigint NOT NULL,
+    gas_used numeric NOT NULL,
+    gas_limit numeric NOT NULL,
+    total_fees numeric NOT NULL,
+    reward numeric NOT NULL,
+    uncle_count bigint NOT NULL,
+    state_node_count bigint NOT NULL,
+    storage_node_count bigint NOT NULL,
+    fee_block_count bigint DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: TABLE daily_block_stats; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.daily_block_stats IS '@name EthDailyBlockStats';
+
+
+--
+-- Name: COLUMN daily_block_stats.period_start; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.daily_block_stats.period_start IS 'Unix timestamp of the start of the day, in UTC';
+
+
+--
+-- Name: COLUMN daily_block_stats.total_fees; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.daily_block_stats.total_fees IS 'Fees of the blocks with known fees, see fee_block_count';
+
+
+--
+-- Name: COLUMN daily_block_stats.fee_block_count; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.daily_block_stats.fee_block_count IS 'Blocks whose fees are known and summed in total_fees, fewer than block_count while some have NULL fees';
+
+
+--
+-- Name: decoded_events; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.decoded_events (
+    id integer NOT NULL,
+    log_id integer NOT NULL,
+    address character varying(66) NOT NULL,
+    event_name character varying(128) NOT NULL,
+    signature text NOT NULL,
+    args jsonb NOT NULL
+);
+
+
+--
+-- Name: TABLE decoded_events; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.decoded_events IS '@name EthDecodedEvents';
+
+
+--
+-- Name: COLUMN decoded_events.signature; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_events.signature IS 'Canonical signature of the event, e.g. Transfer(address,address,uint256)';
+
+
+--
+-- Name: COLUMN decoded_events.args; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_events.args IS 'Arguments of the event by name, with integers as JSON numbers and addresses, hashes, and bytes as hex strings; indexed dynamic arguments hold the hash in their topic';
+
+
+--
+-- Name: decoded_events_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.decoded_events_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: decoded_events_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.decoded_events_id_seq OWNED BY eth.decoded_events.id;
+
+
+--
+-- Name: decoded_storage; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.decoded_storage (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    state_leaf_key character varying(66) NOT NULL,
+    storage_leaf_key character varying(66) NOT NULL,
+    contract character varying(66) NOT NULL,
+    variable text NOT NULL,
+    keys text[] NOT NULL,
+    type text NOT NULL,
+    value jsonb NOT NULL
+);
+
+
+--
+-- Name: TABLE decoded_storage; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.decoded_storage IS '@name EthDecodedStorage';
+
+
+--
+-- Name: COLUMN decoded_storage.variable; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_storage.variable IS 'Name of the variable held in the slot, with the path to struct members and array elements, e.g. config.owner or values[2]';
+
+
+--
+-- Name: COLUMN decoded_storage.keys; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_storage.keys IS 'Mapping keys leading to the variable, outermost first';
+
+
+--
+-- Name: COLUMN decoded_storage.type; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_storage.type IS 'Solidity type of the variable, as labelled in the storage layout';
+
+
+--
+-- Name: COLUMN decoded_storage.value; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.decoded_storage.value IS 'Decoded value, {"length": n} for strings and bytes too long to be held in the slot';
+
+
+--
+-- Name: decoded_storage_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.decoded_storage_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: decoded_storage_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.decoded_storage_id_seq OWNED BY eth.decoded_storage.id;
+
+
+--
+-- Name: header_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.header_cids (
+    id integer NOT NULL,
+    block_number bigint NOT NULL,
+    block_hash character varying(66) NOT NULL,
+    parent_hash character varying(66) NOT NULL,
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    td numeric NOT NULL,
+    node_id integer NOT NULL,
+    reward numeric NOT NULL,
+    state_root character varying(66) NOT NULL,
+    tx_root character varying(66) NOT NULL,
+    receipt_root character varying(66) NOT NULL,
+    uncle_root character varying(66) NOT NULL,
+    bloom bytea NOT NULL,
+    "timestamp" numeric NOT NULL,
+    times_validated integer DEFAULT 1 NOT NULL,
+    coinbase character varying(66),
+    difficulty numeric,
+    gas_limit bigint,
+    gas_used bigint,
+    extra_data bytea,
+    mix_digest character varying(66),
+    nonce numeric,
+    base_fee numeric,
+    burnt_fees numeric,
+    canonical boolean DEFAULT false NOT NULL,
+    producer character varying(66)
+)
+PARTITION BY RANGE (block_number);
+
+
+--
+-- Name: TABLE header_cids; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.header_cids IS '@name EthHeaderCids';
+
+
+--
+-- Name: COLUMN header_cids.node_id; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.header_cids.node_id IS '@name EthNodeID';
+
+
+--
+-- Name: COLUMN header_cids.burnt_fees; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.header_cids.burnt_fees IS 'Base fee multiplied by the gas used by the block, burnt under EIP-1559';
+
+
+--
+-- Name: COLUMN header_cids.canonical; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.header_cids.canonical IS 'Whether the header is part of the chain with the most total difficulty, maintained by the indexer';
+
+
+--
+-- Name: COLUMN header_cids.producer; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.header_cids.producer IS 'Address that produced the block: the coinbase, or the signer on Clique chains';
+
+
+--
+-- Name: child_result; Type: TYPE; Schema: public; Owner: -
+--
+
+CREATE TYPE public.child_result AS (
+	has_child boolean,
+	children eth.header_cids[]
+);
+
+
+--
+-- Name: apply_latest_state(integer, bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.apply_latest_state(header integer, height bigint) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  -- accounts destroyed by the block lose their earlier state and storage
+  DELETE FROM eth.latest_storage USING eth.state_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.destroyed
+  AND latest_storage.state_leaf_key = removed.state_leaf_key AND latest_storage.block_number < height;
+  DELETE FROM eth.latest_accounts USING eth.state_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.destroyed
+  AND latest_accounts.state_leaf_key = removed.state_leaf_key AND latest_accounts.block_number < height;
+
+  -- slots cleared by the block lose their earlier value
+  DELETE FROM eth.latest_storage USING eth.storage_removals removed
+  WHERE removed.header_id = header AND removed.block_number = height AND removed.cleared
+  AND latest_storage.state_leaf_key = removed.state_leaf_key AND latest_storage.storage_leaf_key = removed.storage_leaf_key
+  AND latest_storage.block_number < height;
+
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id, state_cids.id, state_cids.state_path,
+    state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+  FROM eth.state_cids
+  INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+  WHERE state_cids.header_id = header AND state_cids.block_number = height AND state_cids.node_type = 2
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.block_number > height)
+  ON CONFLICT (state_leaf_key) DO UPDATE SET (block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root) =
+    (EXCLUDED.block_number, EXCLUDED.header_id, EXCLUDED.state_id, EXCLUDED.state_path, EXCLUDED.balance, EXCLUDED.nonce, EXCLUDED.code_hash, EXCLUDED.storage_root)
+  WHERE latest_accounts.block_number <= EXCLUDED.block_number;
+
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number, state_cids.header_id,
+    storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+  FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE state_cids.header_id = header AND state_cids.block_number = height AND storage_cids.node_type = 2
+  -- the slot has not been cleared since
+  AND NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.cleared
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.storage_leaf_key = storage_cids.storage_leaf_key
+                  AND removed.block_number > height)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = state_cids.state_leaf_key AND removed.block_number > height)
+  ON CONFLICT (state_leaf_key, storage_leaf_key) DO UPDATE SET (block_number, header_id, storage_path, cid, mh_key) =
+    (EXCLUDED.block_number, EXCLUDED.header_id, EXCLUDED.storage_path, EXCLUDED.cid, EXCLUDED.mh_key)
+  WHERE latest_storage.block_number <= EXCLUDED.block_number;
+END
+$$;
+
+
+--
+-- Name: block_partition_lower(bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.block_partition_lower(height bigint) RETURNS bigint
+    LANGUAGE sql IMMUTABLE
+    AS $$
+SELECT height - height % eth.block_partition_width();
+$$;
+
+
+--
+-- Name: block_partition_width(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.block_partition_width() RETURNS bigint
+    LANGUAGE sql IMMUTABLE
+    AS $$
+SELECT 100000::BIGINT;
+$$;
+
+
+--
+-- Name: create_block_partitions(bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.create_block_partitions(height bigint) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  lower_bound BIGINT = eth.block_partition_lower(height);
+  upper_bound BIGINT = lower_bound + eth.block_partition_width();
+  parent TEXT;
+BEGIN
+  -- serialize writers racing to create the same partitions
+  PERFORM pg_advisory_xact_lock(hashtext('eth.create_block_partitions'));
+  FOREACH parent IN ARRAY ARRAY['header_cids', 'state_cids', 'storage_cids'] LOOP
+    EXECUTE format('CREATE TABLE IF NOT EXISTS eth.%I PARTITION OF eth.%I FOR VALUES FROM (%s) TO (%s)',
+                   parent || '_' || lower_bound, parent, lower_bound, upper_bound);
+  END LOOP;
+END
+$$;
+
+
+--
+-- Name: detach_block_partitions(bigint, boolean); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.detach_block_partitions(height bigint, drop_tables boolean) RETURNS boolean
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  lower_bound BIGINT = eth.block_partition_lower(height);
+  parent TEXT;
+  partition_name TEXT;
+  fk_name TEXT;
+  detached BOOLEAN = FALSE;
+BEGIN
+  -- detach the referencing partitions first
+  FOREACH parent IN ARRAY ARRAY['storage_cids', 'state_cids', 'header_cids'] LOOP
+    partition_name = parent || '_' || lower_bound;
+    IF to_regclass(format('eth.%I', partition_name)) IS NULL THEN
+      CONTINUE;
+    END IF;
+    EXECUTE format('ALTER TABLE eth.%I DETACH PARTITION eth.%I', parent, partition_name);
+    FOR fk_name IN SELECT conname FROM pg_constraint
+                   WHERE conrelid = format('eth.%I', partition_name)::regclass AND contype = 'f' LOOP
+      EXECUTE format('ALTER TABLE eth.%I DROP CONSTRAINT %I', partition_name, fk_name);
+    END LOOP;
+    IF drop_tables THEN
+      EXECUTE format('DROP TABLE eth.%I', partition_name);
+    ELSE
+      EXECUTE format('ALTER TABLE eth.%I RENAME TO %I', partition_name, partition_name || '_detached');
+    END IF;
+    detached = TRUE;
+  END LOOP;
+  RETURN detached;
+END
 $$;
 
 
 --
--- Name: has_child(character varying, bigint); Type: FUNCTION; Schema: public; Owner: -
+-- Name: graphql_subscription(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.graphql_subscription() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $_$
+declare
+    table_name text = TG_ARGV[0];
+    attribute text = TG_ARGV[1];
+    id text;
+begin
+    execute 'select $1.' || quote_ident(attribute)
+        using new
+        into id;
+    perform pg_notify('postgraphile:' || table_name,
+                      json_build_object(
+                              '__node__', json_build_array(
+                              table_name,
+                              id
+                          )
+                          )::text
+        );
+    return new;
+end;
+$_$;
+
+
+--
+-- Name: header_cids_canonical_block_stats(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.header_cids_canonical_block_stats() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  UPDATE eth.block_stats SET canonical = NEW.canonical WHERE header_id = NEW.id;
+  RETURN NULL;
+END
+$$;
+
+
+--
+-- Name: index_block_stats(integer, bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.index_block_stats(header integer, height bigint) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  INSERT INTO eth.block_stats (header_id, block_number, timestamp, canonical, tx_count, gas_used, gas_limit, total_fees, reward,
+                               producer, uncle_count, state_node_count, storage_node_count)
+  SELECT header_cids.id, header_cids.block_number, header_cids.timestamp, header_cids.canonical,
+    (SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id),
+    COALESCE(header_cids.gas_used, 0), COALESCE(header_cids.gas_limit, 0),
+    -- the fees are only known once every tx has its effective gas price and receipt gas used recorded, and are left NULL until then
+    (SELECT CASE WHEN COUNT(*) = COUNT(transaction_cids.effective_gas_price * receipt_cids.gas_used)
+                 THEN COALESCE(SUM(transaction_cids.effective_gas_price * receipt_cids.gas_used), 0) END
+      FROM eth.transaction_cids
+      LEFT JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+      WHERE transaction_cids.header_id = header_cids.id),
+    header_cids.reward, header_cids.producer,
+    (SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id),
+    (SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+      WHERE state_cids.header_id = header_cids.id) +
+      (SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id)
+  FROM eth.header_cids
+  WHERE header_cids.id = header AND header_cids.block_number = height
+  ON CONFLICT (header_id) DO UPDATE SET (block_number, timestamp, tx_count, gas_used, gas_limit, total_fees, reward, producer, uncle_count, state_node_count, storage_node_count)
+    = (EXCLUDED.block_number, EXCLUDED.timestamp, EXCLUDED.tx_count, EXCLUDED.gas_used, EXCLUDED.gas_limit, EXCLUDED.total_fees, EXCLUDED.reward,
+       EXCLUDED.producer, EXCLUDED.uncle_count, EXCLUDED.state_node_count, EXCLUDED.storage_node_count);
+END
+$$;
+
+
+--
+-- Name: queue_block_stats_rollup(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.queue_block_stats_rollup() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.canonical THEN
+    INSERT INTO eth.block_stats_rollup_queue (timestamp) VALUES (OLD.timestamp);
+  END IF;
+  IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.canonical THEN
+    INSERT INTO eth.block_stats_rollup_queue (timestamp) VALUES (NEW.timestamp);
+  END IF;
+  RETURN NULL;
+END
+$$;
+
+
+--
+-- Name: recompute_latest_state(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.recompute_latest_state() RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  IF to_regclass('pg_temp.touched_accounts') IS NULL THEN
+    RETURN;
+  END IF;
+
+  DELETE FROM eth.latest_accounts WHERE state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts);
+  INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+  SELECT * FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key) state_cids.state_leaf_key, state_cids.block_number, state_cids.header_id,
+      state_cids.id AS state_id, state_cids.state_path, state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+    FROM eth.state_cids
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    INNER JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+    WHERE header_cids.canonical AND state_cids.node_type = 2
+    AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM touched_accounts)
+    ORDER BY state_cids.state_leaf_key, state_cids.block_number DESC
+  ) AS latest
+  WHERE NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.destroyed
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DELETE FROM eth.latest_storage WHERE (state_leaf_key, storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage);
+  INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+  SELECT latest.state_leaf_key, latest.storage_leaf_key, latest.block_number, latest.header_id, latest.storage_path, latest.cid, latest.mh_key FROM (
+    SELECT DISTINCT ON (state_cids.state_leaf_key, storage_cids.storage_leaf_key) state_cids.state_leaf_key, storage_cids.storage_leaf_key,
+      storage_cids.block_number, state_cids.header_id, storage_cids.storage_path, storage_cids.cid, storage_cids.mh_key
+    FROM eth.storage_cids
+    INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+    INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+    WHERE header_cids.canonical AND storage_cids.node_type = 2
+    AND (state_cids.state_leaf_key, storage_cids.storage_leaf_key) IN (SELECT state_leaf_key, storage_leaf_key FROM touched_storage)
+    ORDER BY state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.block_number DESC
+  ) AS latest
+  -- the slot has not been cleared since
+  WHERE NOT EXISTS (SELECT 1 FROM eth.storage_removals removed
+                    INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                    WHERE header_cids.canonical AND removed.cleared
+                    AND removed.state_leaf_key = latest.state_leaf_key AND removed.storage_leaf_key = latest.storage_leaf_key
+                    AND removed.block_number > latest.block_number)
+  -- and the account has not been destroyed since
+  AND NOT EXISTS (SELECT 1 FROM eth.state_removals removed
+                  INNER JOIN eth.header_cids ON (removed.header_id = header_cids.id AND removed.block_number = header_cids.block_number)
+                  WHERE header_cids.canonical AND removed.destroyed
+                  AND removed.state_leaf_key = latest.state_leaf_key AND removed.block_number > latest.block_number);
+
+  DROP TABLE touched_accounts;
+  DROP TABLE touched_storage;
+END
+$$;
+
+
+--
+-- Name: refresh_block_stats_rollups(); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.refresh_block_stats_rollups() RETURNS bigint
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  hours NUMERIC[];
+  days NUMERIC[];
+BEGIN
+  -- refreshes are serialized, so that two of them never recompute the same period at once
+  PERFORM pg_advisory_xact_lock(hashtext('eth.refresh_block_stats_rollups'));
+  WITH queued AS (DELETE FROM eth.block_stats_rollup_queue RETURNING timestamp)
+  SELECT array_agg(DISTINCT queued.timestamp - MOD(queued.timestamp, 3600)), array_agg(DISTINCT queued.timestamp - MOD(queued.timestamp, 86400))
+  INTO hours, days FROM queued;
+  IF hours IS NULL THEN
+    RETURN 0;
+  END IF;
+
+  DELETE FROM eth.hourly_block_stats WHERE period_start = ANY(hours);
+  INSERT INTO eth.hourly_block_stats (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, fee_block_count, reward,
+                                      uncle_count, state_node_count, storage_node_count)
+  SELECT period.start, COUNT(*), SUM(block_stats.tx_count), SUM(block_stats.gas_used), SUM(block_stats.gas_limit),
+    COALESCE(SUM(block_stats.total_fees), 0), COUNT(block_stats.total_fees), SUM(block_stats.reward),
+    SUM(block_stats.uncle_count), SUM(block_stats.state_node_count), SUM(block_stats.storage_node_count)
+  FROM unnest(hours) AS period(start)
+  INNER JOIN eth.block_stats ON (block_stats.timestamp >= period.start AND block_stats.timestamp < period.start + 3600)
+  WHERE block_stats.canonical
+  GROUP BY period.start;
+
+  DELETE FROM eth.daily_block_stats WHERE period_start = ANY(days);
+  INSERT INTO eth.daily_block_stats (period_start, block_count, tx_count, gas_used, gas_limit, total_fees, fee_block_count, reward,
+                                     uncle_count, state_node_count, storage_node_count)
+  SELECT period.start, COUNT(*), SUM(block_stats.tx_count), SUM(block_stats.gas_used), SUM(block_stats.gas_limit),
+    COALESCE(SUM(block_stats.total_fees), 0), COUNT(block_stats.total_fees), SUM(block_stats.reward),
+    SUM(block_stats.uncle_count), SUM(block_stats.state_node_count), SUM(block_stats.storage_node_count)
+  FROM unnest(days) AS period(start)
+  INNER JOIN eth.block_stats ON (block_stats.timestamp >= period.start AND block_stats.timestamp < period.start + 86400)
+  WHERE block_stats.canonical
+  GROUP BY period.start;
+  RETURN array_length(hours, 1);
+END
+$$;
+
+
+--
+-- Name: refresh_latest_state(bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.refresh_latest_state(from_height bigint) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  PERFORM eth.touch_latest_state(from_height, NULL);
+  PERFORM eth.recompute_latest_state();
+END
+$$;
+
+
+--
+-- Name: touch_latest_state(bigint, bigint); Type: FUNCTION; Schema: eth; Owner: -
+--
+
+CREATE FUNCTION eth.touch_latest_state(start_height bigint, stop_height bigint) RETURNS void
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  stop BIGINT := COALESCE(stop_height, 9223372036854775807);
+BEGIN
+  CREATE TEMP TABLE IF NOT EXISTS touched_accounts (
+    state_leaf_key VARCHAR(66) PRIMARY KEY
+  ) ON COMMIT DROP;
+  CREATE TEMP TABLE IF NOT EXISTS touched_storage (
+    state_leaf_key   VARCHAR(66) NOT NULL,
+    storage_leaf_key VARCHAR(66) NOT NULL,
+    PRIMARY KEY (state_leaf_key, storage_leaf_key)
+  ) ON COMMIT DROP;
+
+  -- accounts written or destroyed within the range
+  INSERT INTO touched_accounts (state_leaf_key)
+  SELECT state_leaf_key FROM eth.state_cids
+  WHERE block_number BETWEEN start_height AND stop AND node_type = 2
+  UNION
+  SELECT state_leaf_key FROM eth.state_removals
+  WHERE block_number BETWEEN start_height AND stop AND destroyed
+  ON CONFLICT DO NOTHING;
+
+  -- slots written or cleared within the range, and every slot of the accounts destroyed within the range
+  INSERT INTO touched_storage (state_leaf_key, storage_leaf_key)
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.block_number BETWEEN start_height AND stop AND storage_cids.node_type = 2
+  UNION
+  SELECT state_leaf_key, storage_leaf_key FROM eth.storage_removals
+  WHERE block_number BETWEEN start_height AND stop AND cleared
+  UNION
+  SELECT state_cids.state_leaf_key, storage_cids.storage_leaf_key FROM eth.storage_cids
+  INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+  WHERE storage_cids.node_type = 2
+  AND state_cids.state_leaf_key IN (SELECT state_leaf_key FROM eth.state_removals
+                                    WHERE block_number BETWEEN start_height AND stop AND destroyed)
+  ON CONFLICT DO NOTHING;
+END
+$$;
+
+
+--
+-- Name: account_exists_at(character varying, bigint); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.account_exists_at(key character varying, height bigint) RETURNS boolean
+    LANGUAGE sql STABLE
+    AS $$
+SELECT exists(SELECT 1
+              FROM eth.state_cids
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+              WHERE state_cids.state_leaf_key = key
+                AND state_cids.node_type = 2
+                AND state_cids.block_number <= height
+                AND header_cids.canonical
+                AND NOT exists(SELECT 1
+                               FROM eth.state_removals
+                                        INNER JOIN eth.header_cids removal_header ON (state_removals.header_id = removal_header.id AND state_removals.block_number = removal_header.block_number)
+                               WHERE state_removals.state_leaf_key = key
+                                 AND state_removals.destroyed
+                                 AND state_removals.block_number > state_cids.block_number
+                                 AND state_removals.block_number <= height
+                                 AND removal_header.canonical)
+              LIMIT 1);
+$$;
+
+
+--
+-- Name: canonical_block_range(numeric, numeric); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.canonical_block_range(start_ts numeric, stop_ts numeric, OUT start bigint, OUT stop bigint) RETURNS record
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+  -- the range spans the first canonical block at or after the start of the window
+  -- to the last canonical block at or before the end of it
+SELECT block_number INTO start FROM canonical_header_after_time(start_ts);
+SELECT block_number INTO stop FROM canonical_header_at_time(stop_ts);
+  -- if no block falls inside the window, there is no range
+  IF start IS NULL OR stop IS NULL OR start > stop THEN
+    start = NULL;
+    stop = NULL;
+END IF;
+END;
+$$;
+
+
+--
+-- Name: canonical_header_after_time(numeric); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.canonical_header_after_time(ts numeric) RETURNS eth.header_cids
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  candidate eth.header_cids;
+  lo BIGINT;
+  hi BIGINT;
+  mid BIGINT;
+  probe_number BIGINT;
+  probe_ts NUMERIC;
+  match_number BIGINT;
+BEGIN
+  -- the earliest header at or after the timestamp, found with timestamp_index, is the one we want if it is canonical
+  -- since every canonical header below it is earlier than it, and so earlier than the timestamp
+SELECT * INTO candidate FROM eth.header_cids WHERE timestamp >= ts
+ORDER BY timestamp ASC, block_number ASC LIMIT 1;
+IF NOT FOUND THEN
+    RETURN NULL;
+END IF;
+  IF candidate.canonical THEN
+    RETURN candidate;
+END IF;
+  -- otherwise binary search the canonical heights for the lowest one at or after the timestamp
+SELECT MIN(block_number), MAX(block_number) INTO lo, hi FROM eth.header_cids WHERE canonical;
+WHILE lo <= hi LOOP
+    mid = lo + (hi - lo) / 2;
+SELECT block_number, timestamp INTO probe_number, probe_ts FROM eth.header_cids
+WHERE canonical AND block_number BETWEEN mid AND hi
+ORDER BY block_number LIMIT 1;
+IF NOT FOUND THEN
+      hi = mid - 1;
+    ELSIF probe_ts >= ts THEN
+      match_number = probe_number;
+      hi = mid - 1;
+ELSE
+      lo = probe_number + 1;
+END IF;
+END LOOP;
+  IF match_number IS NULL THEN
+    RETURN NULL;
+END IF;
+SELECT * INTO candidate FROM eth.header_cids WHERE block_number = match_number AND canonical;
+RETURN candidate;
+END;
+$$;
+
+
+--
+-- Name: canonical_header_at_time(numeric); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.canonical_header_at_time(ts numeric) RETURNS eth.header_cids
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+  candidate eth.header_cids;
+  lo BIGINT;
+  hi BIGINT;
+  mid BIGINT;
+  probe_number BIGINT;
+  probe_ts NUMERIC;
+  match_number BIGINT;
+BEGIN
+  -- the latest header at or before the timestamp, found with timestamp_index, is the one we want if it is canonical
+  -- since every canonical header above it is later than it, and so later than the timestamp
+SELECT * INTO candidate FROM eth.header_cids WHERE timestamp <= ts
+ORDER BY timestamp DESC, block_number DESC LIMIT 1;
+IF NOT FOUND THEN
+    RETURN NULL;
+END IF;
+  IF candidate.canonical THEN
+    RETURN candidate;
+END IF;
+  -- otherwise binary search the canonical heights for the highest one at or before the timestamp
+  -- canonical timestamps increase with height, and each probe takes the first canonical header at or above the midpoint so gaps are skipped
+SELECT MIN(block_number), MAX(block_number) INTO lo, hi FROM eth.header_cids WHERE canonical;
+WHILE lo <= hi LOOP
+    mid = lo + (hi - lo) / 2;
+SELECT block_number, timestamp INTO probe_number, probe_ts FROM eth.header_cids
+WHERE canonical AND block_number BETWEEN mid AND hi
+ORDER BY block_number LIMIT 1;
+IF NOT FOUND THEN
+      hi = mid - 1;
+    ELSIF probe_ts <= ts THEN
+      match_number = probe_number;
+      lo = probe_number + 1;
+ELSE
+      hi = mid - 1;
+END IF;
+END LOOP;
+  IF match_number IS NULL THEN
+    RETURN NULL;
+END IF;
+SELECT * INTO candidate FROM eth.header_cids WHERE block_number = match_number AND canonical;
+RETURN candidate;
+END;
+$$;
+
+
+--
+-- Name: canonical_header_from_array(eth.header_cids[]); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.canonical_header_from_array(headers eth.header_cids[]) RETURNS eth.header_cids
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+canonical_header eth.header_cids;
+  canonical_child eth.header_cids;
+  header eth.header_cids;
+  current_child_result child_result;
+  child_headers eth.header_cids[];
+  current_header_with_child eth.header_cids;
+  has_children_count INT DEFAULT 0;
+BEGIN
+  -- for each header in the provided set
+  FOREACH header IN ARRAY headers
+  LOOP
+    -- check if it has any children
+    current_child_result = has_child(header.block_hash, header.block_number);
+    IF current_child_result.has_child THEN
+      -- if it does, take note
+      has_children_count = has_children_count + 1;
+      current_header_with_child = header;
+      -- and add the children to the growing set of child headers
+      child_headers = array_cat(child_headers, current_child_result.children);
+END IF;
+END LOOP;
+  -- if none of the headers had children, none is more canonical than the other
+  IF has_children_count = 0 THEN
+    -- return the first one selected
+SELECT * INTO canonical_header FROM unnest(headers) LIMIT 1;
+-- if only one header had children, it can be considered the heaviest/canonical header of the set
+ELSIF has_children_count = 1 THEN
+    -- return the only header with a child
+    canonical_header = current_header_with_child;
+  -- if there are multiple headers with children
+ELSE
+    -- find the canonical header from the child set
+    canonical_child = canonical_header_from_array(child_headers);
+    -- the header that is parent to this header, is the canonical header at this level
+SELECT * INTO canonical_header FROM unnest(headers)
+WHERE block_hash = canonical_child.parent_hash;
+END IF;
+RETURN canonical_header;
+END
+$$;
+
+
+--
+-- Name: canonical_header_id(bigint); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.canonical_header_id(height bigint) RETURNS integer
+    LANGUAGE sql STABLE
+    AS $$
+SELECT id FROM eth.header_cids WHERE block_number = height AND canonical;
+$$;
+
+
+--
+-- Name: has_child(character varying, bigint); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.has_child(hash character varying, height bigint) RETURNS public.child_result
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+child_height INT;
+  temp_child eth.header_cids;
+  new_child_result child_result;
+BEGIN
+  child_height = height + 1;
+  -- short circuit if there are no children
+SELECT exists(SELECT 1
+              FROM eth.header_cids
+              WHERE parent_hash = hash
+                AND block_number = child_height
+              LIMIT 1)
+INTO new_child_result.has_child;
+-- collect all the children for this header
+IF new_child_result.has_child THEN
+    FOR temp_child IN
+SELECT * FROM eth.header_cids WHERE parent_hash = hash AND block_number = child_height
+    LOOP
+      new_child_result.children = array_append(new_child_result.children, temp_child);
+END LOOP;
+END IF;
+RETURN new_child_result;
+END
+$$;
+
+
+--
+-- Name: storage_exists_at(character varying, character varying, bigint); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.storage_exists_at(state_key character varying, storage_key character varying, height bigint) RETURNS boolean
+    LANGUAGE sql STABLE
+    AS $$
+SELECT exists(SELECT 1
+              FROM eth.storage_cids
+                       INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+              WHERE state_cids.state_leaf_key = state_key
+                AND storage_cids.storage_leaf_key = storage_key
+                AND storage_cids.node_type = 2
+                AND storage_cids.block_number <= height
+                AND header_cids.canonical
+                AND NOT exists(SELECT 1
+                               FROM eth.storage_removals
+                                        INNER JOIN eth.header_cids removal_header ON (storage_removals.header_id = removal_header.id AND storage_removals.block_number = removal_header.block_number)
+                               WHERE storage_removals.state_leaf_key = state_key
+                                 AND storage_removals.storage_leaf_key = storage_key
+                                 AND storage_removals.cleared
+                                 AND storage_removals.block_number > storage_cids.block_number
+                                 AND storage_removals.block_number <= height
+                                 AND removal_header.canonical)
+                AND NOT exists(SELECT 1
+                               FROM eth.state_removals
+                                        INNER JOIN eth.header_cids removal_header ON (state_removals.header_id = removal_header.id AND state_removals.block_number = removal_header.block_number)
+                               WHERE state_removals.state_leaf_key = state_key
+                                 AND state_removals.destroyed
+                                 AND state_removals.block_number > storage_cids.block_number
+                                 AND state_removals.block_number <= height
+                                 AND removal_header.canonical)
+              LIMIT 1);
+$$;
+
+
+--
+-- Name: was_state_removed(bytea, bigint, character varying); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.was_state_removed(path bytea, height bigint, hash character varying) RETURNS boolean
+    LANGUAGE sql
+    AS $$
+SELECT exists(SELECT 1
+              FROM eth.state_removals
+              WHERE state_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+              LIMIT 1);
+$$;
+
+
+--
+-- Name: was_storage_removed(bytea, bigint, character varying); Type: FUNCTION; Schema: public; Owner: -
+--
+
+CREATE FUNCTION public.was_storage_removed(path bytea, height bigint, hash character varying) RETURNS boolean
+    LANGUAGE sql
+    AS $$
+SELECT exists(SELECT 1
+              FROM eth.storage_removals
+              WHERE storage_path = path
+                AND block_number > height
+                AND block_number <= (SELECT block_number
+                                     FROM eth.header_cids
+                                     WHERE block_hash = hash)
+              LIMIT 1);
+$$;
+
+
+--
+-- Name: header_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.header_cids_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: header_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.header_cids_id_seq OWNED BY eth.header_cids.id;
+
+
+--
+-- Name: hourly_block_stats; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.hourly_block_stats (
+    period_start numeric NOT NULL,
+    block_count bigint NOT NULL,
+    tx_count bigint NOT NULL,
+    gas_used numeric NOT NULL,
+    gas_limit numeric NOT NULL,
+    total_fees numeric NOT NULL,
+    reward numeric NOT NULL,
+    uncle_count bigint NOT NULL,
+    state_node_count bigint NOT NULL,
+    storage_node_count bigint NOT NULL,
+    fee_block_count bigint DEFAULT 0 NOT NULL
+);
+
+
+--
+-- Name: TABLE hourly_block_stats; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.hourly_block_stats IS '@name EthHourlyBlockStats';
+
+
+--
+-- Name: COLUMN hourly_block_stats.period_start; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.hourly_block_stats.period_start IS 'Unix timestamp of the start of the hour, in UTC';
+
+
+--
+-- Name: COLUMN hourly_block_stats.total_fees; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.hourly_block_stats.total_fees IS 'Fees of the blocks with known fees, see fee_block_count';
+
+
+--
+-- Name: COLUMN hourly_block_stats.fee_block_count; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.hourly_block_stats.fee_block_count IS 'Blocks whose fees are known and summed in total_fees, fewer than block_count while some have NULL fees';
+
+
+--
+-- Name: latest_accounts; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.latest_accounts (
+    state_leaf_key character varying(66) NOT NULL,
+    block_number bigint NOT NULL,
+    header_id integer NOT NULL,
+    state_id bigint NOT NULL,
+    state_path bytea,
+    balance numeric NOT NULL,
+    nonce integer NOT NULL,
+    code_hash bytea NOT NULL,
+    storage_root character varying(66) NOT NULL
+);
+
+
+--
+-- Name: TABLE latest_accounts; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.latest_accounts IS '@name EthLatestAccounts';
+
+
+--
+-- Name: COLUMN latest_accounts.block_number; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.latest_accounts.block_number IS 'Height of the canonical block that last wrote the account';
+
+
+--
+-- Name: latest_storage; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.latest_storage (
+    state_leaf_key character varying(66) NOT NULL,
+    storage_leaf_key character varying(66) NOT NULL,
+    block_number bigint NOT NULL,
+    header_id integer NOT NULL,
+    storage_path bytea,
+    cid text NOT NULL,
+    mh_key text NOT NULL
+);
+
+
+--
+-- Name: TABLE latest_storage; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.latest_storage IS '@name EthLatestStorage';
+
+
+--
+-- Name: COLUMN latest_storage.block_number; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.latest_storage.block_number IS 'Height of the canonical block that last wrote the storage slot';
+
+
+--
+-- Name: log_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.log_cids (
+    id integer NOT NULL,
+    receipt_id integer NOT NULL,
+    log_index integer NOT NULL,
+    address character varying(66) NOT NULL,
+    topic0 character varying(66),
+    topic1 character varying(66),
+    topic2 character varying(66),
+    topic3 character varying(66),
+    log_data bytea
+);
+
+
+--
+-- Name: TABLE log_cids; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.log_cids IS '@name EthLogCids';
+
+
+--
+-- Name: log_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.log_cids_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: log_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.log_cids_id_seq OWNED BY eth.log_cids.id;
+
+
+--
+-- Name: receipt_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.receipt_cids (
+    id integer NOT NULL,
+    tx_id integer NOT NULL,
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    contract character varying(66),
+    contract_hash character varying(66),
+    topic0s character varying(66)[],
+    topic1s character varying(66)[],
+    topic2s character varying(66)[],
+    topic3s character varying(66)[],
+    log_contracts character varying(66)[],
+    post_state character varying(66),
+    post_status integer,
+    gas_used bigint,
+    cumulative_gas_used bigint,
+    log_bloom bytea
+);
+
+
+--
+-- Name: receipt_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.receipt_cids_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: receipt_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.receipt_cids_id_seq OWNED BY eth.receipt_cids.id;
+
+
+--
+-- Name: state_accounts; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.state_accounts (
+    id integer NOT NULL,
+    state_id bigint NOT NULL,
+    balance numeric NOT NULL,
+    nonce integer NOT NULL,
+    code_hash bytea NOT NULL,
+    storage_root character varying(66) NOT NULL
+);
+
+
+--
+-- Name: state_accounts_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.state_accounts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: state_accounts_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.state_accounts_id_seq OWNED BY eth.state_accounts.id;
+
+
+--
+-- Name: state_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.state_cids (
+    id bigint NOT NULL,
+    header_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    state_leaf_key character varying(66),
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    state_path bytea,
+    node_type integer NOT NULL,
+    diff boolean DEFAULT false NOT NULL
+)
+PARTITION BY RANGE (block_number);
+
+
+--
+-- Name: state_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.state_cids_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: state_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.state_cids_id_seq OWNED BY eth.state_cids.id;
+
+
+--
+-- Name: state_removals; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.state_removals (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    state_path bytea NOT NULL,
+    state_leaf_key character varying(66),
+    destroyed boolean DEFAULT false NOT NULL
+);
+
+
+--
+-- Name: TABLE state_removals; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.state_removals IS '@name EthStateRemovals';
+
+
+--
+-- Name: COLUMN state_removals.destroyed; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.state_removals.destroyed IS 'True if the removed node was the leaf of an account that is not written anywhere else in the same block';
+
+
+--
+-- Name: state_removals_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.state_removals_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: state_removals_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.state_removals_id_seq OWNED BY eth.state_removals.id;
+
+
+--
+-- Name: storage_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.storage_cids (
+    id bigint NOT NULL,
+    state_id bigint NOT NULL,
+    block_number bigint NOT NULL,
+    storage_leaf_key character varying(66),
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    storage_path bytea,
+    node_type integer NOT NULL,
+    diff boolean DEFAULT false NOT NULL,
+    value bytea
+)
+PARTITION BY RANGE (block_number);
+
+
+--
+-- Name: COLUMN storage_cids.value; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.storage_cids.value IS 'Value of the storage slot held by a leaf node, left padded to 32 bytes';
+
+
+--
+-- Name: storage_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.storage_cids_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: storage_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY eth.storage_cids.id;
+
+
+--
+-- Name: storage_removals; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.storage_removals (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    state_leaf_key character varying(66) NOT NULL,
+    storage_path bytea NOT NULL,
+    storage_leaf_key character varying(66),
+    cleared boolean DEFAULT false NOT NULL
+);
+
+
+--
+-- Name: TABLE storage_removals; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.storage_removals IS '@name EthStorageRemovals';
+
+
+--
+-- Name: COLUMN storage_removals.cleared; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.storage_removals.cleared IS 'True if the removed node was the leaf of a storage slot that is not written anywhere else in the same block';
+
+
+--
+-- Name: storage_removals_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.storage_removals_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: storage_removals_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.storage_removals_id_seq OWNED BY eth.storage_removals.id;
+
+
+--
+-- Name: token_transfers; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.token_transfers (
+    id integer NOT NULL,
+    log_id integer NOT NULL,
+    batch_index integer NOT NULL,
+    tx_id integer NOT NULL,
+    block_number bigint NOT NULL,
+    log_index integer NOT NULL,
+    token character varying(66) NOT NULL,
+    standard integer NOT NULL,
+    operator character varying(66) NOT NULL,
+    src character varying(66) NOT NULL,
+    dst character varying(66) NOT NULL,
+    token_id numeric,
+    amount numeric NOT NULL
+);
+
+
+--
+-- Name: TABLE token_transfers; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.token_transfers IS '@name EthTokenTransfers';
+
+
+--
+-- Name: COLUMN token_transfers.batch_index; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.token_transfers.batch_index IS 'Position of the transfer within an ERC-1155 TransferBatch event, 0 for every other event';
+
+
+--
+-- Name: COLUMN token_transfers.standard; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.token_transfers.standard IS '20 = ERC-20, 721 = ERC-721, 1155 = ERC-1155';
+
+
+--
+-- Name: COLUMN token_transfers.operator; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.token_transfers.operator IS 'Operator of an ERC-1155 transfer, empty for ERC-20 and ERC-721 transfers';
+
+
+--
+-- Name: COLUMN token_transfers.token_id; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.token_transfers.token_id IS 'Token id of an ERC-721 or ERC-1155 transfer, NULL for ERC-20 transfers';
+
+
+--
+-- Name: COLUMN token_transfers.amount; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.token_transfers.amount IS 'Amount transferred, 1 for ERC-721 transfers';
+
+
+--
+-- Name: token_transfers_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.token_transfers_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: token_transfers_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.token_transfers_id_seq OWNED BY eth.token_transfers.id;
+
+
+--
+-- Name: transaction_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.transaction_cids (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
+    tx_hash character varying(66) NOT NULL,
+    index integer NOT NULL,
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    dst character varying(66) NOT NULL,
+    src character varying(66) NOT NULL,
+    tx_data bytea,
+    gas bigint,
+    gas_price numeric,
+    value numeric,
+    nonce bigint,
+    tx_type integer,
+    max_fee_per_gas numeric,
+    max_priority_fee_per_gas numeric,
+    effective_gas_price numeric,
+    priority_fee numeric
+);
+
+
+--
+-- Name: TABLE transaction_cids; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON TABLE eth.transaction_cids IS '@name EthTransactionCids';
+
+
+--
+-- Name: COLUMN transaction_cids.priority_fee; Type: COMMENT; Schema: eth; Owner: -
+--
+
+COMMENT ON COLUMN eth.transaction_cids.priority_fee IS 'Total fee paid to the miner, the effective gas price less the base fee multiplied by the gas used';
+
+
+--
+-- Name: transaction_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.transaction_cids_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: transaction_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.transaction_cids_id_seq OWNED BY eth.transaction_cids.id;
+
+
+--
+-- Name: uncle_cids; Type: TABLE; Schema: eth; Owner: -
+--
+
+CREATE TABLE eth.uncle_cids (
+    id integer NOT NULL,
+    header_id integer NOT NULL,
+    block_hash character varying(66) NOT NULL,
+    parent_hash character varying(66) NOT NULL,
+    cid text NOT NULL,
+    mh_key text NOT NULL,
+    reward numeric NOT NULL,
+    coinbase character varying(66),
+    difficulty numeric,
+    gas_limit bigint,
+    gas_used bigint,
+    extra_data bytea,
+    mix_digest character varying(66),
+    nonce numeric
+);
+
+
+--
+-- Name: uncle_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+--
+
+CREATE SEQUENCE eth.uncle_cids_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: uncle_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+--
+
+ALTER SEQUENCE eth.uncle_cids_id_seq OWNED BY eth.uncle_cids.id;
+
+
+--
+-- Name: blocks; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.blocks (
+    key text NOT NULL,
+    data bytea NOT NULL
+);
+
+
+--
+-- Name: goose_db_version; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.goose_db_version (
+    id integer NOT NULL,
+    version_id bigint NOT NULL,
+    is_applied boolean NOT NULL,
+    tstamp timestamp without time zone DEFAULT now()
+);
+
+
+--
+-- Name: goose_db_version_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.goose_db_version_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: goose_db_version_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.goose_db_version_id_seq OWNED BY public.goose_db_version.id;
+
+
+--
+-- Name: nodes; Type: TABLE; Schema: public; Owner: -
+--
+
+CREATE TABLE public.nodes (
+    id integer NOT NULL,
+    client_name character varying,
+    genesis_block character varying(66),
+    network_id character varying,
+    node_id character varying(128),
+    chain_id integer DEFAULT 1
+);
+
+
+--
+-- Name: TABLE nodes; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON TABLE public.nodes IS '@name NodeInfo';
+
+
+--
+-- Name: COLUMN nodes.node_id; Type: COMMENT; Schema: public; Owner: -
+--
+
+COMMENT ON COLUMN public.nodes.node_id IS '@name ChainNodeID';
+
+
+--
+-- Name: nodes_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+--
+
+CREATE SEQUENCE public.nodes_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+--
+-- Name: nodes_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+--
+
+ALTER SEQUENCE public.nodes_id_seq OWNED BY public.nodes.id;
+
+
+--
+-- Name: access_list_elements id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.access_list_elements ALTER COLUMN id SET DEFAULT nextval('eth.access_list_elements_id_seq'::regclass);
+
+
+--
+-- Name: account_changes id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.account_changes ALTER COLUMN id SET DEFAULT nextval('eth.account_changes_id_seq'::regclass);
+
+
+--
+-- Name: address_transactions id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.address_transactions ALTER COLUMN id SET DEFAULT nextval('eth.address_transactions_id_seq'::regclass);
+
+
+--
+-- Name: block_stats id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.block_stats ALTER COLUMN id SET DEFAULT nextval('eth.block_stats_id_seq'::regclass);
+
+
+--
+-- Name: contracts id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.contracts ALTER COLUMN id SET DEFAULT nextval('eth.contracts_id_seq'::regclass);
+
+
+--
+-- Name: decoded_events id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.decoded_events ALTER COLUMN id SET DEFAULT nextval('eth.decoded_events_id_seq'::regclass);
+
+
+--
+-- Name: decoded_storage id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.decoded_storage ALTER COLUMN id SET DEFAULT nextval('eth.decoded_storage_id_seq'::regclass);
+
+
+--
+-- Name: header_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.header_cids ALTER COLUMN id SET DEFAULT nextval('eth.header_cids_id_seq'::regclass);
+
+
+--
+-- Name: log_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.log_cids ALTER COLUMN id SET DEFAULT nextval('eth.log_cids_id_seq'::regclass);
+
+
+--
+-- Name: receipt_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.receipt_cids ALTER COLUMN id SET DEFAULT nextval('eth.receipt_cids_id_seq'::regclass);
+
+
+--
+-- Name: state_accounts id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.state_accounts ALTER COLUMN id SET DEFAULT nextval('eth.state_accounts_id_seq'::regclass);
+
+
+--
+-- Name: state_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.state_cids ALTER COLUMN id SET DEFAULT nextval('eth.state_cids_id_seq'::regclass);
+
+
+--
+-- Name: state_removals id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.state_removals ALTER COLUMN id SET DEFAULT nextval('eth.state_removals_id_seq'::regclass);
+
+
+--
+-- Name: storage_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.storage_cids ALTER COLUMN id SET DEFAULT nextval('eth.storage_cids_id_seq'::regclass);
+
+
+--
+-- Name: storage_removals id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.storage_removals ALTER COLUMN id SET DEFAULT nextval('eth.storage_removals_id_seq'::regclass);
+
+
+--
+-- Name: token_transfers id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.token_transfers ALTER COLUMN id SET DEFAULT nextval('eth.token_transfers_id_seq'::regclass);
+
+
+--
+-- Name: transaction_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.transaction_cids ALTER COLUMN id SET DEFAULT nextval('eth.transaction_cids_id_seq'::regclass);
+
+
+--
+-- Name: uncle_cids id; Type: DEFAULT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.uncle_cids ALTER COLUMN id SET DEFAULT nextval('eth.uncle_cids_id_seq'::regclass);
+
+
+--
+-- Name: goose_db_version id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.goose_db_version ALTER COLUMN id SET DEFAULT nextval('public.goose_db_version_id_seq'::regclass);
+
+
+--
+-- Name: nodes id; Type: DEFAULT; Schema: public; Owner: -
+--
+
+ALTER TABLE ONLY public.nodes ALTER COLUMN id SET DEFAULT nextval('public.nodes_id_seq'::regclass);
+
+
+--
+-- Name: access_list_elements access_list_elements_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.access_list_elements
+    ADD CONSTRAINT access_list_elements_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: access_list_elements access_list_elements_tx_id_index_key; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.access_list_elements
+    ADD CONSTRAINT access_list_elements_tx_id_index_key UNIQUE (tx_id, index);
+
+
+--
+-- Name: account_changes account_changes_header_id_state_leaf_key_key; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.account_changes
+    ADD CONSTRAINT account_changes_header_id_state_leaf_key_key UNIQUE (header_id, state_leaf_key);
+
+
+--
+-- Name: account_changes account_changes_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.account_changes
+    ADD CONSTRAINT account_changes_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: address_transactions address_transactions_address_tx_id_role_key; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.address_transactions
+    ADD CONSTRAINT address_transactions_address_tx_id_role_key UNIQUE (address, tx_id, role);
+
+
+--
+-- Name: address_transactions address_transactions_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.address_transactions
+    ADD CONSTRAINT address_transactions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: block_stats block_stats_header_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.block_stats
+    ADD CONSTRAINT block_stats_header_id_key UNIQUE (header_id);
+
+
+--
+-- Name: block_stats block_stats_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.block_stats
+    ADD CONSTRAINT block_stats_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: contracts contracts_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION public.has_child(hash character varying, height bigint) RETURNS public.child_result
-    LANGUAGE plpgsql
-    AS $$
-DECLARE
-child_height INT;
-  temp_child eth.header_cids;
-  new_child_result child_result;
-BEGIN
-  child_height = height + 1;
-  -- short circuit if there are no children
-SELECT exists(SELECT 1
-              FROM eth.header_cids
-              WHERE parent_hash = hash
-                AND block_number = child_height
-              LIMIT 1)
-INTO new_child_result.has_child;
--- collect all the children for this header
-IF new_child_result.has_child THEN
-    FOR temp_child IN
-SELECT * FROM eth.header_cids WHERE parent_hash = hash AND block_number = child_height
-    LOOP
-      new_child_result.children = array_append(new_child_result.children, temp_child);
-END LOOP;
-END IF;
-RETURN new_child_result;
-END
-$$;
+ALTER TABLE ONLY eth.contracts
+    ADD CONSTRAINT contracts_pkey PRIMARY KEY (id);
 
 
 --
--- Name: was_state_removed(bytea, bigint, character varying); Type: FUNCTION; Schema: public; Owner: -
+-- Name: contracts contracts_tx_id_address_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION public.was_state_removed(path bytea, height bigint, hash character varying) RETURNS boolean
-    LANGUAGE sql
-    AS $$
-SELECT exists(SELECT 1
-              FROM eth.state_cids
-                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
-              WHERE state_path = path
-                AND block_number > height
-                AND block_number <= (SELECT block_number
-                                     FROM eth.header_cids
-                                     WHERE block_hash = hash)
-                AND state_cids.node_type = 3
-              LIMIT 1);
-$$;
+ALTER TABLE ONLY eth.contracts
+    ADD CONSTRAINT contracts_tx_id_address_key UNIQUE (tx_id, address);
 
 
 --
--- Name: was_storage_removed(bytea, bigint, character varying); Type: FUNCTION; Schema: public; Owner: -
+-- Name: daily_block_stats daily_block_stats_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE FUNCTION public.was_storage_removed(path bytea, height bigint, hash character varying) RETURNS boolean
-    LANGUAGE sql
-    AS $$
-SELECT exists(SELECT 1
-              FROM eth.storage_cids
-                       INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id)
-                       INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id)
-              WHERE storage_path = path
-                AND block_number > height
-                AND block_number <= (SELECT block_number
-                                     FROM eth.header_cids
-                                     WHERE block_hash = hash)
-                AND storage_cids.node_type = 3
-              LIMIT 1);
-$$;
+ALTER TABLE ONLY eth.daily_block_stats
+    ADD CONSTRAINT daily_block_stats_pkey PRIMARY KEY (period_start);
 
 
 --
--- Name: header_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: decoded_events decoded_events_log_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.header_cids_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.decoded_events
+    ADD CONSTRAINT decoded_events_log_id_key UNIQUE (log_id);
 
 
 --
--- Name: header_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: decoded_events decoded_events_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.header_cids_id_seq OWNED BY eth.header_cids.id;
+ALTER TABLE ONLY eth.decoded_events
+    ADD CONSTRAINT decoded_events_pkey PRIMARY KEY (id);
 
 
 --
--- Name: receipt_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: decoded_storage decoded_storage_header_id_state_leaf_key_storage_leaf_key_v_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.receipt_cids (
-    id integer NOT NULL,
-    tx_id integer NOT NULL,
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    contract character varying(66),
-    contract_hash character varying(66),
-    topic0s character varying(66)[],
-    topic1s character varying(66)[],
-    topic2s character varying(66)[],
-    topic3s character varying(66)[],
-    log_contracts character varying(66)[],
-    post_state character varying(66),
-    post_status integer
-);
+ALTER TABLE ONLY eth.decoded_storage
+    ADD CONSTRAINT decoded_storage_header_id_state_leaf_key_storage_leaf_key_v_key UNIQUE (header_id, state_leaf_key, storage_leaf_key, variable);
 
 
 --
--- Name: receipt_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: decoded_storage decoded_storage_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.receipt_cids_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.decoded_storage
+    ADD CONSTRAINT decoded_storage_pkey PRIMARY KEY (id);
 
 
 --
--- Name: receipt_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: header_cids header_cids_block_number_block_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.receipt_cids_id_seq OWNED BY eth.receipt_cids.id;
+ALTER TABLE eth.header_cids
+    ADD CONSTRAINT header_cids_block_number_block_hash_key UNIQUE (block_number, block_hash);
 
 
 --
--- Name: state_accounts; Type: TABLE; Schema: eth; Owner: -
+-- Name: header_cids header_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.state_accounts (
-    id integer NOT NULL,
-    state_id bigint NOT NULL,
-    balance numeric NOT NULL,
-    nonce integer NOT NULL,
-    code_hash bytea NOT NULL,
-    storage_root character varying(66) NOT NULL
-);
+ALTER TABLE eth.header_cids
+    ADD CONSTRAINT header_cids_pkey PRIMARY KEY (id, block_number);
 
 
 --
--- Name: state_accounts_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: hourly_block_stats hourly_block_stats_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.state_accounts_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.hourly_block_stats
+    ADD CONSTRAINT hourly_block_stats_pkey PRIMARY KEY (period_start);
 
 
 --
--- Name: state_accounts_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: latest_accounts latest_accounts_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.state_accounts_id_seq OWNED BY eth.state_accounts.id;
+ALTER TABLE ONLY eth.latest_accounts
+    ADD CONSTRAINT latest_accounts_pkey PRIMARY KEY (state_leaf_key);
 
 
 --
--- Name: state_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: latest_storage latest_storage_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.state_cids (
-    id bigint NOT NULL,
-    header_id integer NOT NULL,
-    state_leaf_key character varying(66),
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    state_path bytea,
-    node_type integer NOT NULL,
-    diff boolean DEFAULT false NOT NULL
-);
+ALTER TABLE ONLY eth.latest_storage
+    ADD CONSTRAINT latest_storage_pkey PRIMARY KEY (state_leaf_key, storage_leaf_key);
 
 
 --
--- Name: state_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: log_cids log_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.state_cids_id_seq
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.log_cids
+    ADD CONSTRAINT log_cids_pkey PRIMARY KEY (id);
 
 
 --
--- Name: state_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: log_cids log_cids_receipt_id_log_index_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.state_cids_id_seq OWNED BY eth.state_cids.id;
+ALTER TABLE ONLY eth.log_cids
+    ADD CONSTRAINT log_cids_receipt_id_log_index_key UNIQUE (receipt_id, log_index);
 
 
 --
--- Name: storage_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: receipt_cids receipt_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.storage_cids (
-    id bigint NOT NULL,
-    state_id bigint NOT NULL,
-    storage_leaf_key character varying(66),
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    storage_path bytea,
-    node_type integer NOT NULL,
-    diff boolean DEFAULT false NOT NULL
-);
+ALTER TABLE ONLY eth.receipt_cids
+    ADD CONSTRAINT receipt_cids_pkey PRIMARY KEY (id);
 
 
 --
--- Name: storage_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: receipt_cids receipt_cids_tx_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.storage_cids_id_seq
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.receipt_cids
+    ADD CONSTRAINT receipt_cids_tx_id_key UNIQUE (tx_id);
 
 
 --
--- Name: storage_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: state_accounts state_accounts_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.storage_cids_id_seq OWNED BY eth.storage_cids.id;
+ALTER TABLE ONLY eth.state_accounts
+    ADD CONSTRAINT state_accounts_pkey PRIMARY KEY (id);
 
 
 --
--- Name: transaction_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: state_accounts state_accounts_state_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.transaction_cids (
-    id integer NOT NULL,
-    header_id integer NOT NULL,
-    tx_hash character varying(66) NOT NULL,
-    index integer NOT NULL,
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    dst character varying(66) NOT NULL,
-    src character varying(66) NOT NULL,
-    tx_data bytea
-);
+ALTER TABLE ONLY eth.state_accounts
+    ADD CONSTRAINT state_accounts_state_id_key UNIQUE (state_id);
 
 
 --
--- Name: TABLE transaction_cids; Type: COMMENT; Schema: eth; Owner: -
+-- Name: state_cids state_cids_header_id_state_path_block_number_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-COMMENT ON TABLE eth.transaction_cids IS '@name EthTransactionCids';
+ALTER TABLE eth.state_cids
+    ADD CONSTRAINT state_cids_header_id_state_path_block_number_key UNIQUE (header_id, state_path, block_number);
 
 
 --
--- Name: transaction_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: state_cids state_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.transaction_cids_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE eth.state_cids
+    ADD CONSTRAINT state_cids_pkey PRIMARY KEY (id, block_number);
 
 
 --
--- Name: transaction_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: state_removals state_removals_header_id_state_path_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.transaction_cids_id_seq OWNED BY eth.transaction_cids.id;
+ALTER TABLE ONLY eth.state_removals
+    ADD CONSTRAINT state_removals_header_id_state_path_key UNIQUE (header_id, state_path);
 
 
 --
--- Name: uncle_cids; Type: TABLE; Schema: eth; Owner: -
+-- Name: state_removals state_removals_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE eth.uncle_cids (
-    id integer NOT NULL,
-    header_id integer NOT NULL,
-    block_hash character varying(66) NOT NULL,
-    parent_hash character varying(66) NOT NULL,
-    cid text NOT NULL,
-    mh_key text NOT NULL,
-    reward numeric NOT NULL
-);
+ALTER TABLE ONLY eth.state_removals
+    ADD CONSTRAINT state_removals_pkey PRIMARY KEY (id);
 
 
 --
--- Name: uncle_cids_id_seq; Type: SEQUENCE; Schema: eth; Owner: -
+-- Name: storage_cids storage_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE eth.uncle_cids_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE eth.storage_cids
+    ADD CONSTRAINT storage_cids_pkey PRIMARY KEY (id, block_number);
 
 
 --
--- Name: uncle_cids_id_seq; Type: SEQUENCE OWNED BY; Schema: eth; Owner: -
+-- Name: storage_cids storage_cids_state_id_storage_path_block_number_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE eth.uncle_cids_id_seq OWNED BY eth.uncle_cids.id;
+ALTER TABLE eth.storage_cids
+    ADD CONSTRAINT storage_cids_state_id_storage_path_block_number_key UNIQUE (state_id, storage_path, block_number);
 
 
 --
--- Name: blocks; Type: TABLE; Schema: public; Owner: -
+-- Name: storage_removals storage_removals_header_id_state_leaf_key_storage_path_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE public.blocks (
-    key text NOT NULL,
-    data bytea NOT NULL
-);
+ALTER TABLE ONLY eth.storage_removals
+    ADD CONSTRAINT storage_removals_header_id_state_leaf_key_storage_path_key UNIQUE (header_id, state_leaf_key, storage_path);
 
 
 --
--- Name: goose_db_version; Type: TABLE; Schema: public; Owner: -
+-- Name: storage_removals storage_removals_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE public.goose_db_version (
-    id integer NOT NULL,
-    version_id bigint NOT NULL,
-    is_applied boolean NOT NULL,
-    tstamp timestamp without time zone DEFAULT now()
-);
+ALTER TABLE ONLY eth.storage_removals
+    ADD CONSTRAINT storage_removals_pkey PRIMARY KEY (id);
 
 
 --
--- Name: goose_db_version_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+-- Name: token_transfers token_transfers_log_id_batch_index_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE SEQUENCE public.goose_db_version_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY eth.token_transfers
+    ADD CONSTRAINT token_transfers_log_id_batch_index_key UNIQUE (log_id, batch_index);
 
 
 --
--- Name: goose_db_version_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+-- Name: token_transfers token_transfers_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER SEQUENCE public.goose_db_version_id_seq OWNED BY public.goose_db_version.id;
+ALTER TABLE ONLY eth.token_transfers
+    ADD CONSTRAINT token_transfers_pkey PRIMARY KEY (id);
 
 
 --
--- Name: nodes; Type: TABLE; Schema: public; Owner: -
+-- Name: transaction_cids transaction_cids_header_id_tx_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-CREATE TABLE public.nodes (
-    id integer NOT NULL,
-    client_name character varying,
-    genesis_block character varying(66),
-    network_id character varying,
-    node_id character varying(128),
-    chain_id integer DEFAULT 1
-);
+ALTER TABLE ONLY eth.transaction_cids
+    ADD CONSTRAINT transaction_cids_header_id_tx_hash_key UNIQUE (header_id, tx_hash);
+
+
+--
+-- Name: transaction_cids transaction_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.transaction_cids
+    ADD CONSTRAINT transaction_cids_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: uncle_cids uncle_cids_header_id_block_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.uncle_cids
+    ADD CONSTRAINT uncle_cids_header_id_block_hash_key UNIQUE (header_id, block_hash);
 
 
 --
--- Name: TABLE nodes; Type: COMMENT; Schema: public; Owner: -
+-- Name: uncle_cids uncle_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
 --
 
-COMMENT ON TABLE public.nodes IS '@name NodeInfo';
+ALTER TABLE ONLY eth.uncle_cids
+    ADD CONSTRAINT uncle_cids_pkey PRIMARY KEY (id);
 
 
 --
--- Name: COLUMN nodes.node_id; Type: COMMENT; Schema: public; Owner: -
+-- Name: blocks blocks_key_key; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
-COMMENT ON COLUMN public.nodes.node_id IS '@name ChainNodeID';
+ALTER TABLE ONLY public.blocks
+    ADD CONSTRAINT blocks_key_key UNIQUE (key);
 
 
 --
--- Name: nodes_id_seq; Type: SEQUENCE; Schema: public; Owner: -
+-- Name: goose_db_version goose_db_version_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
-CREATE SEQUENCE public.nodes_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
+ALTER TABLE ONLY public.goose_db_version
+    ADD CONSTRAINT goose_db_version_pkey PRIMARY KEY (id);
 
 
 --
--- Name: nodes_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: -
+-- Name: nodes node_uc; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER SEQUENCE public.nodes_id_seq OWNED BY public.nodes.id;
+ALTER TABLE ONLY public.nodes
+    ADD CONSTRAINT node_uc UNIQUE (genesis_block, network_id, node_id, chain_id);
 
 
 --
--- Name: header_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: nodes nodes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
 --
 
-ALTER TABLE ONLY eth.header_cids ALTER COLUMN id SET DEFAULT nextval('eth.header_cids_id_seq'::regclass);
+ALTER TABLE ONLY public.nodes
+    ADD CONSTRAINT nodes_pkey PRIMARY KEY (id);
 
 
 --
--- Name: receipt_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: access_list_element_address_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.receipt_cids ALTER COLUMN id SET DEFAULT nextval('eth.receipt_cids_id_seq'::regclass);
+CREATE INDEX access_list_element_address_index ON eth.access_list_elements USING btree (address);
 
 
 --
--- Name: state_accounts id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: access_list_element_tx_id_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_accounts ALTER COLUMN id SET DEFAULT nextval('eth.state_accounts_id_seq'::regclass);
+CREATE INDEX access_list_element_tx_id_index ON eth.access_list_elements USING btree (tx_id);
 
 
 --
--- Name: state_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: access_list_storage_keys_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_cids ALTER COLUMN id SET DEFAULT nextval('eth.state_cids_id_seq'::regclass);
+CREATE INDEX access_list_storage_keys_index ON eth.access_list_elements USING gin (storage_keys);
 
 
 --
--- Name: storage_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: account_change_state_leaf_key_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.storage_cids ALTER COLUMN id SET DEFAULT nextval('eth.storage_cids_id_seq'::regclass);
+CREATE INDEX account_change_state_leaf_key_index ON eth.account_changes USING btree (state_leaf_key, block_number);
 
 
 --
--- Name: transaction_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: account_code_hash_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.transaction_cids ALTER COLUMN id SET DEFAULT nextval('eth.transaction_cids_id_seq'::regclass);
+CREATE INDEX account_code_hash_index ON eth.state_accounts USING btree (code_hash);
 
 
 --
--- Name: uncle_cids id; Type: DEFAULT; Schema: eth; Owner: -
+-- Name: account_state_id_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.uncle_cids ALTER COLUMN id SET DEFAULT nextval('eth.uncle_cids_id_seq'::regclass);
+CREATE INDEX account_state_id_index ON eth.state_accounts USING btree (state_id);
 
 
 --
--- Name: goose_db_version id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: address_transaction_tx_id_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.goose_db_version ALTER COLUMN id SET DEFAULT nextval('public.goose_db_version_id_seq'::regclass);
+CREATE INDEX address_transaction_tx_id_index ON eth.address_transactions USING btree (tx_id);
 
 
 --
--- Name: nodes id; Type: DEFAULT; Schema: public; Owner: -
+-- Name: block_hash_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.nodes ALTER COLUMN id SET DEFAULT nextval('public.nodes_id_seq'::regclass);
+CREATE INDEX block_hash_index ON eth.header_cids USING btree (block_hash);
 
 
 --
--- Name: header_cids header_cids_block_number_block_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: block_number_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.header_cids
-    ADD CONSTRAINT header_cids_block_number_block_hash_key UNIQUE (block_number, block_hash);
+CREATE INDEX block_number_index ON eth.header_cids USING brin (block_number);
 
 
 --
--- Name: header_cids header_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: block_stats_block_number_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.header_cids
-    ADD CONSTRAINT header_cids_pkey PRIMARY KEY (id);
+CREATE INDEX block_stats_block_number_index ON eth.block_stats USING btree (block_number);
 
 
 --
--- Name: receipt_cids receipt_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: block_stats_timestamp_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.receipt_cids
-    ADD CONSTRAINT receipt_cids_pkey PRIMARY KEY (id);
+CREATE INDEX block_stats_timestamp_index ON eth.block_stats USING btree ("timestamp");
 
 
 --
--- Name: receipt_cids receipt_cids_tx_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: canonical_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.receipt_cids
-    ADD CONSTRAINT receipt_cids_tx_id_key UNIQUE (tx_id);
+CREATE INDEX canonical_index ON eth.header_cids USING btree (block_number) WHERE canonical;
 
 
 --
--- Name: state_accounts state_accounts_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: coinbase_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_accounts
-    ADD CONSTRAINT state_accounts_pkey PRIMARY KEY (id);
+CREATE INDEX coinbase_index ON eth.header_cids USING btree (coinbase);
 
 
 --
--- Name: state_accounts state_accounts_state_id_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: contract_address_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_accounts
-    ADD CONSTRAINT state_accounts_state_id_key UNIQUE (state_id);
+CREATE INDEX contract_address_index ON eth.contracts USING btree (address);
 
 
 --
--- Name: state_cids state_cids_header_id_state_path_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: contract_block_number_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_cids
-    ADD CONSTRAINT state_cids_header_id_state_path_key UNIQUE (header_id, state_path);
+CREATE INDEX contract_block_number_index ON eth.contracts USING brin (block_number);
 
 
 --
--- Name: state_cids state_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: contract_code_hash_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_cids
-    ADD CONSTRAINT state_cids_pkey PRIMARY KEY (id);
+CREATE INDEX contract_code_hash_index ON eth.contracts USING btree (code_hash);
 
 
 --
--- Name: storage_cids storage_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: contract_creator_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.storage_cids
-    ADD CONSTRAINT storage_cids_pkey PRIMARY KEY (id);
+CREATE INDEX contract_creator_index ON eth.contracts USING btree (creator);
 
 
 --
--- Name: storage_cids storage_cids_state_id_storage_path_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: decoded_event_address_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.storage_cids
-    ADD CONSTRAINT storage_cids_state_id_storage_path_key UNIQUE (state_id, storage_path);
+CREATE INDEX decoded_event_address_index ON eth.decoded_events USING btree (address, event_name);
 
 
 --
--- Name: transaction_cids transaction_cids_header_id_tx_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: decoded_event_args_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.transaction_cids
-    ADD CONSTRAINT transaction_cids_header_id_tx_hash_key UNIQUE (header_id, tx_hash);
+CREATE INDEX decoded_event_args_index ON eth.decoded_events USING gin (args);
 
 
 --
--- Name: transaction_cids transaction_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: decoded_storage_contract_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.transaction_cids
-    ADD CONSTRAINT transaction_cids_pkey PRIMARY KEY (id);
+CREATE INDEX decoded_storage_contract_index ON eth.decoded_storage USING btree (contract, variable, block_number);
 
 
 --
--- Name: uncle_cids uncle_cids_header_id_block_hash_key; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: header_cid_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.uncle_cids
-    ADD CONSTRAINT uncle_cids_header_id_block_hash_key UNIQUE (header_id, block_hash);
+CREATE INDEX header_cid_index ON eth.header_cids USING btree (cid);
 
 
 --
--- Name: uncle_cids uncle_cids_pkey; Type: CONSTRAINT; Schema: eth; Owner: -
+-- Name: header_mh_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.uncle_cids
-    ADD CONSTRAINT uncle_cids_pkey PRIMARY KEY (id);
+CREATE INDEX header_mh_index ON eth.header_cids USING btree (mh_key);
 
 
 --
--- Name: blocks blocks_key_key; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: latest_account_block_number_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.blocks
-    ADD CONSTRAINT blocks_key_key UNIQUE (key);
+CREATE INDEX latest_account_block_number_index ON eth.latest_accounts USING brin (block_number);
 
 
 --
--- Name: goose_db_version goose_db_version_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: latest_storage_block_number_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.goose_db_version
-    ADD CONSTRAINT goose_db_version_pkey PRIMARY KEY (id);
+CREATE INDEX latest_storage_block_number_index ON eth.latest_storage USING brin (block_number);
 
 
 --
--- Name: nodes node_uc; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: log_address_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.nodes
-    ADD CONSTRAINT node_uc UNIQUE (genesis_block, network_id, node_id, chain_id);
+CREATE INDEX log_address_index ON eth.log_cids USING btree (address);
 
 
 --
--- Name: nodes nodes_pkey; Type: CONSTRAINT; Schema: public; Owner: -
+-- Name: log_rct_id_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY public.nodes
-    ADD CONSTRAINT nodes_pkey PRIMARY KEY (id);
+CREATE INDEX log_rct_id_index ON eth.log_cids USING btree (receipt_id);
 
 
 --
--- Name: account_state_id_index; Type: INDEX; Schema: eth; Owner: -
+-- Name: log_topic0_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX account_state_id_index ON eth.state_accounts USING btree (state_id);
+CREATE INDEX log_topic0_index ON eth.log_cids USING btree (topic0);
 
 
 --
--- Name: block_hash_index; Type: INDEX; Schema: eth; Owner: -
+-- Name: log_topic1_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX block_hash_index ON eth.header_cids USING btree (block_hash);
+CREATE INDEX log_topic1_index ON eth.log_cids USING btree (topic1);
 
 
 --
--- Name: block_number_index; Type: INDEX; Schema: eth; Owner: -
+-- Name: log_topic2_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX block_number_index ON eth.header_cids USING brin (block_number);
+CREATE INDEX log_topic2_index ON eth.log_cids USING btree (topic2);
 
 
 --
--- Name: header_cid_index; Type: INDEX; Schema: eth; Owner: -
+-- Name: log_topic3_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX header_cid_index ON eth.header_cids USING btree (cid);
+CREATE INDEX log_topic3_index ON eth.log_cids USING btree (topic3);
 
 
 --
--- Name: header_mh_index; Type: INDEX; Schema: eth; Owner: -
+-- Name: producer_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX header_mh_index ON eth.header_cids USING btree (mh_key);
+CREATE INDEX producer_index ON eth.header_cids USING btree (producer);
 
 
 --
@@ -944,6 +2816,20 @@ CREATE INDEX state_mh_index ON eth.state_cids USING btree (mh_key);
 CREATE INDEX state_path_index ON eth.state_cids USING btree (state_path);
 
 
+--
+-- Name: state_removal_destroyed_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX state_removal_destroyed_index ON eth.state_removals USING btree (state_leaf_key, block_number) WHERE destroyed;
+
+
+--
+-- Name: state_removal_path_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX state_removal_path_index ON eth.state_removals USING btree (state_path, block_number);
+
+
 --
 -- Name: state_root_index; Type: INDEX; Schema: eth; Owner: -
 --
@@ -979,6 +2865,20 @@ CREATE INDEX storage_mh_index ON eth.storage_cids USING btree (mh_key);
 CREATE INDEX storage_path_index ON eth.storage_cids USING btree (storage_path);
 
 
+--
+-- Name: storage_removal_cleared_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX storage_removal_cleared_index ON eth.storage_removals USING btree (state_leaf_key, storage_leaf_key, block_number) WHERE cleared;
+
+
+--
+-- Name: storage_removal_path_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX storage_removal_path_index ON eth.storage_removals USING btree (storage_path, block_number);
+
+
 --
 -- Name: storage_root_index; Type: INDEX; Schema: eth; Owner: -
 --
@@ -997,7 +2897,35 @@ CREATE INDEX storage_state_id_index ON eth.storage_cids USING btree (state_id);
 -- Name: timestamp_index; Type: INDEX; Schema: eth; Owner: -
 --
 
-CREATE INDEX timestamp_index ON eth.header_cids USING brin ("timestamp");
+CREATE INDEX timestamp_index ON eth.header_cids USING btree ("timestamp", block_number);
+
+
+--
+-- Name: token_transfer_dst_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX token_transfer_dst_index ON eth.token_transfers USING btree (dst, block_number);
+
+
+--
+-- Name: token_transfer_src_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX token_transfer_src_index ON eth.token_transfers USING btree (src, block_number);
+
+
+--
+-- Name: token_transfer_token_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX token_transfer_token_index ON eth.token_transfers USING btree (token, block_number);
+
+
+--
+-- Name: token_transfer_tx_id_index; Type: INDEX; Schema: eth; Owner: -
+--
+
+CREATE INDEX token_transfer_tx_id_index ON eth.token_transfers USING btree (tx_id);
 
 
 --
@@ -1042,6 +2970,62 @@ CREATE INDEX tx_mh_index ON eth.transaction_cids USING btree (mh_key);
 CREATE INDEX tx_src_index ON eth.transaction_cids USING btree (src);
 
 
+--
+-- Name: access_list_elements access_list_elements_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER access_list_elements_ai AFTER INSERT ON eth.access_list_elements FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('access_list_elements', 'id');
+
+
+--
+-- Name: account_changes account_changes_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER account_changes_ai AFTER INSERT ON eth.account_changes FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('account_changes', 'id');
+
+
+--
+-- Name: address_transactions address_transactions_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER address_transactions_ai AFTER INSERT ON eth.address_transactions FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('address_transactions', 'id');
+
+
+--
+-- Name: block_stats block_stats_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER block_stats_ai AFTER INSERT ON eth.block_stats FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('block_stats', 'id');
+
+
+--
+-- Name: block_stats block_stats_rollup_queue; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER block_stats_rollup_queue AFTER INSERT OR DELETE OR UPDATE ON eth.block_stats FOR EACH ROW EXECUTE FUNCTION eth.queue_block_stats_rollup();
+
+
+--
+-- Name: contracts contracts_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER contracts_ai AFTER INSERT ON eth.contracts FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('contracts', 'id');
+
+
+--
+-- Name: decoded_events decoded_events_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER decoded_events_ai AFTER INSERT ON eth.decoded_events FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('decoded_events', 'id');
+
+
+--
+-- Name: decoded_storage decoded_storage_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER decoded_storage_ai AFTER INSERT ON eth.decoded_storage FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('decoded_storage', 'id');
+
+
 --
 -- Name: header_cids header_cids_ai; Type: TRIGGER; Schema: eth; Owner: -
 --
@@ -1049,6 +3033,20 @@ CREATE INDEX tx_src_index ON eth.transaction_cids USING btree (src);
 CREATE TRIGGER header_cids_ai AFTER INSERT ON eth.header_cids FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('header_cids', 'id');
 
 
+--
+-- Name: header_cids header_cids_canonical_block_stats; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER header_cids_canonical_block_stats AFTER UPDATE OF canonical ON eth.header_cids FOR EACH ROW WHEN ((old.canonical IS DISTINCT FROM new.canonical)) EXECUTE FUNCTION eth.header_cids_canonical_block_stats();
+
+
+--
+-- Name: log_cids log_cids_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER log_cids_ai AFTER INSERT ON eth.log_cids FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('log_cids', 'id');
+
+
 --
 -- Name: receipt_cids receipt_cids_ai; Type: TRIGGER; Schema: eth; Owner: -
 --
@@ -1070,6 +3068,13 @@ CREATE TRIGGER state_accounts_ai AFTER INSERT ON eth.state_accounts FOR EACH ROW
 CREATE TRIGGER state_cids_ai AFTER INSERT ON eth.state_cids FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('state_cids', 'id');
 
 
+--
+-- Name: state_removals state_removals_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER state_removals_ai AFTER INSERT ON eth.state_removals FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('state_removals', 'id');
+
+
 --
 -- Name: storage_cids storage_cids_ai; Type: TRIGGER; Schema: eth; Owner: -
 --
@@ -1077,6 +3082,20 @@ CREATE TRIGGER state_cids_ai AFTER INSERT ON eth.state_cids FOR EACH ROW EXECUTE
 CREATE TRIGGER storage_cids_ai AFTER INSERT ON eth.storage_cids FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('storage_cids', 'id');
 
 
+--
+-- Name: storage_removals storage_removals_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER storage_removals_ai AFTER INSERT ON eth.storage_removals FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('storage_removals', 'id');
+
+
+--
+-- Name: token_transfers token_transfers_ai; Type: TRIGGER; Schema: eth; Owner: -
+--
+
+CREATE TRIGGER token_transfers_ai AFTER INSERT ON eth.token_transfers FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('token_transfers', 'id');
+
+
 --
 -- Name: transaction_cids transaction_cids_ai; Type: TRIGGER; Schema: eth; Owner: -
 --
@@ -1091,11 +3110,43 @@ CREATE TRIGGER transaction_cids_ai AFTER INSERT ON eth.transaction_cids FOR EACH
 CREATE TRIGGER uncle_cids_ai AFTER INSERT ON eth.uncle_cids FOR EACH ROW EXECUTE FUNCTION eth.graphql_subscription('uncle_cids', 'id');
 
 
+--
+-- Name: access_list_elements access_list_elements_tx_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.access_list_elements
+    ADD CONSTRAINT access_list_elements_tx_id_fkey FOREIGN KEY (tx_id) REFERENCES eth.transaction_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+
+--
+-- Name: address_transactions address_transactions_tx_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.address_transactions
+    ADD CONSTRAINT address_transactions_tx_id_fkey FOREIGN KEY (tx_id) REFERENCES eth.transaction_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+
+--
+-- Name: contracts contracts_tx_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.contracts
+    ADD CONSTRAINT contracts_tx_id_fkey FOREIGN KEY (tx_id) REFERENCES eth.transaction_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+
+--
+-- Name: decoded_events decoded_events_log_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+--
+
+ALTER TABLE ONLY eth.decoded_events
+    ADD CONSTRAINT decoded_events_log_id_fkey FOREIGN KEY (log_id) REFERENCES eth.log_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+
+
 --
 -- Name: header_cids header_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.header_cids
+ALTER TABLE eth.header_cids
     ADD CONSTRAINT header_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
@@ -1103,47 +3154,47 @@ ALTER TABLE ONLY eth.header_cids
 -- Name: header_cids header_cids_node_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.header_cids
+ALTER TABLE eth.header_cids
     ADD CONSTRAINT header_cids_node_id_fkey FOREIGN KEY (node_id) REFERENCES public.nodes(id) ON DELETE CASCADE;
 
 
 --
--- Name: receipt_cids receipt_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: log_cids log_cids_receipt_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.receipt_cids
-    ADD CONSTRAINT receipt_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE ONLY eth.log_cids
+    ADD CONSTRAINT log_cids_receipt_id_fkey FOREIGN KEY (receipt_id) REFERENCES eth.receipt_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
--- Name: receipt_cids receipt_cids_tx_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: receipt_cids receipt_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
 ALTER TABLE ONLY eth.receipt_cids
-    ADD CONSTRAINT receipt_cids_tx_id_fkey FOREIGN KEY (tx_id) REFERENCES eth.transaction_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+    ADD CONSTRAINT receipt_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
--- Name: state_accounts state_accounts_state_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: receipt_cids receipt_cids_tx_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_accounts
-    ADD CONSTRAINT state_accounts_state_id_fkey FOREIGN KEY (state_id) REFERENCES eth.state_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE ONLY eth.receipt_cids
+    ADD CONSTRAINT receipt_cids_tx_id_fkey FOREIGN KEY (tx_id) REFERENCES eth.transaction_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
--- Name: state_cids state_cids_header_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: state_cids state_cids_header_id_block_number_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_cids
-    ADD CONSTRAINT state_cids_header_id_fkey FOREIGN KEY (header_id) REFERENCES eth.header_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE eth.state_cids
+    ADD CONSTRAINT state_cids_header_id_block_number_fkey FOREIGN KEY (header_id, block_number) REFERENCES eth.header_cids(id, block_number) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
 -- Name: state_cids state_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.state_cids
+ALTER TABLE eth.state_cids
     ADD CONSTRAINT state_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
@@ -1151,24 +3202,24 @@ ALTER TABLE ONLY eth.state_cids
 -- Name: storage_cids storage_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.storage_cids
+ALTER TABLE eth.storage_cids
     ADD CONSTRAINT storage_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
--- Name: storage_cids storage_cids_state_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: storage_cids storage_cids_state_id_block_number_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.storage_cids
-    ADD CONSTRAINT storage_cids_state_id_fkey FOREIGN KEY (state_id) REFERENCES eth.state_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE eth.storage_cids
+    ADD CONSTRAINT storage_cids_state_id_block_number_fkey FOREIGN KEY (state_id, block_number) REFERENCES eth.state_cids(id, block_number) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
--- Name: transaction_cids transaction_cids_header_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
+-- Name: token_transfers token_transfers_log_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
 
-ALTER TABLE ONLY eth.transaction_cids
-    ADD CONSTRAINT transaction_cids_header_id_fkey FOREIGN KEY (header_id) REFERENCES eth.header_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
+ALTER TABLE ONLY eth.token_transfers
+    ADD CONSTRAINT token_transfers_log_id_fkey FOREIGN KEY (log_id) REFERENCES eth.log_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
 --
@@ -1179,14 +3230,6 @@ ALTER TABLE ONLY eth.transaction_cids
     ADD CONSTRAINT transaction_cids_mh_key_fkey FOREIGN KEY (mh_key) REFERENCES public.blocks(key) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
 
 
---
--- Name: uncle_cids uncle_cids_header_id_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
---
-
-ALTER TABLE ONLY eth.uncle_cids
-    ADD CONSTRAINT uncle_cids_header_id_fkey FOREIGN KEY (header_id) REFERENCES eth.header_cids(id) ON DELETE CASCADE DEFERRABLE INITIALLY DEFERRED;
-
-
 --
 -- Name: uncle_cids uncle_cids_mh_key_fkey; Type: FK CONSTRAINT; Schema: eth; Owner: -
 --
@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+)
+
+// DefaultRollupInterval is how often the sync and backfill services refresh the hourly and daily block stats rollups
+const DefaultRollupInterval = time.Minute
+
+// BlockStatsRoller refreshes eth.hourly_block_stats and eth.daily_block_stats from eth.block_stats
+// The block txs only queue the periods whose stats changed, so that concurrent workers never contend for the same rollup rows,
+// and the roller recomputes the queued periods outside of them
+// A nil BlockStatsRoller does nothing
+type BlockStatsRoller struct {
+	db       *postgres.DB
+	interval time.Duration
+	quit     chan bool
+	stopOnce sync.Once
+}
+
+// NewBlockStatsRoller returns a new BlockStatsRoller that refreshes the rollups every interval once started
+func NewBlockStatsRoller(db *postgres.DB, interval time.Duration) *BlockStatsRoller {
+	if interval <= 0 {
+		interval = DefaultRollupInterval
+	}
+	return &BlockStatsRoller{
+		db:       db,
+		interval: interval,
+		quit:     make(chan bool),
+	}
+}
+
+// Roll recomputes the rollups of the hours and days whose block stats changed since the last refresh, and returns the number of hours recomputed
+func (r *BlockStatsRoller) Roll() (int64, error) {
+	if r == nil {
+		return 0, nil
+	}
+	var hours int64
+	err := r.db.Get(&hours, `SELECT eth.refresh_block_stats_rollups()`)
+	return hours, err
+}
+
+// Start refreshes the rollups every interval until Stop is called, and once more on the way out
+func (r *BlockStatsRoller) Start(wg *sync.WaitGroup) {
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(r.interval)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.roll()
+			case <-r.quit:
+				r.roll()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic refreshes, it is safe to call more than once
+func (r *BlockStatsRoller) Stop() {
+	if r == nil {
+		return
+	}
+	r.stopOnce.Do(func() { close(r.quit) })
+}
+
+func (r *BlockStatsRoller) roll() {
+	hours, err := r.Roll()
+	if err != nil {
+		logrus.Errorf("ethereum block stats rollup error: %v", err)
+		return
+	}
+	if hours > 0 {
+		logrus.Debugf("ethereum block stats rollups refreshed for %d hours", hours)
+	}
+}
@@ -0,0 +1,46 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("BlockStatsRoller", func() {
+	It("Can be stopped more than once", func() {
+		// the roller is stopped by both the service loop and its shutdown
+		roller := eth.NewBlockStatsRoller(nil, 0)
+		Expect(roller.Stop).ToNot(Panic())
+		Expect(roller.Stop).ToNot(Panic())
+	})
+
+	It("Does nothing when nil", func() {
+		var roller *eth.BlockStatsRoller
+		wg := new(sync.WaitGroup)
+		roller.Start(wg)
+		roller.Stop()
+		wg.Wait()
+		hours, err := roller.Roll()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hours).To(BeZero())
+	})
+})
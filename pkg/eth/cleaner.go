@@ -60,7 +60,7 @@ func (c *DBCleaner) ResetValidation(rngs [][2]uint64) error {
 			return err
 		}
 	}
-	return tx.Commit()
+	return shared.Commit(tx)
 }
 
 // Clean removes the specified data from the db within the provided block range
@@ -69,18 +69,214 @@ func (c *DBCleaner) Clean(rngs [][2]uint64, t shared.DataType) error {
 	if err != nil {
 		return err
 	}
+	latest, err := c.tracksLatestState(tx, t)
+	if err != nil {
+		shared.Rollback(tx)
+		return err
+	}
 	for _, rng := range rngs {
 		logrus.Infof("eth db cleaner cleaning up block range %d to %d", rng[0], rng[1])
+		if latest {
+			// the keys have to be collected before the rows that wrote them are deleted
+			if _, err := tx.Exec(`SELECT eth.touch_latest_state($1, $2)`, rng[0], rng[1]); err != nil {
+				shared.Rollback(tx)
+				return err
+			}
+		}
 		if err := c.clean(tx, rng, t); err != nil {
 			shared.Rollback(tx)
 			return err
 		}
 	}
-	if err := tx.Commit(); err != nil {
+	if latest {
+		if _, err := tx.Exec(`SELECT eth.recompute_latest_state()`); err != nil {
+			shared.Rollback(tx)
+			return err
+		}
+	}
+	if err := shared.Commit(tx); err != nil {
+		return err
+	}
+	// the deleted IPLDs can no longer be assumed to be present
+	shared.PurgeKeyCache()
+	// the deleted block stats were queued to be subtracted from the rollups
+	if _, err := NewBlockStatsRoller(c.db, 0).Roll(); err != nil {
 		return err
 	}
 	logrus.Infof("eth db cleaner vacuum analyzing cleaned tables to free up space from deleted rows")
-	return c.vacuumAnalyze(t)
+	return c.vacuumAnalyze(rngs, t)
+}
+
+// Prune removes every header, state, and storage partition that lies entirely below the provided block number
+// If detach is true the partitions are only detached, and kept as eth.<table>_<lower bound>_detached tables for archival,
+// otherwise they are dropped along with their IPLDs and the uncle, transaction, receipt, account, removal, and account change rows that reference them
+// Either way, the accounts and storage slots in eth.latest_accounts and eth.latest_storage that were last written below the pruned bound are removed
+// It returns the lower bounds of the partitions that were removed
+func (c *DBCleaner) Prune(before uint64, detach bool) ([]uint64, error) {
+	width, err := c.partitionWidth()
+	if err != nil {
+		return nil, err
+	}
+	lowers := make([]uint64, 0)
+	pgStr := `SELECT substring(child.relname FROM 'header_cids_([0-9]+)$')::BIGINT AS lower_bound
+			FROM pg_inherits
+			INNER JOIN pg_class parent ON (pg_inherits.inhparent = parent.oid)
+			INNER JOIN pg_class child ON (pg_inherits.inhrelid = child.oid)
+			WHERE parent.oid = 'eth.header_cids'::regclass
+			ORDER BY lower_bound`
+	if err := c.db.Select(&lowers, pgStr); err != nil {
+		return nil, err
+	}
+	pruned := make([]uint64, 0, len(lowers))
+	for _, lower := range lowers {
+		if lower+width > before {
+			break
+		}
+		logrus.Infof("eth db cleaner pruning partitions for block range %d to %d", lower, lower+width-1)
+		if err := c.prunePartitions(lower, detach); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, lower)
+	}
+	if !detach && len(pruned) > 0 {
+		shared.PurgeKeyCache()
+		if _, err := NewBlockStatsRoller(c.db, 0).Roll(); err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+func (c *DBCleaner) prunePartitions(lower uint64, detach bool) error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return err
+	}
+	if detach {
+		_, err = tx.Exec(`SELECT eth.detach_block_partitions($1, false)`, lower)
+	} else {
+		err = c.dropPartitions(tx, lower)
+	}
+	if err == nil {
+		// partitions are pruned from the bottom up, so nothing below the bound is left to recompute these keys from
+		err = c.cleanLatestState(tx, lower)
+	}
+	if err != nil {
+		shared.Rollback(tx)
+		return err
+	}
+	return shared.Commit(tx)
+}
+
+// cleanLatestState removes the latest state that was written within the partition starting at the provided lower bound
+func (c *DBCleaner) cleanLatestState(tx *sqlx.Tx, lower uint64) error {
+	var width uint64
+	if err := tx.Get(&width, `SELECT eth.block_partition_width()`); err != nil {
+		return err
+	}
+	pgStr := `DELETE FROM eth.latest_storage WHERE block_number BETWEEN $1 AND $2`
+	if _, err := tx.Exec(pgStr, lower, lower+width-1); err != nil {
+		return err
+	}
+	pgStr = `DELETE FROM eth.latest_accounts WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, lower, lower+width-1)
+	return err
+}
+
+// tracksLatestState returns whether cleaning the provided type can change the latest state, and the latest state tables are maintained
+func (c *DBCleaner) tracksLatestState(tx *sqlx.Tx, t shared.DataType) (bool, error) {
+	switch t {
+	case shared.Full, shared.Headers, shared.State, shared.Storage:
+	default:
+		return false, nil
+	}
+	var tracked bool
+	pgStr := `SELECT EXISTS (SELECT 1 FROM eth.latest_accounts) OR EXISTS (SELECT 1 FROM eth.latest_storage)`
+	return tracked, tx.Get(&tracked, pgStr)
+}
+
+// partitionWidth returns the number of blocks covered by each header, state, and storage partition
+func (c *DBCleaner) partitionWidth() (uint64, error) {
+	var width uint64
+	return width, c.db.Get(&width, `SELECT eth.block_partition_width()`)
+}
+
+// splitRange splits a block range into the lower bounds of the partitions it completely covers,
+// and the leftover ranges that only partially cover a partition
+func splitRange(rng [2]uint64, width uint64) ([]uint64, [][2]uint64) {
+	whole := make([]uint64, 0)
+	rest := make([][2]uint64, 0)
+	for start := rng[0]; start <= rng[1]; {
+		lower := start - start%width
+		upper := lower + width - 1
+		if upper > rng[1] {
+			upper = rng[1]
+		}
+		if start == lower && upper == lower+width-1 {
+			whole = append(whole, lower)
+		} else {
+			rest = append(rest, [2]uint64{start, upper})
+		}
+		if upper == rng[1] {
+			break
+		}
+		start = upper + 1
+	}
+	return whole, rest
+}
+
+// dropPartitions drops the header, state, and storage partitions starting at the provided lower bound
+// along with their IPLDs and the uncle, transaction, receipt, account, removal, and account change rows that reference them,
+// without deleting and vacuuming the partitioned rows one by one
+func (c *DBCleaner) dropPartitions(tx *sqlx.Tx, lower uint64) error {
+	var detached bool
+	if err := tx.Get(&detached, `SELECT eth.detach_block_partitions($1, false)`, lower); err != nil {
+		return err
+	}
+	if !detached {
+		return nil
+	}
+	headers := fmt.Sprintf("eth.header_cids_%d_detached", lower)
+	states := fmt.Sprintf("eth.state_cids_%d_detached", lower)
+	storage := fmt.Sprintf("eth.storage_cids_%d_detached", lower)
+	pgStrs := []string{
+		// collect the keys of the IPLDs the pruned rows reference before the rows are gone
+		fmt.Sprintf(`CREATE TEMPORARY TABLE pruned_keys AS
+			SELECT mh_key AS key FROM %[1]s
+			UNION SELECT mh_key FROM %[2]s
+			UNION SELECT mh_key FROM %[3]s
+			UNION SELECT B.mh_key FROM eth.receipt_cids B, eth.transaction_cids C, %[3]s D WHERE B.tx_id = C.id AND C.header_id = D.id
+			UNION SELECT B.mh_key FROM eth.transaction_cids B, %[3]s C WHERE B.header_id = C.id
+			UNION SELECT B.mh_key FROM eth.uncle_cids B, %[3]s C WHERE B.header_id = C.id`, storage, states, headers),
+		fmt.Sprintf(`DELETE FROM eth.state_accounts A USING %s B WHERE A.state_id = B.id`, states),
+		fmt.Sprintf(`DELETE FROM eth.storage_removals A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.decoded_storage A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.state_removals A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.account_changes A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.block_stats A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.transaction_cids A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DELETE FROM eth.uncle_cids A USING %s B WHERE A.header_id = B.id`, headers),
+		fmt.Sprintf(`DROP TABLE %s, %s, %s`, storage, states, headers),
+		// IPLDs are content addressed, so an unchanged node is shared with the blocks above the bound
+		// and only the IPLDs that no remaining row references are deleted, so that the delete cascades to none of the rows that are kept
+		`DELETE FROM public.blocks USING pruned_keys
+			WHERE blocks.key = pruned_keys.key
+			AND NOT EXISTS (SELECT 1 FROM eth.header_cids WHERE header_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.uncle_cids WHERE uncle_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.transaction_cids WHERE transaction_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.receipt_cids WHERE receipt_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.state_cids WHERE state_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.storage_cids WHERE storage_cids.mh_key = pruned_keys.key)
+			AND NOT EXISTS (SELECT 1 FROM eth.latest_storage WHERE latest_storage.mh_key = pruned_keys.key)`,
+		// several partitions can be dropped in the same tx
+		`DROP TABLE pruned_keys`,
+	}
+	for _, pgStr := range pgStrs {
+		if _, err := tx.Exec(pgStr); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *DBCleaner) clean(tx *sqlx.Tx, rng [2]uint64, t shared.DataType) error {
@@ -106,27 +302,49 @@ func (c *DBCleaner) clean(tx *sqlx.Tx, rng [2]uint64, t shared.DataType) error {
 		}
 		return c.cleanReceiptMetaData(tx, rng)
 	case shared.State:
+		// the accounts are found through the state nodes, which their IPLDs cascade to
+		if err := c.cleanAccountMetaData(tx, rng); err != nil {
+			return err
+		}
 		if err := c.cleanStorageIPLDs(tx, rng); err != nil {
 			return err
 		}
 		if err := c.cleanStateIPLDs(tx, rng); err != nil {
 			return err
 		}
+		if err := c.cleanStorageRemovals(tx, rng); err != nil {
+			return err
+		}
+		if err := c.cleanDecodedStorage(tx, rng); err != nil {
+			return err
+		}
+		if err := c.cleanStateRemovals(tx, rng); err != nil {
+			return err
+		}
+		if err := c.cleanAccountChanges(tx, rng); err != nil {
+			return err
+		}
 		return c.cleanStateMetaData(tx, rng)
 	case shared.Storage:
 		if err := c.cleanStorageIPLDs(tx, rng); err != nil {
 			return err
 		}
+		if err := c.cleanStorageRemovals(tx, rng); err != nil {
+			return err
+		}
+		if err := c.cleanDecodedStorage(tx, rng); err != nil {
+			return err
+		}
 		return c.cleanStorageMetaData(tx, rng)
 	default:
 		return fmt.Errorf("eth cleaner unrecognized type: %s", t.String())
 	}
 }
 
-func (c *DBCleaner) vacuumAnalyze(t shared.DataType) error {
+func (c *DBCleaner) vacuumAnalyze(rngs [][2]uint64, t shared.DataType) error {
 	switch t {
 	case shared.Full, shared.Headers:
-		return c.vacuumFull()
+		return c.vacuumFull(rngs)
 	case shared.Uncles:
 		if err := c.vacuumUncles(); err != nil {
 			return err
@@ -135,25 +353,73 @@ func (c *DBCleaner) vacuumAnalyze(t shared.DataType) error {
 		if err := c.vacuumTxs(); err != nil {
 			return err
 		}
+		if err := c.vacuumAccessLists(); err != nil {
+			return err
+		}
+		if err := c.vacuumContracts(); err != nil {
+			return err
+		}
+		if err := c.vacuumAddressTransactions(); err != nil {
+			return err
+		}
 		if err := c.vacuumRcts(); err != nil {
 			return err
 		}
+		if err := c.vacuumLogs(); err != nil {
+			return err
+		}
+		if err := c.vacuumDecodedEvents(); err != nil {
+			return err
+		}
+		if err := c.vacuumTokenTransfers(); err != nil {
+			return err
+		}
 	case shared.Receipts:
 		if err := c.vacuumRcts(); err != nil {
 			return err
 		}
+		if err := c.vacuumAddressTransactions(); err != nil {
+			return err
+		}
+		if err := c.vacuumLogs(); err != nil {
+			return err
+		}
+		if err := c.vacuumDecodedEvents(); err != nil {
+			return err
+		}
+		if err := c.vacuumTokenTransfers(); err != nil {
+			return err
+		}
 	case shared.State:
-		if err := c.vacuumState(); err != nil {
+		if err := c.vacuumState(rngs); err != nil {
 			return err
 		}
 		if err := c.vacuumAccounts(); err != nil {
 			return err
 		}
-		if err := c.vacuumStorage(); err != nil {
+		if err := c.vacuumStateRemovals(); err != nil {
+			return err
+		}
+		if err := c.vacuumAccountChanges(); err != nil {
+			return err
+		}
+		if err := c.vacuumStorage(rngs); err != nil {
+			return err
+		}
+		if err := c.vacuumStorageRemovals(); err != nil {
+			return err
+		}
+		if err := c.vacuumDecodedStorage(); err != nil {
 			return err
 		}
 	case shared.Storage:
-		if err := c.vacuumStorage(); err != nil {
+		if err := c.vacuumStorage(rngs); err != nil {
+			return err
+		}
+		if err := c.vacuumStorageRemovals(); err != nil {
+			return err
+		}
+		if err := c.vacuumDecodedStorage(); err != nil {
 			return err
 		}
 	default:
@@ -162,8 +428,11 @@ func (c *DBCleaner) vacuumAnalyze(t shared.DataType) error {
 	return c.vacuumIPLDs()
 }
 
-func (c *DBCleaner) vacuumFull() error {
-	if err := c.vacuumHeaders(); err != nil {
+func (c *DBCleaner) vacuumFull(rngs [][2]uint64) error {
+	if err := c.vacuumHeaders(rngs); err != nil {
+		return err
+	}
+	if err := c.vacuumBlockStats(); err != nil {
 		return err
 	}
 	if err := c.vacuumUncles(); err != nil {
@@ -172,21 +441,50 @@ func (c *DBCleaner) vacuumFull() error {
 	if err := c.vacuumTxs(); err != nil {
 		return err
 	}
+	if err := c.vacuumAccessLists(); err != nil {
+		return err
+	}
+	if err := c.vacuumContracts(); err != nil {
+		return err
+	}
+	if err := c.vacuumAddressTransactions(); err != nil {
+		return err
+	}
 	if err := c.vacuumRcts(); err != nil {
 		return err
 	}
-	if err := c.vacuumState(); err != nil {
+	if err := c.vacuumLogs(); err != nil {
+		return err
+	}
+	if err := c.vacuumDecodedEvents(); err != nil {
+		return err
+	}
+	if err := c.vacuumTokenTransfers(); err != nil {
+		return err
+	}
+	if err := c.vacuumState(rngs); err != nil {
 		return err
 	}
 	if err := c.vacuumAccounts(); err != nil {
 		return err
 	}
-	return c.vacuumStorage()
+	if err := c.vacuumStateRemovals(); err != nil {
+		return err
+	}
+	if err := c.vacuumAccountChanges(); err != nil {
+		return err
+	}
+	if err := c.vacuumStorage(rngs); err != nil {
+		return err
+	}
+	if err := c.vacuumStorageRemovals(); err != nil {
+		return err
+	}
+	return c.vacuumDecodedStorage()
 }
 
-func (c *DBCleaner) vacuumHeaders() error {
-	_, err := c.db.Exec(`VACUUM ANALYZE eth.header_cids`)
-	return err
+func (c *DBCleaner) vacuumHeaders(rngs [][2]uint64) error {
+	return c.vacuumPartitions("header_cids", rngs)
 }
 
 func (c *DBCleaner) vacuumUncles() error {
@@ -199,32 +497,155 @@ func (c *DBCleaner) vacuumTxs() error {
 	return err
 }
 
+func (c *DBCleaner) vacuumAccessLists() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.access_list_elements`)
+	return err
+}
+
+func (c *DBCleaner) vacuumContracts() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.contracts`)
+	return err
+}
+
+func (c *DBCleaner) vacuumAddressTransactions() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.address_transactions`)
+	return err
+}
+
 func (c *DBCleaner) vacuumRcts() error {
 	_, err := c.db.Exec(`VACUUM ANALYZE eth.receipt_cids`)
 	return err
 }
 
-func (c *DBCleaner) vacuumState() error {
-	_, err := c.db.Exec(`VACUUM ANALYZE eth.state_cids`)
+func (c *DBCleaner) vacuumLogs() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.log_cids`)
+	return err
+}
+
+func (c *DBCleaner) vacuumDecodedEvents() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.decoded_events`)
 	return err
 }
 
+func (c *DBCleaner) vacuumTokenTransfers() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.token_transfers`)
+	return err
+}
+
+func (c *DBCleaner) vacuumState(rngs [][2]uint64) error {
+	return c.vacuumPartitions("state_cids", rngs)
+}
+
 func (c *DBCleaner) vacuumAccounts() error {
 	_, err := c.db.Exec(`VACUUM ANALYZE eth.state_accounts`)
 	return err
 }
 
-func (c *DBCleaner) vacuumStorage() error {
-	_, err := c.db.Exec(`VACUUM ANALYZE eth.storage_cids`)
+func (c *DBCleaner) vacuumBlockStats() error {
+	if _, err := c.db.Exec(`VACUUM ANALYZE eth.block_stats`); err != nil {
+		return err
+	}
+	if _, err := c.db.Exec(`VACUUM ANALYZE eth.hourly_block_stats`); err != nil {
+		return err
+	}
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.daily_block_stats`)
+	return err
+}
+
+func (c *DBCleaner) vacuumStateRemovals() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.state_removals`)
+	return err
+}
+
+func (c *DBCleaner) vacuumAccountChanges() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.account_changes`)
 	return err
 }
 
+func (c *DBCleaner) vacuumStorage(rngs [][2]uint64) error {
+	return c.vacuumPartitions("storage_cids", rngs)
+}
+
+func (c *DBCleaner) vacuumStorageRemovals() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.storage_removals`)
+	return err
+}
+
+func (c *DBCleaner) vacuumDecodedStorage() error {
+	_, err := c.db.Exec(`VACUUM ANALYZE eth.decoded_storage`)
+	return err
+}
+
+// vacuumPartitions vacuum analyzes only the partitions of the table that overlap the provided ranges and still exist
+func (c *DBCleaner) vacuumPartitions(table string, rngs [][2]uint64) error {
+	width, err := c.partitionWidth()
+	if err != nil {
+		return err
+	}
+	for _, rng := range rngs {
+		for lower := rng[0] - rng[0]%width; lower <= rng[1]; lower += width {
+			partition := fmt.Sprintf("eth.%s_%d", table, lower)
+			var exists bool
+			if err := c.db.Get(&exists, `SELECT to_regclass($1) IS NOT NULL`, partition); err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			if _, err := c.db.Exec(fmt.Sprintf(`VACUUM ANALYZE %s`, partition)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *DBCleaner) vacuumIPLDs() error {
 	_, err := c.db.Exec(`VACUUM ANALYZE public.blocks`)
 	return err
 }
 
 func (c *DBCleaner) cleanFull(tx *sqlx.Tx, rng [2]uint64) error {
+	var width uint64
+	if err := tx.Get(&width, `SELECT eth.block_partition_width()`); err != nil {
+		return err
+	}
+	// drop the partitions that are entirely within the range, and delete rows for the rest
+	whole, rest := splitRange(rng, width)
+	for _, lower := range whole {
+		if err := c.dropPartitions(tx, lower); err != nil {
+			return err
+		}
+	}
+	for _, r := range rest {
+		if err := c.cleanFullRows(tx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *DBCleaner) cleanFullRows(tx *sqlx.Tx, rng [2]uint64) error {
+	// these tables do not reference the partitioned header and state tables by FK, so their rows are not deleted by cascade
+	// and they are found through the header and state rows, so they are deleted before the IPLD deletes cascade to those
+	if err := c.cleanAccountMetaData(tx, rng); err != nil {
+		return err
+	}
+	if err := c.cleanStorageRemovals(tx, rng); err != nil {
+		return err
+	}
+	if err := c.cleanDecodedStorage(tx, rng); err != nil {
+		return err
+	}
+	if err := c.cleanStateRemovals(tx, rng); err != nil {
+		return err
+	}
+	if err := c.cleanAccountChanges(tx, rng); err != nil {
+		return err
+	}
+	if err := c.cleanBlockStats(tx, rng); err != nil {
+		return err
+	}
 	if err := c.cleanStorageIPLDs(tx, rng); err != nil {
 		return err
 	}
@@ -237,9 +658,15 @@ func (c *DBCleaner) cleanFull(tx *sqlx.Tx, rng [2]uint64) error {
 	if err := c.cleanTransactionIPLDs(tx, rng); err != nil {
 		return err
 	}
+	if err := c.cleanTransactionMetaData(tx, rng); err != nil {
+		return err
+	}
 	if err := c.cleanUncleIPLDs(tx, rng); err != nil {
 		return err
 	}
+	if err := c.cleanUncleMetaData(tx, rng); err != nil {
+		return err
+	}
 	if err := c.cleanHeaderIPLDs(tx, rng); err != nil {
 		return err
 	}
@@ -267,6 +694,20 @@ func (c *DBCleaner) cleanStorageMetaData(tx *sqlx.Tx, rng [2]uint64) error {
 	return err
 }
 
+func (c *DBCleaner) cleanStorageRemovals(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.storage_removals
+			WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
+func (c *DBCleaner) cleanDecodedStorage(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.decoded_storage
+			WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
 func (c *DBCleaner) cleanStateIPLDs(tx *sqlx.Tx, rng [2]uint64) error {
 	pgStr := `DELETE FROM public.blocks A
 			USING eth.state_cids B, eth.header_cids C
@@ -286,6 +727,38 @@ func (c *DBCleaner) cleanStateMetaData(tx *sqlx.Tx, rng [2]uint64) error {
 	return err
 }
 
+func (c *DBCleaner) cleanStateRemovals(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.state_removals
+			WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
+func (c *DBCleaner) cleanAccountChanges(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.account_changes
+			WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
+// cleanBlockStats deletes the stats of the blocks in the range, which subtracts the canonical ones from the hourly and daily rollups
+func (c *DBCleaner) cleanBlockStats(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.block_stats
+			WHERE block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
+func (c *DBCleaner) cleanAccountMetaData(tx *sqlx.Tx, rng [2]uint64) error {
+	pgStr := `DELETE FROM eth.state_accounts A
+			USING eth.state_cids B, eth.header_cids C
+			WHERE A.state_id = B.id
+			AND B.header_id = C.id
+			AND C.block_number BETWEEN $1 AND $2`
+	_, err := tx.Exec(pgStr, rng[0], rng[1])
+	return err
+}
+
 func (c *DBCleaner) cleanReceiptIPLDs(tx *sqlx.Tx, rng [2]uint64) error {
 	pgStr := `DELETE FROM public.blocks A
 			USING eth.receipt_cids B, eth.transaction_cids C, eth.header_cids D
@@ -298,7 +771,17 @@ func (c *DBCleaner) cleanReceiptIPLDs(tx *sqlx.Tx, rng [2]uint64) error {
 }
 
 func (c *DBCleaner) cleanReceiptMetaData(tx *sqlx.Tx, rng [2]uint64) error {
-	pgStr := `DELETE FROM eth.receipt_cids A
+	// the created contract and log emitter links are derived from the receipts, the sender and recipient links stay with the txs
+	pgStr := `DELETE FROM eth.address_transactions A
+			USING eth.transaction_cids B, eth.header_cids C
+			WHERE A.tx_id = B.id
+			AND B.header_id = C.id
+			AND C.block_number BETWEEN $1 AND $2
+			AND A.role IN ($3, $4)`
+	if _, err := tx.Exec(pgStr, rng[0], rng[1], ContractRole, LogEmitterRole); err != nil {
+		return err
+	}
+	pgStr = `DELETE FROM eth.receipt_cids A
 			USING eth.transaction_cids B, eth.header_cids C
 			WHERE A.tx_id = B.id
 			AND B.header_id = C.id
@@ -22,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
@@ -151,12 +152,23 @@ var (
 			},
 		},
 	}
+	stateAccount = eth.StateAccountModel{
+		Balance:     "1000",
+		Nonce:       1,
+		CodeHash:    crypto.Keccak256(nil),
+		StorageRoot: crypto.Keccak256Hash(nil).String(),
+	}
+	stateAccounts1 = map[string]eth.StateAccountModel{
+		common.Bytes2Hex(state1Path): stateAccount,
+		common.Bytes2Hex(state2Path): stateAccount,
+	}
 	mockCIDPayload1 = eth.CIDPayload{
 		HeaderCID:       headerModel,
 		UncleCIDs:       uncleModels1,
 		TransactionCIDs: txModels1,
 		ReceiptCIDs:     receiptModels1,
 		StateNodeCIDs:   stateModels1,
+		StateAccounts:   stateAccounts1,
 		StorageNodeCIDs: storageModels1,
 	}
 
@@ -215,6 +227,33 @@ var (
 		TransactionCIDs: txModels2,
 		ReceiptCIDs:     receiptModels2,
 		StateNodeCIDs:   stateModels2,
+		StateAccounts:   map[string]eth.StateAccountModel{common.Bytes2Hex(state1Path): stateAccount},
+	}
+	// Block 100001, in the second block partition
+	headerCID3      = shared.TestCID([]byte("mockHeaderCID3"))
+	headerMhKey3    = shared.MultihashKeyFromCID(headerCID3)
+	state2CID3      = shared.TestCID([]byte("mockState2CID3"))
+	state2MhKey3    = shared.MultihashKeyFromCID(state2CID3)
+	mockCIDPayload3 = eth.CIDPayload{
+		HeaderCID: eth.HeaderModel{
+			BlockHash:       crypto.Keccak256Hash([]byte{00, 04}).String(),
+			BlockNumber:     "100001",
+			CID:             headerCID3.String(),
+			MhKey:           headerMhKey3,
+			ParentHash:      blockHash2.String(),
+			TotalDifficulty: totalDifficulty,
+			Reward:          reward,
+		},
+		StateNodeCIDs: []eth.StateNodeModel{
+			{
+				CID:      state2CID3.String(),
+				MhKey:    state2MhKey3,
+				Path:     state2Path,
+				NodeType: 2,
+				StateKey: state2Key.String(),
+			},
+		},
+		StateAccounts: map[string]eth.StateAccountModel{common.Bytes2Hex(state2Path): stateAccount},
 	}
 	rngs   = [][2]uint64{{0, 1}}
 	mhKeys = []string{
@@ -291,6 +330,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM eth.header_cids`
 			err = tx.Get(&startingHeaderCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var startingAccountCount int
+			pgStr = `SELECT COUNT(*) FROM eth.state_accounts`
+			err = tx.Get(&startingAccountCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 
 			err = tx.Commit()
 			Expect(err).ToNot(HaveOccurred())
@@ -302,6 +345,7 @@ var _ = Describe("Cleaner", func() {
 			Expect(startingTxCount).To(Equal(3))
 			Expect(startingUncleCount).To(Equal(1))
 			Expect(startingHeaderCount).To(Equal(2))
+			Expect(startingAccountCount).To(Equal(3))
 		})
 		AfterEach(func() {
 			eth.TearDownDB(db)
@@ -337,6 +381,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM eth.storage_cids`
 			err = tx.Get(&storageCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var accountCount int
+			pgStr = `SELECT COUNT(*) FROM eth.state_accounts`
+			err = tx.Get(&accountCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 			var blocksCount int
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
@@ -351,6 +399,7 @@ var _ = Describe("Cleaner", func() {
 			Expect(rctCount).To(Equal(0))
 			Expect(stateCount).To(Equal(0))
 			Expect(storageCount).To(Equal(0))
+			Expect(accountCount).To(Equal(0))
 			Expect(blocksCount).To(Equal(0))
 		})
 		It("Cleans headers and all linked data (same as full)", func() {
@@ -384,6 +433,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM eth.storage_cids`
 			err = tx.Get(&storageCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var accountCount int
+			pgStr = `SELECT COUNT(*) FROM eth.state_accounts`
+			err = tx.Get(&accountCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 			var blocksCount int
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
@@ -398,6 +451,7 @@ var _ = Describe("Cleaner", func() {
 			Expect(rctCount).To(Equal(0))
 			Expect(stateCount).To(Equal(0))
 			Expect(storageCount).To(Equal(0))
+			Expect(accountCount).To(Equal(0))
 			Expect(blocksCount).To(Equal(0))
 		})
 		It("Cleans uncles", func() {
@@ -482,6 +536,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var addressTxCount int
+			pgStr = `SELECT COUNT(*) FROM eth.address_transactions`
+			err = tx.Get(&addressTxCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 
 			err = tx.Commit()
 			Expect(err).ToNot(HaveOccurred())
@@ -493,6 +551,7 @@ var _ = Describe("Cleaner", func() {
 			Expect(stateCount).To(Equal(3))
 			Expect(storageCount).To(Equal(1))
 			Expect(blocksCount).To(Equal(7))
+			Expect(addressTxCount).To(Equal(0))
 		})
 		It("Cleans receipts", func() {
 			err := cleaner.Clean(rngs, shared.Receipts)
@@ -529,6 +588,14 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var rctAddressTxCount int
+			pgStr = `SELECT COUNT(*) FROM eth.address_transactions WHERE role IN ($1, $2)`
+			err = tx.Get(&rctAddressTxCount, pgStr, eth.ContractRole, eth.LogEmitterRole)
+			Expect(err).ToNot(HaveOccurred())
+			var txAddressTxCount int
+			pgStr = `SELECT COUNT(*) FROM eth.address_transactions WHERE role IN ($1, $2)`
+			err = tx.Get(&txAddressTxCount, pgStr, eth.SenderRole, eth.RecipientRole)
+			Expect(err).ToNot(HaveOccurred())
 
 			err = tx.Commit()
 			Expect(err).ToNot(HaveOccurred())
@@ -540,6 +607,8 @@ var _ = Describe("Cleaner", func() {
 			Expect(stateCount).To(Equal(3))
 			Expect(storageCount).To(Equal(1))
 			Expect(blocksCount).To(Equal(10))
+			Expect(rctAddressTxCount).To(Equal(0))
+			Expect(txAddressTxCount).ToNot(BeZero())
 		})
 		It("Cleans state and linked storage", func() {
 			err := cleaner.Clean(rngs, shared.State)
@@ -572,6 +641,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM eth.storage_cids`
 			err = tx.Get(&storageCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var accountCount int
+			pgStr = `SELECT COUNT(*) FROM eth.state_accounts`
+			err = tx.Get(&accountCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 			var blocksCount int
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
@@ -586,6 +659,7 @@ var _ = Describe("Cleaner", func() {
 			Expect(rctCount).To(Equal(3))
 			Expect(stateCount).To(Equal(0))
 			Expect(storageCount).To(Equal(0))
+			Expect(accountCount).To(Equal(0))
 			Expect(blocksCount).To(Equal(9))
 		})
 		It("Cleans storage", func() {
@@ -619,6 +693,10 @@ var _ = Describe("Cleaner", func() {
 			pgStr = `SELECT COUNT(*) FROM eth.storage_cids`
 			err = tx.Get(&storageCount, pgStr)
 			Expect(err).ToNot(HaveOccurred())
+			var accountCount int
+			pgStr = `SELECT COUNT(*) FROM eth.state_accounts`
+			err = tx.Get(&accountCount, pgStr)
+			Expect(err).ToNot(HaveOccurred())
 			var blocksCount int
 			pgStr = `SELECT COUNT(*) FROM public.blocks`
 			err = tx.Get(&blocksCount, pgStr)
@@ -633,10 +711,178 @@ var _ = Describe("Cleaner", func() {
 			Expect(rctCount).To(Equal(3))
 			Expect(stateCount).To(Equal(3))
 			Expect(storageCount).To(Equal(0))
+			Expect(accountCount).To(Equal(3))
 			Expect(blocksCount).To(Equal(12))
 		})
 	})
 
+	Describe("Clean across partitions", func() {
+		BeforeEach(func() {
+			for _, key := range append(mhKeys, headerMhKey3, state2MhKey3) {
+				_, err := db.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2)`, key, mockData)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			err := repo.Index(mockCIDPayload1)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.Index(mockCIDPayload2)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.Index(mockCIDPayload3)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			eth.TearDownDB(db)
+		})
+		It("Creates the partitions holding each indexed block", func() {
+			Expect(tableExists(db, "eth.header_cids_0")).To(BeTrue())
+			Expect(tableExists(db, "eth.state_cids_0")).To(BeTrue())
+			Expect(tableExists(db, "eth.storage_cids_0")).To(BeTrue())
+			Expect(tableExists(db, "eth.header_cids_100000")).To(BeTrue())
+			Expect(tableExists(db, "eth.state_cids_100000")).To(BeTrue())
+			Expect(tableExists(db, "eth.storage_cids_100000")).To(BeTrue())
+		})
+		It("Drops the partitions the range covers and deletes the rows of the rest", func() {
+			err := cleaner.Clean([][2]uint64{{0, 100001}}, shared.Full)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tableExists(db, "eth.header_cids_0")).To(BeFalse())
+			Expect(tableExists(db, "eth.state_cids_0")).To(BeFalse())
+			Expect(tableExists(db, "eth.storage_cids_0")).To(BeFalse())
+			Expect(tableExists(db, "eth.header_cids_100000")).To(BeTrue())
+			Expect(countRows(db, "eth.header_cids")).To(Equal(0))
+			Expect(countRows(db, "eth.state_cids")).To(Equal(0))
+			Expect(countRows(db, "eth.transaction_cids")).To(Equal(0))
+			Expect(countRows(db, "eth.state_accounts")).To(Equal(0))
+			Expect(countRows(db, "public.blocks")).To(Equal(0))
+		})
+		It("Only deletes the rows within a range that partially covers two partitions", func() {
+			err := cleaner.Clean([][2]uint64{{1, 100001}}, shared.Full)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(tableExists(db, "eth.header_cids_0")).To(BeTrue())
+			Expect(tableExists(db, "eth.header_cids_100000")).To(BeTrue())
+			var blockNumbers []uint64
+			err = db.Select(&blockNumbers, `SELECT block_number FROM eth.header_cids`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockNumbers).To(Equal([]uint64{0}))
+			Expect(countRows(db, "eth.state_cids")).To(Equal(2))
+			Expect(countRows(db, "eth.state_accounts")).To(Equal(2))
+		})
+		It("Removes the latest state written within the range", func() {
+			insertLatestAccount(db, state1Key.String(), 1)
+			insertLatestAccount(db, state2Key.String(), 100001)
+
+			err := cleaner.Clean([][2]uint64{{100000, 100001}}, shared.Full)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the account written outside of the range is kept, the one written within it can only fall back to an earlier write
+			var blockNumber uint64
+			err = db.Get(&blockNumber, `SELECT block_number FROM eth.latest_accounts WHERE state_leaf_key = $1`, state1Key.String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockNumber).To(Equal(uint64(1)))
+			var count int
+			err = db.Get(&count, `SELECT COUNT(*) FROM eth.latest_accounts WHERE block_number >= 100000`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+		})
+	})
+	Describe("Prune", func() {
+		BeforeEach(func() {
+			for _, key := range append(mhKeys, headerMhKey3, state2MhKey3) {
+				_, err := db.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2)`, key, mockData)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			err := repo.Index(mockCIDPayload1)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.Index(mockCIDPayload2)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.Index(mockCIDPayload3)
+			Expect(err).ToNot(HaveOccurred())
+		})
+		AfterEach(func() {
+			_, err := db.Exec(`DROP TABLE IF EXISTS eth.header_cids_0_detached, eth.state_cids_0_detached, eth.storage_cids_0_detached`)
+			Expect(err).ToNot(HaveOccurred())
+			eth.TearDownDB(db)
+		})
+		It("Drops the partitions that lie entirely below the bound", func() {
+			pruned, err := cleaner.Prune(100001, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pruned).To(Equal([]uint64{0}))
+
+			Expect(tableExists(db, "eth.header_cids_0")).To(BeFalse())
+			Expect(tableExists(db, "eth.header_cids_0_detached")).To(BeFalse())
+			Expect(tableExists(db, "eth.header_cids_100000")).To(BeTrue())
+			Expect(countRows(db, "eth.header_cids")).To(Equal(1))
+			Expect(countRows(db, "eth.state_cids")).To(Equal(1))
+			Expect(countRows(db, "eth.transaction_cids")).To(Equal(0))
+			Expect(countRows(db, "eth.uncle_cids")).To(Equal(0))
+			Expect(countRows(db, "eth.state_accounts")).To(Equal(1))
+			Expect(countRows(db, "public.blocks")).To(Equal(2))
+		})
+		It("Keeps the IPLDs that are still referenced above the bound", func() {
+			// an account left unchanged since block 0 shares its leaf IPLD with the pruned partition
+			headerCID4 := shared.TestCID([]byte("mockHeaderCID4"))
+			headerMhKey4 := shared.MultihashKeyFromCID(headerCID4)
+			_, err := db.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2)`, headerMhKey4, mockData)
+			Expect(err).ToNot(HaveOccurred())
+			err = repo.Index(eth.CIDPayload{
+				HeaderCID: eth.HeaderModel{
+					BlockHash:       crypto.Keccak256Hash([]byte{00, 05}).String(),
+					BlockNumber:     "100002",
+					CID:             headerCID4.String(),
+					MhKey:           headerMhKey4,
+					ParentHash:      mockCIDPayload3.HeaderCID.BlockHash,
+					TotalDifficulty: totalDifficulty,
+					Reward:          reward,
+				},
+				StateNodeCIDs: []eth.StateNodeModel{stateModels1[0]},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cleaner.Prune(100001, false)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(countRows(db, "eth.state_cids")).To(Equal(2))
+			var count int
+			err = db.Get(&count, `SELECT COUNT(*) FROM public.blocks WHERE key = $1`, state1MhKey1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			err = db.Get(&count, `SELECT COUNT(*) FROM public.blocks WHERE key = $1`, state2MhKey1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(BeZero())
+		})
+		It("Keeps a partition that is not entirely below the bound", func() {
+			pruned, err := cleaner.Prune(99999, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pruned).To(BeEmpty())
+			Expect(countRows(db, "eth.header_cids")).To(Equal(3))
+		})
+		It("Detaches the partitions for archival", func() {
+			pruned, err := cleaner.Prune(100000, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pruned).To(Equal([]uint64{0}))
+
+			Expect(tableExists(db, "eth.header_cids_0")).To(BeFalse())
+			Expect(countRows(db, "eth.header_cids_0_detached")).To(Equal(2))
+			Expect(countRows(db, "eth.state_cids_0_detached")).To(Equal(3))
+			Expect(countRows(db, "eth.header_cids")).To(Equal(1))
+		})
+		It("Removes the latest state written below the bound", func() {
+			insertLatestAccount(db, state1Key.String(), 1)
+			insertLatestAccount(db, state2Key.String(), 100001)
+			_, err := db.Exec(`INSERT INTO eth.latest_storage (state_leaf_key, storage_leaf_key, block_number, header_id, storage_path, cid, mh_key)
+				VALUES ($1, $2, 0, 0, $3, $4, $5)`, state1Key.String(), storageKey.String(), storagePath, storageCID.String(), storageMhKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = cleaner.Prune(100000, true)
+			Expect(err).ToNot(HaveOccurred())
+
+			var keys []string
+			err = db.Select(&keys, `SELECT state_leaf_key FROM eth.latest_accounts`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(keys).To(Equal([]string{state2Key.String()}))
+			Expect(countRows(db, "eth.latest_storage")).To(Equal(0))
+		})
+	})
 	Describe("ResetValidation", func() {
 		BeforeEach(func() {
 			for _, key := range mhKeys {
@@ -696,3 +942,36 @@ var _ = Describe("Cleaner", func() {
 		})
 	})
 })
+
+var _ = table.DescribeTable("splitRange",
+	func(rng [2]uint64, whole []uint64, rest [][2]uint64) {
+		w, r := eth.SplitRange(rng, 100)
+		Expect(w).To(Equal(whole))
+		Expect(r).To(Equal(rest))
+	},
+	table.Entry("within a single partition", [2]uint64{10, 20}, []uint64{}, [][2]uint64{{10, 20}}),
+	table.Entry("covering a single partition", [2]uint64{100, 199}, []uint64{100}, [][2]uint64{}),
+	table.Entry("crossing a partition bound", [2]uint64{150, 249}, []uint64{}, [][2]uint64{{150, 199}, {200, 249}}),
+	table.Entry("covering partitions with leftovers on both ends", [2]uint64{50, 349}, []uint64{100, 200}, [][2]uint64{{50, 99}, {300, 349}}),
+	table.Entry("a single block on a partition bound", [2]uint64{200, 200}, []uint64{}, [][2]uint64{{200, 200}}),
+)
+
+func tableExists(db *postgres.DB, table string) bool {
+	var exists bool
+	err := db.Get(&exists, `SELECT to_regclass($1) IS NOT NULL`, table)
+	Expect(err).ToNot(HaveOccurred())
+	return exists
+}
+
+func countRows(db *postgres.DB, table string) int {
+	var count int
+	err := db.Get(&count, `SELECT COUNT(*) FROM `+table)
+	Expect(err).ToNot(HaveOccurred())
+	return count
+}
+
+func insertLatestAccount(db *postgres.DB, key string, blockNumber uint64) {
+	_, err := db.Exec(`INSERT INTO eth.latest_accounts (state_leaf_key, block_number, header_id, state_id, state_path, balance, nonce, code_hash, storage_root)
+		VALUES ($1, $2, 0, 0, $3, 0, 0, $4, $5)`, key, blockNumber, state1Path, crypto.Keccak256(nil), crypto.Keccak256Hash(nil).String())
+	Expect(err).ToNot(HaveOccurred())
+}
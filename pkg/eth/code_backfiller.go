@@ -0,0 +1,217 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// DefaultCodeBatchSize is the number of code hashes checked, and the max number of codes fetched, in each batch by the CodeBackfiller
+const DefaultCodeBatchSize uint64 = 100
+
+var emptyCodeHash = crypto.Keccak256(nil)
+
+// CodeBackfiller publishes the contract code that is referenced by eth.state_accounts but missing from public.blocks,
+// e.g. for data indexed before code was streamed alongside the statediffs, by fetching it from an ethereum node with debug_accountRange
+type CodeBackfiller struct {
+	db        *postgres.DB
+	client    BatchClient
+	timeout   time.Duration
+	batchSize uint64
+}
+
+// NewCodeBackfiller returns a new CodeBackfiller
+func NewCodeBackfiller(db *postgres.DB, client BatchClient, timeout time.Duration, batchSize uint64) *CodeBackfiller {
+	if batchSize == 0 {
+		batchSize = DefaultCodeBatchSize
+	}
+	return &CodeBackfiller{
+		db:        db,
+		client:    client,
+		timeout:   timeout,
+		batchSize: batchSize,
+	}
+}
+
+// codeLocation is a code hash and the state leaf key of a contract account, and block height, at which code with that hash can be fetched
+type codeLocation struct {
+	CodeHash    []byte `db:"code_hash"`
+	LeafKey     string `db:"state_leaf_key"`
+	BlockNumber uint64 `db:"block_number"`
+	mhKey       string
+}
+
+// Backfill finds every code hash without stored code and publishes the code fetched for it
+// Code hashes that no indexed state account holds are logged and skipped
+func (cb *CodeBackfiller) Backfill() error {
+	var published, unresolved uint64
+	last := []byte{}
+	for {
+		codeHashes := make([][]byte, 0, cb.batchSize)
+		pgStr := `SELECT DISTINCT code_hash FROM eth.state_accounts
+				WHERE code_hash > $1
+				AND code_hash <> $2
+				ORDER BY code_hash
+				LIMIT $3`
+		if err := cb.db.Select(&codeHashes, pgStr, last, emptyCodeHash, cb.batchSize); err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			logrus.Infof("code backfiller finished publishing %d codes, %d codes could not be located", published, unresolved)
+			return nil
+		}
+		last = codeHashes[len(codeHashes)-1]
+		missing, err := cb.missingCode(codeHashes)
+		if err != nil {
+			return err
+		}
+		locations := make([]codeLocation, 0, len(missing))
+		for _, location := range missing {
+			if err := cb.locate(&location); err != nil {
+				if err == sql.ErrNoRows {
+					logrus.Warnf("code backfiller found no state account for code hash %s", common.BytesToHash(location.CodeHash).Hex())
+					unresolved++
+					continue
+				}
+				return err
+			}
+			locations = append(locations, location)
+		}
+		if len(locations) == 0 {
+			continue
+		}
+		codes, err := cb.fetchCode(locations)
+		if err != nil {
+			return err
+		}
+		count, err := cb.publishCode(locations, codes)
+		if err != nil {
+			return err
+		}
+		published += count
+		logrus.Infof("code backfiller published %d codes", published)
+	}
+}
+
+// missingCode returns the code hashes that have no code published under their multihash key
+func (cb *CodeBackfiller) missingCode(codeHashes [][]byte) ([]codeLocation, error) {
+	keys := make([]string, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		mhKey, err := shared.MultihashKeyFromKeccak256(common.BytesToHash(codeHash))
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = mhKey
+	}
+	stored := make([]string, 0, len(keys))
+	if err := cb.db.Select(&stored, `SELECT key FROM public.blocks WHERE key = ANY($1)`, pq.Array(keys)); err != nil {
+		return nil, err
+	}
+	storedKeys := make(map[string]bool, len(stored))
+	for _, key := range stored {
+		storedKeys[key] = true
+	}
+	missing := make([]codeLocation, 0)
+	for i, key := range keys {
+		if !storedKeys[key] {
+			missing = append(missing, codeLocation{CodeHash: codeHashes[i], mhKey: key})
+		}
+	}
+	return missing, nil
+}
+
+// locate finds the state leaf key of a contract account holding the code hash, and the earliest indexed height at which the account holds that code
+// The account is found by its code hash alone, so contracts created by other contracts (e.g. factories) are located as well as those created by a tx
+func (cb *CodeBackfiller) locate(location *codeLocation) error {
+	pgStr := `SELECT state_cids.state_leaf_key, state_cids.block_number
+			FROM eth.state_accounts
+			INNER JOIN eth.state_cids ON (state_accounts.state_id = state_cids.id)
+			WHERE state_accounts.code_hash = $1
+			ORDER BY state_cids.block_number
+			LIMIT 1`
+	return cb.db.QueryRowx(pgStr, location.CodeHash).Scan(&location.LeafKey, &location.BlockNumber)
+}
+
+// fetchCode batch calls debug_accountRange for the account at each of the locations
+// debug_accountRange iterates the state trie from a leaf key, so the code is fetched without knowing the address of the contract
+func (cb *CodeBackfiller) fetchCode(locations []codeLocation) ([]hexutil.Bytes, error) {
+	batch := make([]rpc.BatchElem, len(locations))
+	for i, location := range locations {
+		batch[i] = rpc.BatchElem{
+			Method: "debug_accountRange",
+			// block number, start key, max results, nocode, nostorage, incompletes (include accounts without an address preimage)
+			Args:   []interface{}{hexutil.EncodeBig(new(big.Int).SetUint64(location.BlockNumber)), common.HexToHash(location.LeafKey).Bytes(), 1, false, true, true},
+			Result: new(state.IteratorDump),
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cb.timeout)
+	defer cancel()
+	if err := cb.client.BatchCallContext(ctx, batch); err != nil {
+		return nil, fmt.Errorf("ethereum CodeBackfiller batch err: %s", err.Error())
+	}
+	codes := make([]hexutil.Bytes, len(batch))
+	for i, batchElem := range batch {
+		if batchElem.Error != nil {
+			return nil, fmt.Errorf("ethereum CodeBackfiller err for contract %s at blockheight %d: %s", locations[i].LeafKey, locations[i].BlockNumber, batchElem.Error.Error())
+		}
+		// the range starts at the first account at or after the leaf key, so only the account at the leaf key is taken
+		for _, account := range batchElem.Result.(*state.IteratorDump).Accounts {
+			if common.BytesToHash(account.SecureKey) == common.HexToHash(locations[i].LeafKey) {
+				codes[i] = account.Code
+			}
+		}
+	}
+	return codes, nil
+}
+
+// publishCode publishes each code that hashes to the code hash it was fetched for, and returns the number of codes published
+func (cb *CodeBackfiller) publishCode(locations []codeLocation, codes []hexutil.Bytes) (uint64, error) {
+	tx, err := cb.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	var published uint64
+	for i, location := range locations {
+		if codeHash := crypto.Keccak256(codes[i]); !bytes.Equal(codeHash, location.CodeHash) {
+			logrus.Errorf("code backfiller fetched code with hash %s for contract %s at blockheight %d, expected %s",
+				common.BytesToHash(codeHash).Hex(), location.LeafKey, location.BlockNumber, common.BytesToHash(location.CodeHash).Hex())
+			continue
+		}
+		if err := shared.PublishDirect(tx, location.mhKey, codes[i]); err != nil {
+			shared.Rollback(tx)
+			return 0, err
+		}
+		published++
+	}
+	return published, shared.Commit(tx)
+}
@@ -0,0 +1,97 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth/mocks"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+var _ = Describe("CodeBackfiller", func() {
+	var (
+		db        *postgres.DB
+		err       error
+		client    *mocks.CodeClient
+		codeMhKey string
+		ipfsPgGet = `SELECT data FROM public.blocks
+					WHERE key = $1`
+	)
+	BeforeEach(func() {
+		db, err = shared.SetupDB()
+		Expect(err).ToNot(HaveOccurred())
+		transformer := eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{})
+		_, err = transformer.Transform(1, mocks.MockStateDiffPayload)
+		Expect(err).ToNot(HaveOccurred())
+		// the contract account references the mock code, which is not published
+		_, err = db.Exec(`UPDATE eth.state_accounts SET code_hash = $1 WHERE code_hash = $2`, mocks.MockCodeHash.Bytes(), mocks.ContractCodeHash.Bytes())
+		Expect(err).ToNot(HaveOccurred())
+		codeMhKey, err = shared.MultihashKeyFromKeccak256(mocks.MockCodeHash)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Exec(`DELETE FROM public.blocks WHERE key = $1`, codeMhKey)
+		Expect(err).ToNot(HaveOccurred())
+		client = &mocks.CodeClient{Codes: make(map[common.Hash][]byte)}
+	})
+	AfterEach(func() {
+		eth.TearDownDB(db)
+	})
+
+	It("Publishes the missing code fetched for the contract that holds it", func() {
+		client.Codes[common.BytesToHash(mocks.ContractLeafKey)] = mocks.MockContractByteCode
+		err = eth.NewCodeBackfiller(db, client, time.Second*10, 0).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		var data []byte
+		err = db.Get(&data, ipfsPgGet, codeMhKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(mocks.MockContractByteCode))
+
+		// code that is already stored is not fetched again
+		err = eth.NewCodeBackfiller(db, client, time.Second*10, 0).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.Calls).To(Equal(1))
+	})
+
+	It("Publishes the code of a contract that no indexed receipt created, e.g. one created by a factory", func() {
+		_, err = db.Exec(`UPDATE eth.receipt_cids SET contract = NULL, contract_hash = NULL`)
+		Expect(err).ToNot(HaveOccurred())
+		client.Codes[common.BytesToHash(mocks.ContractLeafKey)] = mocks.MockContractByteCode
+		err = eth.NewCodeBackfiller(db, client, time.Second*10, 0).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		var data []byte
+		err = db.Get(&data, ipfsPgGet, codeMhKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(mocks.MockContractByteCode))
+	})
+
+	It("Does not publish fetched code that does not match the code hash", func() {
+		client.Codes[common.BytesToHash(mocks.ContractLeafKey)] = []byte{1, 2, 3}
+		err = eth.NewCodeBackfiller(db, client, time.Second*10, 0).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		var count int
+		err = db.Get(&count, `SELECT COUNT(*) FROM public.blocks WHERE key = $1`, codeMhKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(0))
+	})
+})
@@ -0,0 +1,87 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// DefaultEventBatchSize is the number of logs decoded in each tx by the EventBackfiller
+const DefaultEventBatchSize uint64 = 1000
+
+// EventBackfiller decodes the logs already indexed in eth.log_cids for the contracts with a registered ABI,
+// e.g. for logs indexed before their contract's ABI was registered or after it was changed
+type EventBackfiller struct {
+	db        *postgres.DB
+	events    *EventDecoder
+	batchSize uint64
+}
+
+// NewEventBackfiller returns a new EventBackfiller
+func NewEventBackfiller(db *postgres.DB, events *EventDecoder, batchSize uint64) *EventBackfiller {
+	if batchSize == 0 {
+		batchSize = DefaultEventBatchSize
+	}
+	return &EventBackfiller{
+		db:        db,
+		events:    events,
+		batchSize: batchSize,
+	}
+}
+
+// Backfill decodes every indexed log of the registered contracts, upserting its decoded event
+// Previously decoded events of logs that no longer match an event of their contract's ABI are deleted
+func (eb *EventBackfiller) Backfill() error {
+	addresses := pq.StringArray(eb.events.Addresses())
+	var last int64
+	var decoded uint64
+	for {
+		logs := make([]LogModel, 0, eb.batchSize)
+		pgStr := `SELECT id, receipt_id, log_index, address, topic0, topic1, topic2, topic3, log_data FROM eth.log_cids
+				WHERE address = ANY($1)
+				AND id > $2
+				ORDER BY id
+				LIMIT $3`
+		if err := eb.db.Select(&logs, pgStr, addresses, last, eb.batchSize); err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			logrus.Infof("event backfiller finished decoding %d logs", decoded)
+			return nil
+		}
+		tx, err := eb.db.Beginx()
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			if err := indexDecodedEvent(tx, eb.events, log, log.ID); err != nil {
+				shared.Rollback(tx)
+				return err
+			}
+		}
+		if err := shared.Commit(tx); err != nil {
+			return err
+		}
+		last = logs[len(logs)-1].ID
+		decoded += uint64(len(logs))
+		logrus.Infof("event backfiller decoded %d logs", decoded)
+	}
+}
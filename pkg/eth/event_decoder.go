@@ -0,0 +1,194 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// EventDecoder decodes the logs emitted by contracts with a registered ABI into their event name and typed arguments
+type EventDecoder struct {
+	abis map[common.Address]*abi.ABI
+}
+
+// abiFile is the form of an ABI file that lists the contract addresses it applies to, e.g. a build artifact with an added addresses field
+type abiFile struct {
+	Addresses []string        `json:"addresses"`
+	ABI       json.RawMessage `json:"abi"`
+}
+
+// NewEventDecoder loads every .json file in the provided directory as a contract ABI
+// A file holding a bare ABI array is registered for the contract address it is named after, e.g. 0x6B17...1d0F.json,
+// a file holding an object is registered for each address in its "addresses" field
+// It returns nil if no directory is provided
+func NewEventDecoder(dir string) (*EventDecoder, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	decoder := &EventDecoder{
+		abis: make(map[common.Address]*abi.ABI),
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.register(strings.TrimSuffix(filepath.Base(path), ".json"), data); err != nil {
+			return nil, fmt.Errorf("error loading abi file %s: %s", path, err.Error())
+		}
+	}
+	return decoder, nil
+}
+
+// register parses the contents of an ABI file and registers it for the addresses it applies to
+func (d *EventDecoder) register(name string, data []byte) error {
+	addresses := []string{name}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		file := new(abiFile)
+		if err := json.Unmarshal(trimmed, file); err != nil {
+			return err
+		}
+		addresses, data = file.Addresses, file.ABI
+	}
+	contractABI, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no contract addresses")
+	}
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid contract address %s", address)
+		}
+		d.abis[common.HexToAddress(address)] = &contractABI
+	}
+	return nil
+}
+
+// Addresses returns the contract addresses with a registered ABI
+func (d *EventDecoder) Addresses() []string {
+	addresses := make([]string, 0, len(d.abis))
+	for address := range d.abis {
+		addresses = append(addresses, address.String())
+	}
+	return addresses
+}
+
+// Decode decodes a log with the ABI registered for its contract
+// It returns nil if there is no ABI registered for the contract, or if the ABI has no event matching the log's first topic
+func (d *EventDecoder) Decode(log LogModel) (*DecodedEventModel, error) {
+	contractABI, ok := d.abis[common.HexToAddress(log.Address)]
+	if !ok || log.Topic0 == "" {
+		return nil, nil
+	}
+	event, err := contractABI.EventByID(common.HexToHash(log.Topic0))
+	if err != nil {
+		return nil, nil
+	}
+	topics := make([]common.Hash, 0, 3)
+	for _, topic := range []string{log.Topic1, log.Topic2, log.Topic3} {
+		if topic != "" {
+			topics = append(topics, common.HexToHash(topic))
+		}
+	}
+	values, err := event.Inputs.NonIndexed().UnpackValues(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking %s log data: %s", event.Sig, err.Error())
+	}
+	args := make(map[string]interface{}, len(event.Inputs))
+	for i, input := range event.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		if !input.Indexed {
+			args[name] = eventArgValue(input.Type, values[0])
+			values = values[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, fmt.Errorf("log is missing a topic for indexed %s argument %s", event.Sig, name)
+		}
+		// indexed strings, bytes, arrays, and tuples are only present as the hash of their encoding
+		indexed := make(map[string]interface{}, 1)
+		if err := abi.ParseTopicsIntoMap(indexed, abi.Arguments{input}, topics[:1]); err != nil {
+			return nil, fmt.Errorf("error parsing %s topic: %s", event.Sig, err.Error())
+		}
+		args[name] = eventArgValue(input.Type, indexed[input.Name])
+		topics = topics[1:]
+	}
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedEventModel{
+		Address:   common.HexToAddress(log.Address).String(),
+		EventName: event.Name,
+		Signature: event.Sig,
+		Args:      string(encodedArgs),
+	}, nil
+}
+
+// eventArgValue converts a decoded argument into a value whose JSON encoding keeps its type
+// integers are encoded as JSON numbers of arbitrary size, addresses, hashes, and byte strings as 0x prefixed hex strings,
+// arrays as JSON arrays, and tuples as JSON objects
+func eventArgValue(t abi.Type, value interface{}) interface{} {
+	switch v := value.(type) {
+	case common.Hash:
+		return v.Hex()
+	case common.Address:
+		return v.String()
+	case []byte:
+		return hexutil.Encode(v)
+	}
+	rv := reflect.ValueOf(value)
+	switch t.T {
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		elems := make([]interface{}, rv.Len())
+		for i := range elems {
+			elems[i] = eventArgValue(*t.Elem, rv.Index(i).Interface())
+		}
+		return elems
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			fields[t.TupleRawNames[i]] = eventArgValue(*elem, rv.Field(i).Interface())
+		}
+		return fields
+	default:
+		return value
+	}
+}
@@ -0,0 +1,117 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("Event decoding", func() {
+	var (
+		dir     string
+		decoder *eth.EventDecoder
+		token   = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+		other   = common.HexToAddress("0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984")
+		from    = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476592")
+		to      = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476593")
+	)
+	tokenABI := `[{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"}]`
+	otherArtifact := `{"addresses":["` + other.Hex() + `"],"abi":[{"anonymous":false,"inputs":[{"indexed":true,"name":"label","type":"string"},{"indexed":false,"name":"","type":"bytes32"},{"indexed":false,"name":"amounts","type":"uint64[]"}],"name":"Labelled","type":"event"}]}`
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "abis")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, token.Hex()+".json"), []byte(tokenABI), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "other.json"), []byte(otherArtifact), 0644)).To(Succeed())
+		decoder, err = eth.NewEventDecoder(dir)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Is disabled without an ABI directory", func() {
+		decoder, err := eth.NewEventDecoder("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoder).To(BeNil())
+	})
+
+	It("Rejects an ABI file that is not named after a contract address", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "token.json"), []byte(tokenABI), 0644)).To(Succeed())
+		_, err := eth.NewEventDecoder(dir)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Decodes indexed and non-indexed arguments", func() {
+		value, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+		event, err := decoder.Decode(eth.LogModel{
+			Address: token.String(),
+			Topic0:  crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")).Hex(),
+			Topic1:  common.BytesToHash(from.Bytes()).Hex(),
+			Topic2:  common.BytesToHash(to.Bytes()).Hex(),
+			Data:    common.LeftPadBytes(value.Bytes(), 32),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.Address).To(Equal(token.String()))
+		Expect(event.EventName).To(Equal("Transfer"))
+		Expect(event.Signature).To(Equal("Transfer(address,address,uint256)"))
+		Expect(event.Args).To(MatchJSON(`{"from":"` + from.String() + `","to":"` + to.String() + `","value":123456789012345678901234567890}`))
+	})
+
+	It("Keeps the topic hash of indexed dynamic arguments and names unnamed arguments by position", func() {
+		label := crypto.Keccak256Hash([]byte("label"))
+		data := append(common.LeftPadBytes([]byte{0xab}, 32), common.LeftPadBytes([]byte{0x40}, 32)...)
+		data = append(data, common.LeftPadBytes([]byte{2}, 32)...)
+		data = append(data, common.LeftPadBytes([]byte{7}, 32)...)
+		data = append(data, common.LeftPadBytes([]byte{9}, 32)...)
+		event, err := decoder.Decode(eth.LogModel{
+			Address: other.String(),
+			Topic0:  crypto.Keccak256Hash([]byte("Labelled(string,bytes32,uint64[])")).Hex(),
+			Topic1:  label.Hex(),
+			Data:    data,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event.EventName).To(Equal("Labelled"))
+		Expect(event.Args).To(MatchJSON(`{"label":"` + label.Hex() + `","arg1":"0x` + common.Bytes2Hex(common.LeftPadBytes([]byte{0xab}, 32)) + `","amounts":[7,9]}`))
+	})
+
+	It("Skips logs of unregistered contracts and unknown events", func() {
+		event, err := decoder.Decode(eth.LogModel{
+			Address: from.String(),
+			Topic0:  crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")).Hex(),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event).To(BeNil())
+		event, err = decoder.Decode(eth.LogModel{
+			Address: token.String(),
+			Topic0:  crypto.Keccak256Hash([]byte("Approval(address,address,uint256)")).Hex(),
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(event).To(BeNil())
+	})
+})
@@ -17,10 +17,19 @@
 package eth
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
 )
 
 func ResolveFromNodeType(nodeType sdtypes.NodeType) int {
@@ -38,6 +47,126 @@ func ResolveFromNodeType(nodeType sdtypes.NodeType) int {
 	}
 }
 
+// LogModels returns the db models for the provided logs of a receipt
+// the logs are numbered by their index in the block, starting at firstIndex
+func LogModels(logs []*types.Log, firstIndex int64) []LogModel {
+	models := make([]LogModel, len(logs))
+	for i, log := range logs {
+		topics := make([]string, 4)
+		for j, topic := range log.Topics {
+			if j < len(topics) {
+				topics[j] = topic.Hex()
+			}
+		}
+		models[i] = LogModel{
+			Index:   firstIndex + int64(i),
+			Address: log.Address.String(),
+			Topic0:  topics[0],
+			Topic1:  topics[1],
+			Topic2:  topics[2],
+			Topic3:  topics[3],
+			Data:    log.Data,
+		}
+	}
+	return models
+}
+
+// setHeaderMetaData sets the fields decoded from the header on its db model
+func setHeaderMetaData(model *HeaderModel, header *types.Header) {
+	model.Coinbase = header.Coinbase.String()
+	model.Difficulty = header.Difficulty.String()
+	model.GasLimit = header.GasLimit
+	model.GasUsed = header.GasUsed
+	model.ExtraData = header.Extra
+	model.MixDigest = header.MixDigest.String()
+	model.Nonce = strconv.FormatUint(header.Nonce.Uint64(), 10)
+	if header.BaseFee != nil {
+		model.BaseFee = header.BaseFee.String()
+	}
+	model.BurntFees = CalcBurntFees(header).String()
+}
+
+// setUncleMetaData sets the fields decoded from the uncle header on its db model
+func setUncleMetaData(model *UncleModel, header *types.Header) {
+	model.Coinbase = header.Coinbase.String()
+	model.Difficulty = header.Difficulty.String()
+	model.GasLimit = header.GasLimit
+	model.GasUsed = header.GasUsed
+	model.ExtraData = header.Extra
+	model.MixDigest = header.MixDigest.String()
+	model.Nonce = strconv.FormatUint(header.Nonce.Uint64(), 10)
+}
+
+// setTxMetaData sets the fields decoded from the transaction on its db model
+func setTxMetaData(model *TxModel, trx *types.Transaction) {
+	model.Gas = trx.Gas()
+	model.GasPrice = trx.GasPrice().String()
+	model.Value = trx.Value().String()
+	model.Nonce = trx.Nonce()
+	model.Type = int64(trx.Type())
+	accessList := trx.AccessList()
+	model.AccessList = make([]AccessListElementModel, len(accessList))
+	for i, tuple := range accessList {
+		storageKeys := make([]string, len(tuple.StorageKeys))
+		for j, key := range tuple.StorageKeys {
+			storageKeys[j] = key.Hex()
+		}
+		model.AccessList[i] = AccessListElementModel{
+			Index:       int64(i),
+			Address:     tuple.Address.String(),
+			StorageKeys: storageKeys,
+		}
+	}
+}
+
+// setTxFees sets the fee caps of the transaction, and the price and fee it paid given the base fee of its block and the gas it used
+func setTxFees(model *TxModel, trx *types.Transaction, baseFee *big.Int, gasUsed uint64) {
+	model.MaxFeePerGas = trx.GasFeeCap().String()
+	model.MaxPriorityFeePerGas = trx.GasTipCap().String()
+	model.EffectiveGasPrice = CalcEffectiveGasPrice(trx, baseFee).String()
+	model.PriorityFee = CalcPriorityFee(trx, baseFee, gasUsed).String()
+}
+
+// setContractCode sets the code hash of the contract, and the multihash key its code is published under, on its db model
+func setContractCode(model *ContractModel, codeHash []byte) error {
+	mhKey, err := shared.MultihashKeyFromKeccak256(common.BytesToHash(codeHash))
+	if err != nil {
+		return err
+	}
+	model.CodeHash = codeHash
+	model.CodeMhKey = mhKey
+	return nil
+}
+
+// setReceiptMetaData sets the fields decoded from the receipt on its db model
+// gas used is not part of the consensus encoding of a receipt, so it is derived from the cumulative gas used of the previous receipt in the block
+func setReceiptMetaData(model *ReceiptModel, receipt *types.Receipt, prevCumulativeGasUsed uint64) {
+	model.GasUsed = receipt.CumulativeGasUsed - prevCumulativeGasUsed
+	model.CumulativeGasUsed = receipt.CumulativeGasUsed
+	model.LogBloom = receipt.Bloom.Bytes()
+}
+
+// storageLeafValue decodes the value of the storage slot held by a storage leaf node, left padded to 32 bytes
+// the leaf holds the rlp encoding of the slot value with its leading zeros trimmed
+func storageLeafValue(leafNode []byte) ([]byte, error) {
+	var i []interface{}
+	if err := rlp.DecodeBytes(leafNode, &i); err != nil {
+		return nil, err
+	}
+	if len(i) != 2 {
+		return nil, fmt.Errorf("eth expected storage leaf node rlp to decode into two elements")
+	}
+	encoded, ok := i[1].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("eth expected storage leaf node value to be a byte string")
+	}
+	var value []byte
+	if err := rlp.DecodeBytes(encoded, &value); err != nil {
+		return nil, err
+	}
+	return common.LeftPadBytes(value, common.HashLength), nil
+}
+
 // ChainConfig returns the appropriate ethereum chain config for the provided chain id
 func ChainConfig(chainID uint64) (*params.ChainConfig, error) {
 	switch chainID {
@@ -53,3 +182,36 @@ func ChainConfig(chainID uint64) (*params.ChainConfig, error) {
 		return nil, fmt.Errorf("chain config for chainid %d not available", chainID)
 	}
 }
+
+// LoadChainConfig returns the chain config read from the genesis or chain config .json file at path,
+// for chains such as private Clique networks that ChainConfig does not know, or else ChainConfig(chainID)
+func LoadChainConfig(chainID uint64, path string) (*params.ChainConfig, error) {
+	if path == "" {
+		return ChainConfig(chainID)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// a genesis file nests the chain config under "config"
+	var genesis struct {
+		Config *params.ChainConfig `json:"config"`
+	}
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, fmt.Errorf("chain config file %s: %v", path, err)
+	}
+	config := genesis.Config
+	if config == nil {
+		config = new(params.ChainConfig)
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("chain config file %s: %v", path, err)
+		}
+	}
+	if config.ChainID == nil {
+		return nil, fmt.Errorf("chain config file %s has no chainId", path)
+	}
+	if !config.ChainID.IsUint64() || config.ChainID.Uint64() != chainID {
+		return nil, fmt.Errorf("chain config file %s is for chainid %s, not %d", path, config.ChainID, chainID)
+	}
+	return config, nil
+}
@@ -17,7 +17,13 @@
 package eth
 
 import (
+	"database/sql"
+	"strconv"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/jmoiron/sqlx"
 	log "github.com/sirupsen/logrus"
 
@@ -28,6 +34,12 @@ import (
 
 var (
 	nullHash = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000000")
+	// an account that does not exist has no balance, nonce, code, or storage
+	emptyAccount = StateAccountModel{
+		Balance:     "0",
+		CodeHash:    crypto.Keccak256(nil),
+		StorageRoot: types.EmptyRootHash.String(),
+	}
 )
 
 // Indexer interface to allow substituting mocks in tests
@@ -38,17 +50,39 @@ type Indexer interface {
 // Indexer satisfies the Indexer interface for ethereum
 type CIDIndexer struct {
 	db *postgres.DB
+
+	// lower bounds of the block partitions known to exist
+	partitionLock  sync.Mutex
+	partitionWidth uint64
+	partitions     map[uint64]struct{}
+
+	// keep eth.latest_accounts and eth.latest_storage up to date
+	latestState bool
+	// decode the logs of contracts with a registered ABI into eth.decoded_events (optional)
+	events *EventDecoder
+	// extract the standard token transfer events into eth.token_transfers
+	tokenTransfers bool
+	// decode the storage of contracts with a registered storage layout into eth.decoded_storage (optional)
+	storage *StorageDecoder
 }
 
 // NewCIDIndexer creates a new pointer to a Indexer which satisfies the CIDIndexer interface
 func NewCIDIndexer(db *postgres.DB) *CIDIndexer {
 	return &CIDIndexer{
-		db: db,
+		db:         db,
+		partitions: make(map[uint64]struct{}),
 	}
 }
 
 // Index indexes a cidPayload in Postgres
 func (in *CIDIndexer) Index(cids CIDPayload) error {
+	blockNumber, err := strconv.ParseUint(cids.HeaderCID.BlockNumber, 10, 64)
+	if err != nil {
+		return err
+	}
+	if err := in.ensurePartitions(blockNumber); err != nil {
+		return err
+	}
 	// Begin new db tx
 	tx, err := in.db.Beginx()
 	if err != nil {
@@ -61,7 +95,7 @@ func (in *CIDIndexer) Index(cids CIDPayload) error {
 		} else if err != nil {
 			shared.Rollback(tx)
 		} else {
-			err = tx.Commit()
+			err = shared.Commit(tx)
 		}
 	}()
 
@@ -80,48 +114,234 @@ func (in *CIDIndexer) Index(cids CIDPayload) error {
 		log.Error("eth indexer error when indexing transactions and receipts")
 		return err
 	}
-	err = in.indexStateAndStorageCIDs(tx, cids, headerID)
-	if err != nil {
+	if err := in.indexStateAndStorageCIDs(tx, cids, headerID); err != nil {
 		log.Error("eth indexer error when indexing state and storage nodes")
+		return err
+	}
+	if err := in.indexBlockStats(tx, headerID, cids.HeaderCID.BlockNumber); err != nil {
+		log.Error("eth indexer error when indexing block stats")
+		return err
+	}
+	err = in.indexCanonicalState(tx, cids.HeaderCID.BlockNumber, cids.HeaderCID.BlockHash)
+	if err != nil {
+		log.Error("eth indexer error when updating canonical state")
 	}
 	return err
 }
 
+// ensurePartitions creates the header, state, and storage partitions that hold the provided block number if they do not exist yet
+// This is done outside of the block's db tx, since creating a partition locks its parent table
+func (in *CIDIndexer) ensurePartitions(blockNumber uint64) error {
+	in.partitionLock.Lock()
+	defer in.partitionLock.Unlock()
+	if in.partitionWidth == 0 {
+		if err := in.db.Get(&in.partitionWidth, `SELECT eth.block_partition_width()`); err != nil {
+			return err
+		}
+	}
+	lower := blockNumber - blockNumber%in.partitionWidth
+	if _, ok := in.partitions[lower]; ok {
+		return nil
+	}
+	if _, err := in.db.Exec(`SELECT eth.create_block_partitions($1)`, blockNumber); err != nil {
+		return err
+	}
+	in.partitions[lower] = struct{}{}
+	return nil
+}
+
+// forgetPartitions drops the partitions holding the provided block number from the set known to exist
+// so that they are recreated on the next write if they have since been pruned
+func (in *CIDIndexer) forgetPartitions(blockNumber uint64) {
+	in.partitionLock.Lock()
+	defer in.partitionLock.Unlock()
+	if in.partitionWidth != 0 {
+		delete(in.partitions, blockNumber-blockNumber%in.partitionWidth)
+	}
+}
+
 func (in *CIDIndexer) indexHeaderCID(tx *sqlx.Tx, header HeaderModel) (int64, error) {
 	var headerID int64
-	err := tx.QueryRowx(`INSERT INTO eth.header_cids (block_number, block_hash, parent_hash, cid, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, mh_key, times_validated)
-								VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-								ON CONFLICT (block_number, block_hash) DO UPDATE SET (parent_hash, cid, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, mh_key, times_validated) = ($3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, eth.header_cids.times_validated + 1)
+	err := tx.QueryRowx(`INSERT INTO eth.header_cids (block_number, block_hash, parent_hash, cid, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, mh_key, times_validated,
+								coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce, base_fee, burnt_fees, producer)
+								VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
+								ON CONFLICT (block_number, block_hash) DO UPDATE SET (parent_hash, cid, td, node_id, reward, state_root, tx_root, receipt_root, uncle_root, bloom, timestamp, mh_key, times_validated,
+								coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce, base_fee, burnt_fees, producer) = ($3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, eth.header_cids.times_validated + 1,
+								$16, $17, $18, $19, $20, $21, $22, $23, $24, $25)
 								RETURNING id`,
 		header.BlockNumber, header.BlockHash, header.ParentHash, header.CID, header.TotalDifficulty, in.db.NodeID, header.Reward, header.StateRoot, header.TxRoot,
-		header.RctRoot, header.UncleRoot, header.Bloom, header.Timestamp, header.MhKey, 1).Scan(&headerID)
+		header.RctRoot, header.UncleRoot, header.Bloom, header.Timestamp, header.MhKey, 1,
+		header.Coinbase, nullNumeric(header.Difficulty), header.GasLimit, header.GasUsed, header.ExtraData, header.MixDigest, nullNumeric(header.Nonce),
+		nullNumeric(header.BaseFee), nullNumeric(header.BurntFees), nullString(header.Producer)).Scan(&headerID)
+	if err != nil {
+		return 0, err
+	}
+	prom.BlockInc()
+	return headerID, nil
+}
+
+// indexBlockStats upserts the per-block stats of the header with the provided id from the rows indexed beneath it
+// it is called once the header's transactions, receipts, uncles, state, and storage nodes are indexed, and before its canonical state is updated,
+// so that the stats are added to the hourly and daily rollups as soon as the header becomes canonical
+func (in *CIDIndexer) indexBlockStats(tx *sqlx.Tx, headerID int64, blockNumber string) error {
+	_, err := tx.Exec(`SELECT eth.index_block_stats($1, $2)`, headerID, blockNumber)
+	return err
+}
+
+// indexCanonicalState updates the canonical flags around the header at the provided number and hash
+// and, if they are maintained, updates the latest state tables
+// when only headers that were not canonical before were flagged, their own diffs are applied to the tables,
+// when the flag was taken from another header, the tables are refreshed from the lowest height whose canonical header changed
+// it is called once the header's state and storage nodes are indexed
+func (in *CIDIndexer) indexCanonicalState(tx *sqlx.Tx, blockNumber, blockHash string) error {
+	canonical, fromHeight, reorged, err := in.updateCanonical(tx, blockNumber, blockHash)
+	if err != nil || !canonical || !in.latestState {
+		return err
+	}
+	if reorged {
+		_, err = tx.Exec(`SELECT eth.refresh_latest_state($1)`, fromHeight)
+		return err
+	}
+	pgStr := `SELECT eth.apply_latest_state(id, block_number) FROM eth.header_cids
+			WHERE canonical AND block_number BETWEEN $1 AND $2
+			ORDER BY block_number`
+	_, err = tx.Exec(pgStr, fromHeight, blockNumber)
+	return err
+}
+
+// updateCanonical flags the indexed header at the provided number and hash as canonical if its child is canonical,
+// if it has more total difficulty than the canonical head, or if it fills a gap in the canonical chain
+// when it becomes canonical the flag is cleared from the other headers at its height, from any headers above it if it is the new head,
+// and moved down onto its ancestors until the chain joins the canonical one again
+// concurrent writers may each flag a header in the same gap, the flags converge as the headers around them are indexed
+// it returns whether the header is canonical, and if so the lowest height whose canonical header it changed (or its own height)
+// and whether the flag was cleared from any other header on the way
+func (in *CIDIndexer) updateCanonical(tx *sqlx.Tx, blockNumber, blockHash string) (bool, uint64, bool, error) {
+	canonicality := new(CanonicalityModel)
+	pgStr := `SELECT header_cids.canonical,
+				EXISTS (SELECT 1 FROM eth.header_cids child WHERE child.block_number = header_cids.block_number + 1
+					AND child.parent_hash = header_cids.block_hash AND child.canonical) AS child_canonical,
+				COALESCE(header_cids.td > (SELECT head.td FROM eth.header_cids head WHERE head.canonical
+					ORDER BY head.block_number DESC LIMIT 1), TRUE) AS heaviest,
+				NOT EXISTS (SELECT 1 FROM eth.header_cids other WHERE other.block_number IN (header_cids.block_number, header_cids.block_number + 1)
+					AND other.canonical) AS fills_gap
+			FROM eth.header_cids
+			WHERE block_number = $1 AND block_hash = $2`
+	if err := tx.Get(canonicality, pgStr, blockNumber, blockHash); err != nil {
+		return false, 0, false, err
+	}
+	height, err := strconv.ParseUint(blockNumber, 10, 64)
+	if err != nil {
+		return false, 0, false, err
+	}
+	if canonicality.Canonical {
+		return true, height, false, nil
+	}
+	if !(canonicality.ChildCanonical || canonicality.Heaviest || canonicality.FillsGap) {
+		return false, 0, false, nil
+	}
+	var reorged bool
+	if canonicality.Heaviest {
+		res, err := tx.Exec(`UPDATE eth.header_cids SET canonical = FALSE WHERE canonical AND block_number > $1`, height)
+		if err != nil {
+			return false, 0, false, err
+		}
+		cleared, err := res.RowsAffected()
+		if err != nil {
+			return false, 0, false, err
+		}
+		reorged = cleared > 0
+	}
+	for {
+		res, err := tx.Exec(`UPDATE eth.header_cids SET canonical = (block_hash = $2) WHERE block_number = $1 AND (canonical OR block_hash = $2)`,
+			height, blockHash)
+		if err != nil {
+			return false, 0, false, err
+		}
+		// any row besides the header itself was a canonical header losing its flag
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return false, 0, false, err
+		}
+		reorged = reorged || updated > 1
+		parent := new(HeaderModel)
+		pgStr = `SELECT parent.block_hash, parent.canonical FROM eth.header_cids child
+				INNER JOIN eth.header_cids parent ON (parent.block_number = child.block_number - 1 AND parent.block_hash = child.parent_hash)
+				WHERE child.block_number = $1 AND child.block_hash = $2`
+		err = tx.Get(parent, pgStr, height, blockHash)
+		if err == sql.ErrNoRows || (err == nil && parent.Canonical) {
+			return true, height, reorged, nil
+		}
+		if err != nil {
+			return false, 0, false, err
+		}
+		height--
+		blockHash = parent.BlockHash
+	}
+}
+
+// retrieveBlockSummary returns the roots and row counts, including those of the derived tables, for the header at the provided number and hash
+func (in *CIDIndexer) retrieveBlockSummary(tx *sqlx.Tx, blockNumber, blockHash string) (*BlockSummaryModel, error) {
+	summary := new(BlockSummaryModel)
+	pgStr := `SELECT header_cids.id, state_root, tx_root, receipt_root, uncle_root,
+				(SELECT COUNT(*) FROM eth.uncle_cids WHERE uncle_cids.header_id = header_cids.id) AS uncle_count,
+				(SELECT COUNT(*) FROM eth.transaction_cids WHERE transaction_cids.header_id = header_cids.id) AS tx_count,
+				(SELECT COUNT(*) FROM eth.receipt_cids INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS rct_count,
+				(SELECT COUNT(*) FROM eth.log_cids INNER JOIN eth.receipt_cids ON (log_cids.receipt_id = receipt_cids.id)
+					INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS log_count,
+				(SELECT COUNT(*) FROM eth.state_cids WHERE state_cids.header_id = header_cids.id) +
+					(SELECT COUNT(*) FROM eth.state_removals WHERE state_removals.header_id = header_cids.id) AS state_count,
+				(SELECT COUNT(*) FROM eth.storage_cids INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id)
+					WHERE state_cids.header_id = header_cids.id) +
+					(SELECT COUNT(*) FROM eth.storage_removals WHERE storage_removals.header_id = header_cids.id) AS storage_count,
+				(SELECT COUNT(*) FROM eth.access_list_elements INNER JOIN eth.transaction_cids ON (access_list_elements.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS access_list_count,
+				(SELECT COUNT(*) FROM eth.address_transactions INNER JOIN eth.transaction_cids ON (address_transactions.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS address_tx_count,
+				(SELECT COUNT(*) FROM eth.contracts INNER JOIN eth.transaction_cids ON (contracts.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS contract_count,
+				(SELECT COUNT(*) FROM eth.account_changes WHERE account_changes.header_id = header_cids.id) AS account_change_count,
+				(SELECT COUNT(*) FROM eth.token_transfers INNER JOIN eth.transaction_cids ON (token_transfers.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS token_transfer_count,
+				(SELECT COUNT(*) FROM eth.decoded_events INNER JOIN eth.log_cids ON (decoded_events.log_id = log_cids.id)
+					INNER JOIN eth.receipt_cids ON (log_cids.receipt_id = receipt_cids.id)
+					INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+					WHERE transaction_cids.header_id = header_cids.id) AS decoded_event_count,
+				(SELECT COUNT(*) FROM eth.block_stats WHERE block_stats.header_id = header_cids.id) AS stats_count
+			FROM eth.header_cids
+			WHERE block_number = $1 AND block_hash = $2`
+	return summary, tx.Get(summary, pgStr, blockNumber, blockHash)
+}
+
+// incrementValidation increments the times_validated of the header with the provided id
+func (in *CIDIndexer) incrementValidation(tx *sqlx.Tx, headerID int64) error {
+	_, err := tx.Exec(`UPDATE eth.header_cids SET times_validated = times_validated + 1 WHERE id = $1`, headerID)
 	if err == nil {
 		prom.BlockInc()
 	}
-	return headerID, err
+	return err
 }
 
 func (in *CIDIndexer) indexUncleCID(tx *sqlx.Tx, uncle UncleModel, headerID int64) error {
-	_, err := tx.Exec(`INSERT INTO eth.uncle_cids (block_hash, header_id, parent_hash, cid, reward, mh_key) VALUES ($1, $2, $3, $4, $5, $6)
-								ON CONFLICT (header_id, block_hash) DO UPDATE SET (parent_hash, cid, reward, mh_key) = ($3, $4, $5, $6)`,
-		uncle.BlockHash, headerID, uncle.ParentHash, uncle.CID, uncle.Reward, uncle.MhKey)
+	_, err := tx.Exec(`INSERT INTO eth.uncle_cids (block_hash, header_id, parent_hash, cid, reward, mh_key, coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce)
+								VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+								ON CONFLICT (header_id, block_hash) DO UPDATE SET (parent_hash, cid, reward, mh_key, coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce) = ($3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		uncle.BlockHash, headerID, uncle.ParentHash, uncle.CID, uncle.Reward, uncle.MhKey,
+		uncle.Coinbase, nullNumeric(uncle.Difficulty), uncle.GasLimit, uncle.GasUsed, uncle.ExtraData, uncle.MixDigest, nullNumeric(uncle.Nonce))
 	return err
 }
 
 func (in *CIDIndexer) indexTransactionAndReceiptCIDs(tx *sqlx.Tx, payload CIDPayload, headerID int64) error {
 	for _, trxCidMeta := range payload.TransactionCIDs {
-		var txID int64
-		err := tx.QueryRowx(`INSERT INTO eth.transaction_cids (header_id, tx_hash, cid, dst, src, index, mh_key, tx_data) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-									ON CONFLICT (header_id, tx_hash) DO UPDATE SET (cid, dst, src, index, mh_key, tx_data) = ($3, $4, $5, $6, $7, $8)
-									RETURNING id`,
-			headerID, trxCidMeta.TxHash, trxCidMeta.CID, trxCidMeta.Dst, trxCidMeta.Src, trxCidMeta.Index, trxCidMeta.MhKey, trxCidMeta.Data).Scan(&txID)
+		txID, err := in.indexTransactionCID(tx, trxCidMeta, headerID)
 		if err != nil {
 			return err
 		}
-		prom.TransactionInc()
 		receiptCidMeta, ok := payload.ReceiptCIDs[common.HexToHash(trxCidMeta.TxHash)]
 		if ok {
-			if err := in.indexReceiptCID(tx, receiptCidMeta, txID); err != nil {
+			if err := in.indexReceiptCID(tx, receiptCidMeta, txID, payload.HeaderCID.BlockNumber); err != nil {
 				return err
 			}
 		}
@@ -131,23 +351,151 @@ func (in *CIDIndexer) indexTransactionAndReceiptCIDs(tx *sqlx.Tx, payload CIDPay
 
 func (in *CIDIndexer) indexTransactionCID(tx *sqlx.Tx, transaction TxModel, headerID int64) (int64, error) {
 	var txID int64
-	err := tx.QueryRowx(`INSERT INTO eth.transaction_cids (header_id, tx_hash, cid, dst, src, index, mh_key, tx_data) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-									ON CONFLICT (header_id, tx_hash) DO UPDATE SET (cid, dst, src, index, mh_key, tx_data) = ($3, $4, $5, $6, $7, $8)
+	err := tx.QueryRowx(`INSERT INTO eth.transaction_cids (header_id, tx_hash, cid, dst, src, index, mh_key, tx_data, gas, gas_price, value, nonce, tx_type,
+									max_fee_per_gas, max_priority_fee_per_gas, effective_gas_price, priority_fee)
+									VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+									ON CONFLICT (header_id, tx_hash) DO UPDATE SET (cid, dst, src, index, mh_key, tx_data, gas, gas_price, value, nonce, tx_type,
+									max_fee_per_gas, max_priority_fee_per_gas, effective_gas_price, priority_fee) = ($3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 									RETURNING id`,
-		headerID, transaction.TxHash, transaction.CID, transaction.Dst, transaction.Src, transaction.Index, transaction.MhKey, transaction.Data).Scan(&txID)
-	if err == nil {
-		prom.TransactionInc()
+		headerID, transaction.TxHash, transaction.CID, transaction.Dst, transaction.Src, transaction.Index, transaction.MhKey, transaction.Data,
+		transaction.Gas, nullNumeric(transaction.GasPrice), nullNumeric(transaction.Value), transaction.Nonce, transaction.Type,
+		nullNumeric(transaction.MaxFeePerGas), nullNumeric(transaction.MaxPriorityFeePerGas), nullNumeric(transaction.EffectiveGasPrice), nullNumeric(transaction.PriorityFee)).Scan(&txID)
+	if err != nil {
+		return 0, err
+	}
+	prom.TransactionInc()
+	for _, element := range transaction.AccessList {
+		if err := in.indexAccessListElement(tx, element, txID); err != nil {
+			return 0, err
+		}
+	}
+	if err := in.indexAddressTransaction(tx, transaction.Src, txID, SenderRole); err != nil {
+		return 0, err
 	}
-	return txID, err
+	if err := in.indexAddressTransaction(tx, transaction.Dst, txID, RecipientRole); err != nil {
+		return 0, err
+	}
+	return txID, nil
 }
 
-func (in *CIDIndexer) indexReceiptCID(tx *sqlx.Tx, rct ReceiptModel, txID int64) error {
-	_, err := tx.Exec(`INSERT INTO eth.receipt_cids (tx_id, cid, contract, contract_hash, topic0s, topic1s, topic2s, topic3s, log_contracts, mh_key, post_state, post_status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-							  ON CONFLICT (tx_id) DO UPDATE SET (cid, contract, contract_hash, topic0s, topic1s, topic2s, topic3s, log_contracts, mh_key, post_state, post_status) = ($2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
-		txID, rct.CID, rct.Contract, rct.ContractHash, rct.Topic0s, rct.Topic1s, rct.Topic2s, rct.Topic3s, rct.LogContracts, rct.MhKey, rct.PostState, rct.PostStatus)
-	if err == nil {
-		prom.ReceiptInc()
+// indexAddressTransaction links the address to the transaction in the provided role, unless the address is empty
+func (in *CIDIndexer) indexAddressTransaction(tx *sqlx.Tx, address string, txID int64, role AddressRole) error {
+	if address == "" {
+		return nil
+	}
+	_, err := tx.Exec(`INSERT INTO eth.address_transactions (address, tx_id, role) VALUES ($1, $2, $3)
+							  ON CONFLICT (address, tx_id, role) DO NOTHING`,
+		address, txID, role)
+	return err
+}
+
+func (in *CIDIndexer) indexAccessListElement(tx *sqlx.Tx, element AccessListElementModel, txID int64) error {
+	_, err := tx.Exec(`INSERT INTO eth.access_list_elements (tx_id, index, address, storage_keys) VALUES ($1, $2, $3, $4)
+							  ON CONFLICT (tx_id, index) DO UPDATE SET (address, storage_keys) = ($3, $4)`,
+		txID, element.Index, element.Address, element.StorageKeys)
+	return err
+}
+
+func (in *CIDIndexer) indexContract(tx *sqlx.Tx, contract ContractModel) error {
+	var codeMhKey interface{}
+	if contract.CodeMhKey != "" {
+		codeMhKey = contract.CodeMhKey
 	}
+	_, err := tx.Exec(`INSERT INTO eth.contracts (tx_id, block_number, address, creator, code_hash, code_mh_key) VALUES ($1, $2, $3, $4, $5, $6)
+							  ON CONFLICT (tx_id, address) DO UPDATE SET (block_number, creator, code_hash, code_mh_key) = ($2, $4, $5, $6)`,
+		contract.TxID, contract.BlockNumber, contract.Address, contract.Creator, contract.CodeHash, codeMhKey)
+	return err
+}
+
+// nullNumeric returns nil for an empty numeric string, so that it is written as NULL instead of failing to parse
+func nullNumeric(num string) interface{} {
+	if num == "" {
+		return nil
+	}
+	return num
+}
+
+// nullString returns nil for an empty string, so that it is written as NULL
+func nullString(str string) interface{} {
+	if str == "" {
+		return nil
+	}
+	return str
+}
+
+func (in *CIDIndexer) indexReceiptCID(tx *sqlx.Tx, rct ReceiptModel, txID int64, blockNumber string) error {
+	var rctID int64
+	err := tx.QueryRowx(`INSERT INTO eth.receipt_cids (tx_id, cid, contract, contract_hash, topic0s, topic1s, topic2s, topic3s, log_contracts, mh_key, post_state, post_status, gas_used, cumulative_gas_used, log_bloom) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+							  ON CONFLICT (tx_id) DO UPDATE SET (cid, contract, contract_hash, topic0s, topic1s, topic2s, topic3s, log_contracts, mh_key, post_state, post_status, gas_used, cumulative_gas_used, log_bloom) = ($2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+							  RETURNING id`,
+		txID, rct.CID, rct.Contract, rct.ContractHash, rct.Topic0s, rct.Topic1s, rct.Topic2s, rct.Topic3s, rct.LogContracts, rct.MhKey, rct.PostState, rct.PostStatus,
+		rct.GasUsed, rct.CumulativeGasUsed, rct.LogBloom).Scan(&rctID)
+	if err != nil {
+		return err
+	}
+	prom.ReceiptInc()
+	for _, log := range rct.Logs {
+		if err := in.indexLogCID(tx, log, rctID, txID, blockNumber); err != nil {
+			return err
+		}
+	}
+	if err := in.indexAddressTransaction(tx, rct.Contract, txID, ContractRole); err != nil {
+		return err
+	}
+	for _, address := range rct.LogContracts {
+		if err := in.indexAddressTransaction(tx, address, txID, LogEmitterRole); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *CIDIndexer) indexLogCID(tx *sqlx.Tx, log LogModel, rctID, txID int64, blockNumber string) error {
+	var logID int64
+	err := tx.QueryRowx(`INSERT INTO eth.log_cids (receipt_id, log_index, address, topic0, topic1, topic2, topic3, log_data) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+							  ON CONFLICT (receipt_id, log_index) DO UPDATE SET (address, topic0, topic1, topic2, topic3, log_data) = ($3, $4, $5, $6, $7, $8)
+							  RETURNING id`,
+		rctID, log.Index, log.Address, log.Topic0, log.Topic1, log.Topic2, log.Topic3, log.Data).Scan(&logID)
+	if err != nil {
+		return err
+	}
+	if in.tokenTransfers {
+		for _, transfer := range TokenTransferModels(log) {
+			transfer.TxID, transfer.BlockNumber = txID, blockNumber
+			if err := indexTokenTransfer(tx, transfer, logID); err != nil {
+				return err
+			}
+		}
+	}
+	if in.events == nil {
+		return nil
+	}
+	return indexDecodedEvent(tx, in.events, log, logID)
+}
+
+func indexTokenTransfer(tx *sqlx.Tx, transfer TokenTransferModel, logID int64) error {
+	_, err := tx.Exec(`INSERT INTO eth.token_transfers (log_id, batch_index, tx_id, block_number, log_index, token, standard, operator, src, dst, token_id, amount) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+							  ON CONFLICT (log_id, batch_index) DO UPDATE SET (tx_id, block_number, log_index, token, standard, operator, src, dst, token_id, amount) = ($3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		logID, transfer.BatchIndex, transfer.TxID, transfer.BlockNumber, transfer.LogIndex, transfer.Token, transfer.Standard,
+		transfer.Operator, transfer.Src, transfer.Dst, nullNumeric(transfer.TokenID), nullNumeric(transfer.Amount))
+	return err
+}
+
+// indexDecodedEvent decodes a log with the ABI registered for its contract and upserts the decoded event,
+// or deletes a previously decoded event if the log no longer matches any registered event
+// A log that matches an event but cannot be decoded with it is logged and left undecoded, rather than failing the block
+func indexDecodedEvent(tx *sqlx.Tx, events *EventDecoder, logModel LogModel, logID int64) error {
+	event, err := events.Decode(logModel)
+	if err != nil {
+		log.Warnf("eth indexer unable to decode log %d of contract %s: %s", logID, logModel.Address, err.Error())
+	}
+	if event == nil {
+		_, err = tx.Exec(`DELETE FROM eth.decoded_events WHERE log_id = $1`, logID)
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO eth.decoded_events (log_id, address, event_name, signature, args) VALUES ($1, $2, $3, $4, $5)
+							  ON CONFLICT (log_id) DO UPDATE SET (address, event_name, signature, args) = ($2, $3, $4, $5)`,
+		logID, event.Address, event.EventName, event.Signature, event.Args)
 	return err
 }
 
@@ -158,10 +506,10 @@ func (in *CIDIndexer) indexStateAndStorageCIDs(tx *sqlx.Tx, payload CIDPayload,
 		if stateCID.StateKey != nullHash.String() {
 			stateKey = stateCID.StateKey
 		}
-		err := tx.QueryRowx(`INSERT INTO eth.state_cids (header_id, state_leaf_key, cid, state_path, node_type, diff, mh_key) VALUES ($1, $2, $3, $4, $5, $6, $7)
-									ON CONFLICT (header_id, state_path) DO UPDATE SET (state_leaf_key, cid, node_type, diff, mh_key) = ($2, $3, $5, $6, $7)
+		err := tx.QueryRowx(`INSERT INTO eth.state_cids (header_id, state_leaf_key, cid, state_path, node_type, diff, mh_key, block_number) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+									ON CONFLICT (header_id, state_path, block_number) DO UPDATE SET (state_leaf_key, cid, node_type, diff, mh_key) = ($2, $3, $5, $6, $7)
 									RETURNING id`,
-			headerID, stateKey, stateCID.CID, stateCID.Path, stateCID.NodeType, true, stateCID.MhKey).Scan(&stateID)
+			headerID, stateKey, stateCID.CID, stateCID.Path, stateCID.NodeType, true, stateCID.MhKey, payload.HeaderCID.BlockNumber).Scan(&stateID)
 		if err != nil {
 			return err
 		}
@@ -169,17 +517,38 @@ func (in *CIDIndexer) indexStateAndStorageCIDs(tx *sqlx.Tx, payload CIDPayload,
 		if stateCID.NodeType == 2 {
 			statePath := common.Bytes2Hex(stateCID.Path)
 			for _, storageCID := range payload.StorageNodeCIDs[statePath] {
+				storageCID.BlockNumber = payload.HeaderCID.BlockNumber
 				if err := in.indexStorageCID(tx, storageCID, stateID); err != nil {
 					return err
 				}
+				if storageCID.NodeType == 2 {
+					if err := in.indexDecodedStorage(tx, stateCID.StateKey, storageCID.StorageKey, storageCID.BlockNumber, storageCID.Value, headerID); err != nil {
+						return err
+					}
+				}
 			}
 			if stateAccount, ok := payload.StateAccounts[statePath]; ok {
 				if err := in.indexStateAccount(tx, stateAccount, stateID); err != nil {
 					return err
 				}
+				if err := in.indexAccountChange(tx, stateCID.StateKey, payload.HeaderCID.BlockNumber, stateAccount, headerID); err != nil {
+					return err
+				}
 			}
 		}
 	}
+	for _, stateRemoval := range payload.StateRemovals {
+		stateRemoval.BlockNumber = payload.HeaderCID.BlockNumber
+		if err := in.indexStateRemoval(tx, stateRemoval, headerID); err != nil {
+			return err
+		}
+	}
+	for _, storageRemoval := range payload.StorageRemovals {
+		storageRemoval.BlockNumber = payload.HeaderCID.BlockNumber
+		if err := in.indexStorageRemoval(tx, storageRemoval, headerID); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -189,10 +558,10 @@ func (in *CIDIndexer) indexStateCID(tx *sqlx.Tx, stateNode StateNodeModel, heade
 	if stateNode.StateKey != nullHash.String() {
 		stateKey = stateNode.StateKey
 	}
-	err := tx.QueryRowx(`INSERT INTO eth.state_cids (header_id, state_leaf_key, cid, state_path, node_type, diff, mh_key) VALUES ($1, $2, $3, $4, $5, $6, $7)
-									ON CONFLICT (header_id, state_path) DO UPDATE SET (state_leaf_key, cid, node_type, diff, mh_key) = ($2, $3, $5, $6, $7)
+	err := tx.QueryRowx(`INSERT INTO eth.state_cids (header_id, state_leaf_key, cid, state_path, node_type, diff, mh_key, block_number) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+									ON CONFLICT (header_id, state_path, block_number) DO UPDATE SET (state_leaf_key, cid, node_type, diff, mh_key) = ($2, $3, $5, $6, $7)
 									RETURNING id`,
-		headerID, stateKey, stateNode.CID, stateNode.Path, stateNode.NodeType, true, stateNode.MhKey).Scan(&stateID)
+		headerID, stateKey, stateNode.CID, stateNode.Path, stateNode.NodeType, true, stateNode.MhKey, stateNode.BlockNumber).Scan(&stateID)
 	return stateID, err
 }
 
@@ -208,8 +577,115 @@ func (in *CIDIndexer) indexStorageCID(tx *sqlx.Tx, storageCID StorageNodeModel,
 	if storageCID.StorageKey != nullHash.String() {
 		storageKey = storageCID.StorageKey
 	}
-	_, err := tx.Exec(`INSERT INTO eth.storage_cids (state_id, storage_leaf_key, cid, storage_path, node_type, diff, mh_key) VALUES ($1, $2, $3, $4, $5, $6, $7) 
-							  ON CONFLICT (state_id, storage_path) DO UPDATE SET (storage_leaf_key, cid, node_type, diff, mh_key) = ($2, $3, $5, $6, $7)`,
-		stateID, storageKey, storageCID.CID, storageCID.Path, storageCID.NodeType, true, storageCID.MhKey)
+	_, err := tx.Exec(`INSERT INTO eth.storage_cids (state_id, storage_leaf_key, cid, storage_path, node_type, diff, mh_key, block_number, value) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+							  ON CONFLICT (state_id, storage_path, block_number) DO UPDATE SET (storage_leaf_key, cid, node_type, diff, mh_key, value) = ($2, $3, $5, $6, $7, $9)`,
+		stateID, storageKey, storageCID.CID, storageCID.Path, storageCID.NodeType, true, storageCID.MhKey, storageCID.BlockNumber, storageCID.Value)
+	return err
+}
+
+// indexAccountChange records how the account with the provided leaf key changed in the header's block,
+// relative to its latest snapshot in an ancestor block, or to an empty account if it did not exist there
+// the ancestors are followed by parent hash down to the first one flagged canonical, since a concurrent worker may not have flagged those above it yet,
+// and below it (or below a gap in the indexed ancestors) the canonical flags are used
+// unless another header is canonical at its height, the account's next canonical change above the block is then rebased
+// onto this one, so that changes stay chained when blocks are backfilled beneath blocks that are already indexed
+func (in *CIDIndexer) indexAccountChange(tx *sqlx.Tx, stateKey, blockNumber string, account StateAccountModel, headerID int64) error {
+	prev := emptyAccount
+	pgStr := `WITH RECURSIVE ancestors AS (
+				SELECT id, block_number, parent_hash, canonical FROM eth.header_cids
+				WHERE block_number = $2 AND id = $3
+				UNION ALL
+				SELECT parent.id, parent.block_number, parent.parent_hash, parent.canonical FROM ancestors
+				INNER JOIN eth.header_cids parent ON (parent.block_number = ancestors.block_number - 1 AND parent.block_hash = ancestors.parent_hash)
+				WHERE ancestors.block_number = $2 OR NOT ancestors.canonical
+			)
+			SELECT state_accounts.balance, state_accounts.nonce, state_accounts.code_hash, state_accounts.storage_root
+			FROM eth.state_accounts
+			INNER JOIN eth.state_cids ON (state_accounts.state_id = state_cids.id)
+			INNER JOIN eth.header_cids ON (state_cids.header_id = header_cids.id AND state_cids.block_number = header_cids.block_number)
+			WHERE state_cids.state_leaf_key = $1 AND state_cids.block_number < $2
+			AND ((header_cids.id, header_cids.block_number) IN (SELECT id, block_number FROM ancestors)
+				OR (header_cids.canonical AND header_cids.block_number < (SELECT MIN(block_number) FROM ancestors)))
+			AND NOT EXISTS (SELECT 1 FROM eth.state_removals
+							INNER JOIN eth.header_cids removal_header ON (state_removals.header_id = removal_header.id AND state_removals.block_number = removal_header.block_number)
+							WHERE state_removals.state_leaf_key = $1 AND state_removals.destroyed
+							AND ((removal_header.id, removal_header.block_number) IN (SELECT id, block_number FROM ancestors)
+								OR (removal_header.canonical AND removal_header.block_number < (SELECT MIN(block_number) FROM ancestors)))
+							AND state_removals.block_number > state_cids.block_number AND state_removals.block_number < $2)
+			ORDER BY state_cids.block_number DESC
+			LIMIT 1`
+	if err := tx.Get(&prev, pgStr, stateKey, blockNumber, headerID); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO eth.account_changes (header_id, block_number, state_leaf_key, prev_balance, balance, prev_nonce, nonce, code_hash, storage_root, code_changed, storage_root_changed)
+							  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+							  ON CONFLICT (header_id, state_leaf_key) DO UPDATE SET (prev_balance, balance, prev_nonce, nonce, code_hash, storage_root, code_changed, storage_root_changed) = ($4, $5, $6, $7, $8, $9, $10, $11)`,
+		headerID, blockNumber, stateKey, prev.Balance, account.Balance, prev.Nonce, account.Nonce, account.CodeHash, account.StorageRoot,
+		common.BytesToHash(prev.CodeHash) != common.BytesToHash(account.CodeHash), prev.StorageRoot != account.StorageRoot)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE eth.account_changes
+							  SET (prev_balance, prev_nonce, code_changed, storage_root_changed) = ($3, $4, code_hash <> $5, storage_root <> $6)
+							  WHERE id = (SELECT account_changes.id FROM eth.account_changes
+										  INNER JOIN eth.header_cids ON (account_changes.header_id = header_cids.id AND account_changes.block_number = header_cids.block_number)
+										  WHERE account_changes.state_leaf_key = $1 AND account_changes.block_number > $2 AND header_cids.canonical
+										  ORDER BY account_changes.block_number
+										  LIMIT 1)
+							  AND NOT EXISTS (SELECT 1 FROM eth.header_cids WHERE block_number = $2 AND canonical AND id <> $7)`,
+		stateKey, blockNumber, account.Balance, account.Nonce, account.CodeHash, account.StorageRoot, headerID)
 	return err
 }
+
+// indexStateRemoval records a state node that was removed from the state trie by the header's block
+// removed nodes have no value, so no IPLD is published for them
+func (in *CIDIndexer) indexStateRemoval(tx *sqlx.Tx, removal StateRemovalModel, headerID int64) error {
+	var stateKey string
+	if removal.StateKey != nullHash.String() {
+		stateKey = removal.StateKey
+	}
+	_, err := tx.Exec(`INSERT INTO eth.state_removals (header_id, block_number, state_path, state_leaf_key, destroyed) VALUES ($1, $2, $3, $4, $5)
+							  ON CONFLICT (header_id, state_path) DO UPDATE SET (state_leaf_key, destroyed) = ($4, $5)`,
+		headerID, removal.BlockNumber, removal.Path, stateKey, removal.Destroyed)
+	if err != nil || !removal.Destroyed {
+		return err
+	}
+	// a destroyed account is left empty
+	return in.indexAccountChange(tx, stateKey, removal.BlockNumber, emptyAccount, headerID)
+}
+
+// indexStorageRemoval records a storage node that was removed from an account's storage trie by the header's block
+func (in *CIDIndexer) indexStorageRemoval(tx *sqlx.Tx, removal StorageRemovalModel, headerID int64) error {
+	var storageKey string
+	if removal.StorageKey != nullHash.String() {
+		storageKey = removal.StorageKey
+	}
+	_, err := tx.Exec(`INSERT INTO eth.storage_removals (header_id, block_number, state_leaf_key, storage_path, storage_leaf_key, cleared) VALUES ($1, $2, $3, $4, $5, $6)
+							  ON CONFLICT (header_id, state_leaf_key, storage_path) DO UPDATE SET (storage_leaf_key, cleared) = ($5, $6)`,
+		headerID, removal.BlockNumber, removal.StateKey, removal.Path, storageKey, removal.Cleared)
+	if err != nil || !removal.Cleared {
+		return err
+	}
+	// a cleared slot holds zero
+	return in.indexDecodedStorage(tx, removal.StateKey, storageKey, removal.BlockNumber, nil, headerID)
+}
+
+// indexDecodedStorage upserts the variables held in a storage slot of a contract with a registered storage layout
+func (in *CIDIndexer) indexDecodedStorage(tx *sqlx.Tx, stateKey, storageKey, blockNumber string, value []byte, headerID int64) error {
+	if in.storage == nil {
+		return nil
+	}
+	return indexDecodedStorage(tx, in.storage.Decode(stateKey, storageKey, value), blockNumber, headerID)
+}
+
+func indexDecodedStorage(tx *sqlx.Tx, variables []DecodedStorageModel, blockNumber string, headerID int64) error {
+	for _, v := range variables {
+		_, err := tx.Exec(`INSERT INTO eth.decoded_storage (header_id, block_number, state_leaf_key, storage_leaf_key, contract, variable, keys, type, value) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+							  ON CONFLICT (header_id, state_leaf_key, storage_leaf_key, variable) DO UPDATE SET (block_number, contract, keys, type, value) = ($2, $5, $7, $8, $9)`,
+			headerID, blockNumber, v.StateKey, v.StorageKey, v.Contract, v.Variable, v.Keys, v.Type, v.Value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"database/sql"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// DefaultMetaDataBatchSize is the number of rows decoded and updated, or of blocks whose gas used and fees are derived, in each tx by the MetaDataBackfiller
+const DefaultMetaDataBatchSize uint64 = 1000
+
+// MetaDataBackfiller fills in the decoded header, uncle, transaction, receipt, and storage columns of rows that were indexed before those columns existed
+// by decoding the IPLDs already published in public.blocks, and indexes the contracts created by those receipts and the addresses those txs touched
+type MetaDataBackfiller struct {
+	db        *postgres.DB
+	batchSize uint64
+}
+
+// NewMetaDataBackfiller returns a new MetaDataBackfiller
+func NewMetaDataBackfiller(db *postgres.DB, batchSize uint64) *MetaDataBackfiller {
+	if batchSize == 0 {
+		batchSize = DefaultMetaDataBatchSize
+	}
+	return &MetaDataBackfiller{
+		db:        db,
+		batchSize: batchSize,
+	}
+}
+
+// encodedRow is a row id and the raw IPLD data it references
+// the block number is only selected for rows of the partitioned tables
+type encodedRow struct {
+	ID          int64  `db:"id"`
+	BlockNumber int64  `db:"block_number"`
+	Data        []byte `db:"data"`
+}
+
+// Backfill fills in the missing header, uncle, transaction, receipt, and storage columns, contracts, and address to transaction links
+func (mb *MetaDataBackfiller) Backfill() error {
+	if err := mb.backfillHeaders(); err != nil {
+		return err
+	}
+	if err := mb.backfillUncles(); err != nil {
+		return err
+	}
+	if err := mb.backfillTxs(); err != nil {
+		return err
+	}
+	if err := mb.backfillRcts(); err != nil {
+		return err
+	}
+	if err := mb.backfillGasUsed(); err != nil {
+		return err
+	}
+	if err := mb.backfillTxFees(); err != nil {
+		return err
+	}
+	if err := mb.backfillStorageValues(); err != nil {
+		return err
+	}
+	if err := mb.backfillContracts(); err != nil {
+		return err
+	}
+	return mb.backfillAddressTransactions()
+}
+
+// backfillRows repeatedly selects a batch of rows with the provided query and updates each of them in a single tx,
+// until the query returns no more rows
+// the query is passed the id of the last row of the previous batch as $1 and the batch size as $2,
+// and must return the rows with a greater id in ascending order of id, so that each batch picks up where the last one stopped
+func (mb *MetaDataBackfiller) backfillRows(name, pgStr string, update func(tx *sqlx.Tx, row encodedRow) error) error {
+	var filled uint64
+	var last int64
+	for {
+		rows := make([]encodedRow, 0, mb.batchSize)
+		if err := mb.db.Select(&rows, pgStr, last, mb.batchSize); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			logrus.Infof("metadata backfiller finished filling in %d %s", filled, name)
+			return nil
+		}
+		last = rows[len(rows)-1].ID
+		tx, err := mb.db.Beginx()
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if err := update(tx, row); err != nil {
+				shared.Rollback(tx)
+				return err
+			}
+		}
+		if err := shared.Commit(tx); err != nil {
+			return err
+		}
+		filled += uint64(len(rows))
+		logrus.Infof("metadata backfiller filled in %d %s", filled, name)
+	}
+}
+
+func (mb *MetaDataBackfiller) backfillHeaders() error {
+	pgStr := `SELECT header_cids.id, blocks.data FROM eth.header_cids
+			INNER JOIN public.blocks ON (header_cids.mh_key = blocks.key)
+			WHERE header_cids.id > $1
+			AND header_cids.burnt_fees IS NULL
+			ORDER BY header_cids.id
+			LIMIT $2`
+	return mb.backfillRows("headers", pgStr, func(tx *sqlx.Tx, row encodedRow) error {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(row.Data, header); err != nil {
+			return err
+		}
+		var model HeaderModel
+		setHeaderMetaData(&model, header)
+		_, err := tx.Exec(`UPDATE eth.header_cids SET (coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce, base_fee, burnt_fees) = ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+				WHERE id = $10 AND block_number = $11`,
+			model.Coinbase, model.Difficulty, model.GasLimit, model.GasUsed, model.ExtraData, model.MixDigest, model.Nonce,
+			nullNumeric(model.BaseFee), model.BurntFees, row.ID, header.Number.Uint64())
+		return err
+	})
+}
+
+func (mb *MetaDataBackfiller) backfillUncles() error {
+	pgStr := `SELECT uncle_cids.id, blocks.data FROM eth.uncle_cids
+			INNER JOIN public.blocks ON (uncle_cids.mh_key = blocks.key)
+			WHERE uncle_cids.id > $1
+			AND uncle_cids.coinbase IS NULL
+			ORDER BY uncle_cids.id
+			LIMIT $2`
+	return mb.backfillRows("uncles", pgStr, func(tx *sqlx.Tx, row encodedRow) error {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(row.Data, header); err != nil {
+			return err
+		}
+		var model UncleModel
+		setUncleMetaData(&model, header)
+		_, err := tx.Exec(`UPDATE eth.uncle_cids SET (coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce) = ($1, $2, $3, $4, $5, $6, $7)
+				WHERE id = $8`,
+			model.Coinbase, model.Difficulty, model.GasLimit, model.GasUsed, model.ExtraData, model.MixDigest, model.Nonce, row.ID)
+		return err
+	})
+}
+
+func (mb *MetaDataBackfiller) backfillTxs() error {
+	pgStr := `SELECT transaction_cids.id, blocks.data FROM eth.transaction_cids
+			INNER JOIN public.blocks ON (transaction_cids.mh_key = blocks.key)
+			WHERE transaction_cids.id > $1
+			AND transaction_cids.max_fee_per_gas IS NULL
+			ORDER BY transaction_cids.id
+			LIMIT $2`
+	return mb.backfillRows("transactions", pgStr, func(tx *sqlx.Tx, row encodedRow) error {
+		trx := new(types.Transaction)
+		if err := trx.UnmarshalBinary(row.Data); err != nil {
+			return err
+		}
+		var model TxModel
+		setTxMetaData(&model, trx)
+		_, err := tx.Exec(`UPDATE eth.transaction_cids SET (gas, gas_price, value, nonce, tx_type, max_fee_per_gas, max_priority_fee_per_gas) = ($1, $2, $3, $4, $5, $6, $7)
+				WHERE id = $8`,
+			model.Gas, model.GasPrice, model.Value, model.Nonce, model.Type, trx.GasFeeCap().String(), trx.GasTipCap().String(), row.ID)
+		return err
+	})
+}
+
+func (mb *MetaDataBackfiller) backfillRcts() error {
+	pgStr := `SELECT receipt_cids.id, blocks.data FROM eth.receipt_cids
+			INNER JOIN public.blocks ON (receipt_cids.mh_key = blocks.key)
+			WHERE receipt_cids.id > $1
+			AND receipt_cids.cumulative_gas_used IS NULL
+			ORDER BY receipt_cids.id
+			LIMIT $2`
+	return mb.backfillRows("receipts", pgStr, func(tx *sqlx.Tx, row encodedRow) error {
+		receipt := new(types.Receipt)
+		if err := receipt.UnmarshalBinary(row.Data); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`UPDATE eth.receipt_cids SET (cumulative_gas_used, log_bloom) = ($1, $2) WHERE id = $3`,
+			receipt.CumulativeGasUsed, receipt.Bloom.Bytes(), row.ID)
+		return err
+	})
+}
+
+// backfillGasUsed derives the gas used by each receipt from its cumulative gas used and that of the previous receipt in the block
+func (mb *MetaDataBackfiller) backfillGasUsed() error {
+	pgStr := `UPDATE eth.receipt_cids
+			SET gas_used = receipt_cids.cumulative_gas_used - COALESCE(prev.prev_cumulative_gas_used, 0)
+			FROM (SELECT receipt_cids.id,
+					LAG(receipt_cids.cumulative_gas_used) OVER (PARTITION BY transaction_cids.header_id ORDER BY transaction_cids.index) AS prev_cumulative_gas_used
+				FROM eth.receipt_cids
+				INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+				INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+				WHERE header_cids.block_number BETWEEN $1 AND $2) AS prev
+			WHERE receipt_cids.id = prev.id
+			AND receipt_cids.gas_used IS NULL
+			AND receipt_cids.cumulative_gas_used IS NOT NULL`
+	return mb.backfillBlockRanges("receipt gas used", pgStr)
+}
+
+// backfillTxFees derives the effective gas price and priority fee of each transaction from its fee caps,
+// the base fee of its block, and the gas used by its receipt
+func (mb *MetaDataBackfiller) backfillTxFees() error {
+	pgStr := `UPDATE eth.transaction_cids
+			SET effective_gas_price = CASE WHEN header_cids.base_fee IS NULL THEN transaction_cids.gas_price
+					ELSE LEAST(header_cids.base_fee + transaction_cids.max_priority_fee_per_gas, transaction_cids.max_fee_per_gas) END,
+				priority_fee = (CASE WHEN header_cids.base_fee IS NULL THEN transaction_cids.gas_price
+					ELSE LEAST(header_cids.base_fee + transaction_cids.max_priority_fee_per_gas, transaction_cids.max_fee_per_gas) - header_cids.base_fee END) * receipt_cids.gas_used
+			FROM eth.header_cids, eth.receipt_cids
+			WHERE transaction_cids.header_id = header_cids.id
+			AND receipt_cids.tx_id = transaction_cids.id
+			AND header_cids.block_number BETWEEN $1 AND $2
+			AND transaction_cids.priority_fee IS NULL
+			AND transaction_cids.max_fee_per_gas IS NOT NULL
+			AND header_cids.burnt_fees IS NOT NULL
+			AND receipt_cids.gas_used IS NOT NULL`
+	return mb.backfillBlockRanges("transaction fees", pgStr)
+}
+
+// backfillBlockRanges runs the provided update over the indexed blocks in ranges of batch size blocks, each in its own tx,
+// so that no single statement has to lock and rewrite a whole table
+// the update is passed the first and last block number of the range as $1 and $2
+func (mb *MetaDataBackfiller) backfillBlockRanges(name, pgStr string) error {
+	var bounds struct {
+		First sql.NullInt64 `db:"first"`
+		Last  sql.NullInt64 `db:"last"`
+	}
+	if err := mb.db.Get(&bounds, `SELECT MIN(block_number) AS first, MAX(block_number) AS last FROM eth.header_cids`); err != nil {
+		return err
+	}
+	if !bounds.First.Valid {
+		logrus.Infof("metadata backfiller finished filling in 0 %s", name)
+		return nil
+	}
+	var filled int64
+	for start := uint64(bounds.First.Int64); start <= uint64(bounds.Last.Int64); start += mb.batchSize {
+		res, err := mb.db.Exec(pgStr, start, start+mb.batchSize-1)
+		if err != nil {
+			return err
+		}
+		updated, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		filled += updated
+		logrus.Infof("metadata backfiller filled in %d %s up to block %d", filled, name, start+mb.batchSize-1)
+	}
+	logrus.Infof("metadata backfiller finished filling in %d %s", filled, name)
+	return nil
+}
+
+// backfillStorageValues decodes the slot values of the storage leaf nodes
+func (mb *MetaDataBackfiller) backfillStorageValues() error {
+	pgStr := `SELECT storage_cids.id, storage_cids.block_number, blocks.data FROM eth.storage_cids
+			INNER JOIN public.blocks ON (storage_cids.mh_key = blocks.key)
+			WHERE storage_cids.id > $1
+			AND storage_cids.value IS NULL
+			AND storage_cids.node_type = 2
+			ORDER BY storage_cids.id
+			LIMIT $2`
+	return mb.backfillRows("storage values", pgStr, func(tx *sqlx.Tx, row encodedRow) error {
+		value, err := storageLeafValue(row.Data)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE eth.storage_cids SET value = $1 WHERE id = $2 AND block_number = $3`, value, row.ID, row.BlockNumber)
+		return err
+	})
+}
+
+// backfillContracts indexes the contracts created by receipts that do not have a row in eth.contracts yet,
+// taking their code hash from the state account written for the contract in the same block, if there is one
+func (mb *MetaDataBackfiller) backfillContracts() error {
+	pgStr := `SELECT transaction_cids.id AS tx_id, header_cids.block_number, receipt_cids.contract AS address, transaction_cids.src AS creator, state_accounts.code_hash
+			FROM eth.receipt_cids
+			INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+			INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+			LEFT JOIN eth.state_cids ON (state_cids.header_id = header_cids.id
+				AND state_cids.block_number = header_cids.block_number
+				AND state_cids.state_leaf_key = receipt_cids.contract_hash)
+			LEFT JOIN eth.state_accounts ON (state_accounts.state_id = state_cids.id)
+			WHERE receipt_cids.contract IS NOT NULL
+			AND receipt_cids.contract <> ''
+			AND transaction_cids.id > $1
+			AND NOT EXISTS (SELECT 1 FROM eth.contracts WHERE contracts.tx_id = transaction_cids.id)
+			ORDER BY transaction_cids.id
+			LIMIT $2`
+	indexer := NewCIDIndexer(mb.db)
+	var filled uint64
+	var last int64
+	for {
+		contracts := make([]ContractModel, 0, mb.batchSize)
+		if err := mb.db.Select(&contracts, pgStr, last, mb.batchSize); err != nil {
+			return err
+		}
+		if len(contracts) == 0 {
+			logrus.Infof("metadata backfiller finished filling in %d contracts", filled)
+			return nil
+		}
+		last = contracts[len(contracts)-1].TxID
+		tx, err := mb.db.Beginx()
+		if err != nil {
+			return err
+		}
+		for _, contract := range contracts {
+			if contract.CodeHash != nil {
+				if err := setContractCode(&contract, contract.CodeHash); err != nil {
+					shared.Rollback(tx)
+					return err
+				}
+			}
+			if err := indexer.indexContract(tx, contract); err != nil {
+				shared.Rollback(tx)
+				return err
+			}
+		}
+		if err := shared.Commit(tx); err != nil {
+			return err
+		}
+		filled += uint64(len(contracts))
+		logrus.Infof("metadata backfiller filled in %d contracts", filled)
+	}
+}
+
+// backfillAddressTransactions links the sender, recipient, created contract, and log emitters of each tx that has no links yet
+func (mb *MetaDataBackfiller) backfillAddressTransactions() error {
+	selectStr := `SELECT id FROM eth.transaction_cids
+			WHERE id > $1
+			AND NOT EXISTS (SELECT 1 FROM eth.address_transactions WHERE address_transactions.tx_id = transaction_cids.id)
+			ORDER BY id
+			LIMIT $2`
+	insertStr := `INSERT INTO eth.address_transactions (address, tx_id, role)
+			SELECT links.address, links.tx_id, links.role FROM (
+				SELECT transaction_cids.src AS address, transaction_cids.id AS tx_id, $3::INTEGER AS role FROM eth.transaction_cids
+				WHERE transaction_cids.id = ANY($1)
+				UNION ALL
+				SELECT transaction_cids.dst, transaction_cids.id, $4::INTEGER FROM eth.transaction_cids
+				WHERE transaction_cids.id = ANY($1)
+				UNION ALL
+				SELECT receipt_cids.contract, receipt_cids.tx_id, $5::INTEGER FROM eth.receipt_cids
+				WHERE receipt_cids.tx_id = ANY($1)
+				UNION ALL
+				SELECT UNNEST(receipt_cids.log_contracts), receipt_cids.tx_id, $6::INTEGER FROM eth.receipt_cids
+				WHERE receipt_cids.tx_id = ANY($1)
+			) AS links
+			WHERE links.address IS NOT NULL
+			AND links.address <> ''
+			ON CONFLICT (address, tx_id, role) DO NOTHING`
+	var filled uint64
+	var last int64
+	for {
+		ids := make([]int64, 0, mb.batchSize)
+		if err := mb.db.Select(&ids, selectStr, last, mb.batchSize); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			logrus.Infof("metadata backfiller finished linking %d transactions to their addresses", filled)
+			return nil
+		}
+		last = ids[len(ids)-1]
+		if _, err := mb.db.Exec(insertStr, pq.Array(ids), SenderRole, RecipientRole, ContractRole, LogEmitterRole); err != nil {
+			return err
+		}
+		filled += uint64(len(ids))
+		logrus.Infof("metadata backfiller linked %d transactions to their addresses", filled)
+	}
+}
@@ -0,0 +1,99 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"github.com/ethereum/go-ethereum/params"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth/mocks"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+var _ = Describe("MetaDataBackfiller", func() {
+	var (
+		db  *postgres.DB
+		err error
+		// each query renders the backfilled columns of every row, with NULLs spelled out
+		metaDataQueries = []string{
+			`SELECT id || ':' || COALESCE(coinbase, 'NULL') || ':' || COALESCE(gas_used::TEXT, 'NULL') || ':' || COALESCE(burnt_fees::TEXT, 'NULL')
+				FROM eth.header_cids ORDER BY id`,
+			`SELECT id || ':' || COALESCE(coinbase, 'NULL') || ':' || COALESCE(gas_used::TEXT, 'NULL')
+				FROM eth.uncle_cids ORDER BY id`,
+			`SELECT id || ':' || COALESCE(value::TEXT, 'NULL') || ':' || COALESCE(max_fee_per_gas::TEXT, 'NULL') || ':' ||
+				COALESCE(effective_gas_price::TEXT, 'NULL') || ':' || COALESCE(priority_fee::TEXT, 'NULL')
+				FROM eth.transaction_cids ORDER BY id`,
+			`SELECT id || ':' || COALESCE(cumulative_gas_used::TEXT, 'NULL') || ':' || COALESCE(gas_used::TEXT, 'NULL')
+				FROM eth.receipt_cids ORDER BY id`,
+			`SELECT id || ':' || COALESCE(encode(value, 'hex'), 'NULL')
+				FROM eth.storage_cids WHERE node_type = 2 ORDER BY id`,
+		}
+		metaData = func() []string {
+			all := make([]string, 0)
+			for _, pgStr := range metaDataQueries {
+				rows := make([]string, 0)
+				err := db.Select(&rows, pgStr)
+				Expect(err).ToNot(HaveOccurred())
+				all = append(all, rows...)
+			}
+			return all
+		}
+	)
+	BeforeEach(func() {
+		db, err = shared.SetupDB()
+		Expect(err).ToNot(HaveOccurred())
+		transformer := eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{})
+		_, err = transformer.Transform(1, mocks.MockStateDiffPayload)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		eth.TearDownDB(db)
+	})
+
+	It("Fills in the columns of rows indexed before they existed", func() {
+		indexed := metaData()
+		Expect(indexed).ToNot(BeEmpty())
+		Expect(indexed).ToNot(ContainElement(ContainSubstring("NULL")))
+
+		// as for rows indexed before the columns were added
+		for _, pgStr := range []string{
+			`UPDATE eth.header_cids SET (coinbase, gas_used, burnt_fees) = (NULL, NULL, NULL)`,
+			`UPDATE eth.uncle_cids SET (coinbase, gas_used) = (NULL, NULL)`,
+			`UPDATE eth.transaction_cids SET (value, max_fee_per_gas, effective_gas_price, priority_fee) = (NULL, NULL, NULL, NULL)`,
+			`UPDATE eth.receipt_cids SET (cumulative_gas_used, gas_used) = (NULL, NULL)`,
+			`UPDATE eth.storage_cids SET value = NULL`,
+		} {
+			_, err = db.Exec(pgStr)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// a batch size of one pages through every row and block one at a time
+		err = eth.NewMetaDataBackfiller(db, 1).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metaData()).To(Equal(indexed))
+	})
+
+	It("Leaves rows that are already filled in as they are", func() {
+		indexed := metaData()
+		err = eth.NewMetaDataBackfiller(db, 0).Backfill()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(metaData()).To(Equal(indexed))
+	})
+})
@@ -0,0 +1,93 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mocks
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BlockClient is a mock client for use in block fetcher tests
+// it serves debug_getBlockRlp and eth_getTransactionReceipt from the blocks and receipts it is given, and records the methods called
+type BlockClient struct {
+	Blocks   map[uint64]*types.Block
+	Receipts map[common.Hash]*types.Receipt
+	Methods  []string
+}
+
+// NewBlockClient returns a BlockClient serving the provided blocks and the receipts of their transactions
+func NewBlockClient(blocks map[uint64]*types.Block, receipts types.Receipts) *BlockClient {
+	mc := &BlockClient{
+		Blocks:   blocks,
+		Receipts: make(map[common.Hash]*types.Receipt),
+	}
+	for _, receipt := range receipts {
+		mc.Receipts[receipt.TxHash] = receipt
+	}
+	return mc
+}
+
+// BatchCallContext mockClient method to simulate batch call to geth
+func (mc *BlockClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	for i := range batch {
+		batchElem := &batch[i]
+		mc.Methods = append(mc.Methods, batchElem.Method)
+		switch batchElem.Method {
+		case "debug_getBlockRlp":
+			height, ok := batchElem.Args[0].(uint64)
+			if !ok {
+				return fmt.Errorf("expected batch elem first argument to be a uint64")
+			}
+			block, ok := mc.Blocks[height]
+			if !ok {
+				batchElem.Error = fmt.Errorf("block #%d not found", height)
+				continue
+			}
+			by, err := rlp.EncodeToBytes(block)
+			if err != nil {
+				return err
+			}
+			// geth returns the rlp hex encoded, without a 0x prefix
+			*batchElem.Result.(*string) = hex.EncodeToString(by)
+		case "eth_getTransactionReceipt":
+			hash, ok := batchElem.Args[0].(common.Hash)
+			if !ok {
+				return fmt.Errorf("expected batch elem first argument to be a hash")
+			}
+			by := []byte("null")
+			if receipt, ok := mc.Receipts[hash]; ok {
+				var err error
+				if by, err = json.Marshal(receipt); err != nil {
+					return err
+				}
+			}
+			if err := json.Unmarshal(by, batchElem.Result); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected method %s", batchElem.Method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mocks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CodeClient is a mock client for use in code backfiller tests
+// it holds the code of contract accounts by their state leaf key, which it serves without their address as a node missing the address preimages would
+type CodeClient struct {
+	Codes map[common.Hash][]byte
+	Calls int
+}
+
+// BatchCallContext mockClient method to simulate batch debug_accountRange calls to geth
+func (mc *CodeClient) BatchCallContext(ctx context.Context, batch []rpc.BatchElem) error {
+	for _, batchElem := range batch {
+		if batchElem.Method != "debug_accountRange" || len(batchElem.Args) != 6 {
+			return errors.New("expected batch elem to be a debug_accountRange call with a block number, start key, max results, and dump flags")
+		}
+		start, ok := batchElem.Args[1].([]byte)
+		if !ok {
+			return errors.New("expected batch elem second argument to be a start key")
+		}
+		dump := batchElem.Result.(*state.IteratorDump)
+		dump.Accounts = make(map[common.Address]state.DumpAccount)
+		if code, ok := mc.Codes[common.BytesToHash(start)]; ok {
+			dump.Accounts[common.Address{}] = state.DumpAccount{
+				Code:      code,
+				SecureKey: start,
+			}
+		}
+		mc.Calls++
+	}
+	return nil
+}
@@ -391,6 +391,69 @@ var (
 		StateNodes:      MockStateNodes,
 	}
 
+	// a block holding an EIP-2930 access list tx and an EIP-1559 dynamic fee tx, which both carry an access list
+	MockAccessList = types.AccessList{
+		{
+			Address:     AnotherAddress,
+			StorageKeys: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+		},
+		{
+			Address:     ContractAddress,
+			StorageKeys: []common.Hash{},
+		},
+	}
+	MockTypedHeader = types.Header{
+		Time:        0,
+		Number:      new(big.Int).Set(BlockNumber),
+		Root:        common.HexToHash("0x0"),
+		TxHash:      common.HexToHash("0x0"),
+		ReceiptHash: common.HexToHash("0x0"),
+		Difficulty:  big.NewInt(5000000),
+		Extra:       []byte{},
+		BaseFee:     big.NewInt(50),
+	}
+	MockTypedTransactions, MockTypedReceipts, TypedSenderAddr = createTypedTransactionsAndReceipts()
+	MockTypedBlock                                            = types.NewBlock(&MockTypedHeader, MockTypedTransactions, nil, MockTypedReceipts, new(trie.Trie))
+	MockTypedConvertedPayload                                 = eth.ConvertedPayload{
+		TotalDifficulty: MockTypedBlock.Difficulty(),
+		Block:           MockTypedBlock,
+		Receipts:        MockTypedReceipts,
+		TxMetaData: []eth.TxModel{
+			{
+				Src:    TypedSenderAddr.Hex(),
+				Dst:    Address.String(),
+				Index:  0,
+				TxHash: MockTypedTransactions[0].Hash().String(),
+				Data:   []byte{},
+			},
+			{
+				Src:    TypedSenderAddr.Hex(),
+				Dst:    AnotherAddress.String(),
+				Index:  1,
+				TxHash: MockTypedTransactions[1].Hash().String(),
+				Data:   []byte{},
+			},
+		},
+		ReceiptMetaData: []eth.ReceiptModel{
+			{
+				PostStatus: 1,
+				Topic0s:    []string{},
+				Topic1s:    []string{},
+				Topic2s:    []string{},
+				Topic3s:    []string{},
+			},
+			{
+				PostStatus: 1,
+				Topic0s:    []string{},
+				Topic1s:    []string{},
+				Topic2s:    []string{},
+				Topic3s:    []string{},
+			},
+		},
+		StateNodes:   []eth.TrieNode{},
+		StorageNodes: map[string][]eth.TrieNode{},
+	}
+
 	MockCIDPayload = eth.CIDPayload{
 		HeaderCID: eth.HeaderModel{
 			BlockHash:       MockBlock.Hash().String(),
@@ -475,15 +538,70 @@ func createTransactionsAndReceipts() (types.Transactions, types.Receipts, common
 	mockReceipt1 := types.NewReceipt(nil, false, 50)
 	mockReceipt1.Logs = []*types.Log{MockLog1}
 	mockReceipt1.TxHash = signedTrx1.Hash()
-	mockReceipt2 := types.NewReceipt(common.HexToHash("0x1").Bytes(), false, 100)
+	mockReceipt2 := types.NewReceipt(common.HexToHash("0x1").Bytes(), false, 150)
 	mockReceipt2.Logs = []*types.Log{MockLog2}
 	mockReceipt2.TxHash = signedTrx2.Hash()
-	mockReceipt3 := types.NewReceipt(common.HexToHash("0x2").Bytes(), false, 75)
+	mockReceipt3 := types.NewReceipt(common.HexToHash("0x2").Bytes(), false, 225)
 	mockReceipt3.Logs = []*types.Log{}
 	mockReceipt3.TxHash = signedTrx3.Hash()
 	return types.Transactions{signedTrx1, signedTrx2, signedTrx3}, types.Receipts{mockReceipt1, mockReceipt2, mockReceipt3}, SenderAddr
 }
 
+// createTypedTransactionsAndReceipts is a helper function to generate a signed mock access list tx and dynamic fee tx,
+// both carrying MockAccessList, and their mock receipts
+func createTypedTransactionsAndReceipts() (types.Transactions, types.Receipts, common.Address) {
+	chainID := params.MainnetChainConfig.ChainID
+	trx1 := types.NewTx(&types.AccessListTx{
+		ChainID:    chainID,
+		Nonce:      0,
+		GasPrice:   big.NewInt(100),
+		Gas:        50,
+		To:         &Address,
+		Value:      big.NewInt(1000),
+		AccessList: MockAccessList,
+	})
+	trx2 := types.NewTx(&types.DynamicFeeTx{
+		ChainID:    chainID,
+		Nonce:      1,
+		GasTipCap:  big.NewInt(10),
+		GasFeeCap:  big.NewInt(200),
+		Gas:        100,
+		To:         &AnotherAddress,
+		Value:      big.NewInt(2000),
+		AccessList: MockAccessList,
+	})
+	transactionSigner := types.NewLondonSigner(chainID)
+	mockCurve := elliptic.P256()
+	mockPrvKey, err := ecdsa.GenerateKey(mockCurve, rand.Reader)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signedTrx1, err := types.SignTx(trx1, transactionSigner, mockPrvKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signedTrx2, err := types.SignTx(trx2, transactionSigner, mockPrvKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	senderAddr, err := types.Sender(transactionSigner, signedTrx1) // same for both trx
+	if err != nil {
+		log.Fatal(err)
+	}
+	// make receipts
+	mockReceipt1 := types.NewReceipt(nil, false, 50)
+	mockReceipt1.Type = signedTrx1.Type()
+	mockReceipt1.Status = types.ReceiptStatusSuccessful
+	mockReceipt1.Logs = []*types.Log{}
+	mockReceipt1.TxHash = signedTrx1.Hash()
+	mockReceipt2 := types.NewReceipt(nil, false, 150)
+	mockReceipt2.Type = signedTrx2.Type()
+	mockReceipt2.Status = types.ReceiptStatusSuccessful
+	mockReceipt2.Logs = []*types.Log{}
+	mockReceipt2.TxHash = signedTrx2.Hash()
+	return types.Transactions{signedTrx1, signedTrx2}, types.Receipts{mockReceipt1, mockReceipt2}, senderAddr
+}
+
 func GetTxnRlp(num int, txs types.Transactions) []byte {
 	buf := new(bytes.Buffer)
 	txs.EncodeIndex(num, buf)
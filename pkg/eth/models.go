@@ -36,6 +36,17 @@ type HeaderModel struct {
 	Bloom           []byte `db:"bloom"`
 	Timestamp       uint64 `db:"timestamp"`
 	TimesValidated  int64  `db:"times_validated"`
+	Coinbase        string `db:"coinbase"`
+	Difficulty      string `db:"difficulty"`
+	GasLimit        uint64 `db:"gas_limit"`
+	GasUsed         uint64 `db:"gas_used"`
+	ExtraData       []byte `db:"extra_data"`
+	MixDigest       string `db:"mix_digest"`
+	Nonce           string `db:"nonce"`
+	BaseFee         string `db:"base_fee"`
+	BurntFees       string `db:"burnt_fees"`
+	Producer        string `db:"producer"`
+	Canonical       bool   `db:"canonical"`
 }
 
 // UncleModel is the db model for eth.uncle_cids
@@ -47,60 +58,171 @@ type UncleModel struct {
 	CID        string `db:"cid"`
 	MhKey      string `db:"mh_key"`
 	Reward     string `db:"reward"`
+	Coinbase   string `db:"coinbase"`
+	Difficulty string `db:"difficulty"`
+	GasLimit   uint64 `db:"gas_limit"`
+	GasUsed    uint64 `db:"gas_used"`
+	ExtraData  []byte `db:"extra_data"`
+	MixDigest  string `db:"mix_digest"`
+	Nonce      string `db:"nonce"`
 }
 
 // TxModel is the db model for eth.transaction_cids
 type TxModel struct {
-	ID       int64  `db:"id"`
-	HeaderID int64  `db:"header_id"`
-	Index    int64  `db:"index"`
-	TxHash   string `db:"tx_hash"`
-	CID      string `db:"cid"`
-	MhKey    string `db:"mh_key"`
-	Dst      string `db:"dst"`
-	Src      string `db:"src"`
-	Data     []byte `db:"tx_data"`
+	ID                   int64                    `db:"id"`
+	HeaderID             int64                    `db:"header_id"`
+	Index                int64                    `db:"index"`
+	TxHash               string                   `db:"tx_hash"`
+	CID                  string                   `db:"cid"`
+	MhKey                string                   `db:"mh_key"`
+	Dst                  string                   `db:"dst"`
+	Src                  string                   `db:"src"`
+	Data                 []byte                   `db:"tx_data"`
+	Gas                  uint64                   `db:"gas"`
+	GasPrice             string                   `db:"gas_price"`
+	Value                string                   `db:"value"`
+	Nonce                uint64                   `db:"nonce"`
+	Type                 int64                    `db:"tx_type"`
+	MaxFeePerGas         string                   `db:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string                   `db:"max_priority_fee_per_gas"`
+	EffectiveGasPrice    string                   `db:"effective_gas_price"`
+	PriorityFee          string                   `db:"priority_fee"`
+	AccessList           []AccessListElementModel `db:"-"`
+}
+
+// AccessListElementModel is the db model for eth.access_list_elements
+type AccessListElementModel struct {
+	ID          int64          `db:"id"`
+	TxID        int64          `db:"tx_id"`
+	Index       int64          `db:"index"`
+	Address     string         `db:"address"`
+	StorageKeys pq.StringArray `db:"storage_keys"`
+}
+
+// AddressRole is the part an address plays in a transaction
+type AddressRole int
+
+const (
+	SenderRole AddressRole = iota
+	RecipientRole
+	ContractRole
+	LogEmitterRole
+)
+
+// AddressTransactionModel is the db model for eth.address_transactions
+type AddressTransactionModel struct {
+	ID      int64       `db:"id"`
+	Address string      `db:"address"`
+	TxID    int64       `db:"tx_id"`
+	Role    AddressRole `db:"role"`
+}
+
+// DecodedEventModel is the db model for eth.decoded_events
+type DecodedEventModel struct {
+	ID        int64  `db:"id"`
+	LogID     int64  `db:"log_id"`
+	Address   string `db:"address"`
+	EventName string `db:"event_name"`
+	Signature string `db:"signature"`
+	Args      string `db:"args"`
+}
+
+// TokenStandard is the token standard whose transfer event a token transfer was extracted from
+type TokenStandard int
+
+const (
+	ERC20   TokenStandard = 20
+	ERC721  TokenStandard = 721
+	ERC1155 TokenStandard = 1155
+)
+
+// TokenTransferModel is the db model for eth.token_transfers
+type TokenTransferModel struct {
+	ID          int64         `db:"id"`
+	LogID       int64         `db:"log_id"`
+	BatchIndex  int64         `db:"batch_index"`
+	TxID        int64         `db:"tx_id"`
+	BlockNumber string        `db:"block_number"`
+	LogIndex    int64         `db:"log_index"`
+	Token       string        `db:"token"`
+	Standard    TokenStandard `db:"standard"`
+	Operator    string        `db:"operator"`
+	Src         string        `db:"src"`
+	Dst         string        `db:"dst"`
+	TokenID     string        `db:"token_id"`
+	Amount      string        `db:"amount"`
+}
+
+// ContractModel is the db model for eth.contracts
+type ContractModel struct {
+	ID          int64  `db:"id"`
+	TxID        int64  `db:"tx_id"`
+	BlockNumber string `db:"block_number"`
+	Address     string `db:"address"`
+	Creator     string `db:"creator"`
+	CodeHash    []byte `db:"code_hash"`
+	CodeMhKey   string `db:"code_mh_key"`
 }
 
 // ReceiptModel is the db model for eth.receipt_cids
 type ReceiptModel struct {
-	ID           int64          `db:"id"`
-	TxID         int64          `db:"tx_id"`
-	CID          string         `db:"cid"`
-	MhKey        string         `db:"mh_key"`
-	PostStatus   uint64         `db:"post_status"`
-	PostState    string         `db:"post_state"`
-	Contract     string         `db:"contract"`
-	ContractHash string         `db:"contract_hash"`
-	LogContracts pq.StringArray `db:"log_contracts"`
-	Topic0s      pq.StringArray `db:"topic0s"`
-	Topic1s      pq.StringArray `db:"topic1s"`
-	Topic2s      pq.StringArray `db:"topic2s"`
-	Topic3s      pq.StringArray `db:"topic3s"`
+	ID                int64          `db:"id"`
+	TxID              int64          `db:"tx_id"`
+	CID               string         `db:"cid"`
+	MhKey             string         `db:"mh_key"`
+	PostStatus        uint64         `db:"post_status"`
+	PostState         string         `db:"post_state"`
+	Contract          string         `db:"contract"`
+	ContractHash      string         `db:"contract_hash"`
+	LogContracts      pq.StringArray `db:"log_contracts"`
+	Topic0s           pq.StringArray `db:"topic0s"`
+	Topic1s           pq.StringArray `db:"topic1s"`
+	Topic2s           pq.StringArray `db:"topic2s"`
+	Topic3s           pq.StringArray `db:"topic3s"`
+	GasUsed           uint64         `db:"gas_used"`
+	CumulativeGasUsed uint64         `db:"cumulative_gas_used"`
+	LogBloom          []byte         `db:"log_bloom"`
+	Logs              []LogModel     `db:"-"`
+}
+
+// LogModel is the db model for eth.log_cids
+type LogModel struct {
+	ID        int64  `db:"id"`
+	ReceiptID int64  `db:"receipt_id"`
+	Index     int64  `db:"log_index"`
+	Address   string `db:"address"`
+	Topic0    string `db:"topic0"`
+	Topic1    string `db:"topic1"`
+	Topic2    string `db:"topic2"`
+	Topic3    string `db:"topic3"`
+	Data      []byte `db:"log_data"`
 }
 
 // StateNodeModel is the db model for eth.state_cids
 type StateNodeModel struct {
-	ID       int64  `db:"id"`
-	HeaderID int64  `db:"header_id"`
-	Path     []byte `db:"state_path"`
-	StateKey string `db:"state_leaf_key"`
-	NodeType int    `db:"node_type"`
-	CID      string `db:"cid"`
-	MhKey    string `db:"mh_key"`
-	Diff     bool   `db:"diff"`
+	ID          int64  `db:"id"`
+	HeaderID    int64  `db:"header_id"`
+	BlockNumber string `db:"block_number"`
+	Path        []byte `db:"state_path"`
+	StateKey    string `db:"state_leaf_key"`
+	NodeType    int    `db:"node_type"`
+	CID         string `db:"cid"`
+	MhKey       string `db:"mh_key"`
+	Diff        bool   `db:"diff"`
 }
 
 // StorageNodeModel is the db model for eth.storage_cids
 type StorageNodeModel struct {
-	ID         int64  `db:"id"`
-	StateID    int64  `db:"state_id"`
-	Path       []byte `db:"storage_path"`
-	StorageKey string `db:"storage_leaf_key"`
-	NodeType   int    `db:"node_type"`
-	CID        string `db:"cid"`
-	MhKey      string `db:"mh_key"`
-	Diff       bool   `db:"diff"`
+	ID          int64  `db:"id"`
+	StateID     int64  `db:"state_id"`
+	BlockNumber string `db:"block_number"`
+	Path        []byte `db:"storage_path"`
+	StorageKey  string `db:"storage_leaf_key"`
+	NodeType    int    `db:"node_type"`
+	CID         string `db:"cid"`
+	MhKey       string `db:"mh_key"`
+	Diff        bool   `db:"diff"`
+	Value       []byte `db:"value"`
 }
 
 // StorageNodeWithStateKeyModel is a db model for eth.storage_cids + eth.state_cids.state_key
@@ -114,6 +236,58 @@ type StorageNodeWithStateKeyModel struct {
 	CID        string `db:"cid"`
 	MhKey      string `db:"mh_key"`
 	Diff       bool   `db:"diff"`
+	Value      []byte `db:"value"`
+}
+
+// StateRemovalModel is the db model for eth.state_removals
+type StateRemovalModel struct {
+	ID          int64  `db:"id"`
+	HeaderID    int64  `db:"header_id"`
+	BlockNumber string `db:"block_number"`
+	Path        []byte `db:"state_path"`
+	StateKey    string `db:"state_leaf_key"`
+	Destroyed   bool   `db:"destroyed"`
+}
+
+// StorageRemovalModel is the db model for eth.storage_removals
+type StorageRemovalModel struct {
+	ID          int64  `db:"id"`
+	HeaderID    int64  `db:"header_id"`
+	BlockNumber string `db:"block_number"`
+	StateKey    string `db:"state_leaf_key"`
+	Path        []byte `db:"storage_path"`
+	StorageKey  string `db:"storage_leaf_key"`
+	Cleared     bool   `db:"cleared"`
+}
+
+// AccountChangeModel is the db model for eth.account_changes
+type AccountChangeModel struct {
+	ID                 int64  `db:"id"`
+	HeaderID           int64  `db:"header_id"`
+	BlockNumber        string `db:"block_number"`
+	StateKey           string `db:"state_leaf_key"`
+	PrevBalance        string `db:"prev_balance"`
+	Balance            string `db:"balance"`
+	PrevNonce          uint64 `db:"prev_nonce"`
+	Nonce              uint64 `db:"nonce"`
+	CodeHash           []byte `db:"code_hash"`
+	StorageRoot        string `db:"storage_root"`
+	CodeChanged        bool   `db:"code_changed"`
+	StorageRootChanged bool   `db:"storage_root_changed"`
+}
+
+// DecodedStorageModel is the db model for eth.decoded_storage
+type DecodedStorageModel struct {
+	ID          int64          `db:"id"`
+	HeaderID    int64          `db:"header_id"`
+	BlockNumber string         `db:"block_number"`
+	StateKey    string         `db:"state_leaf_key"`
+	StorageKey  string         `db:"storage_leaf_key"`
+	Contract    string         `db:"contract"`
+	Variable    string         `db:"variable"`
+	Keys        pq.StringArray `db:"keys"`
+	Type        string         `db:"type"`
+	Value       string         `db:"value"`
 }
 
 // StateAccountModel is a db model for an eth state account (decoded value of state leaf node)
@@ -125,3 +299,67 @@ type StateAccountModel struct {
 	CodeHash    []byte `db:"code_hash"`
 	StorageRoot string `db:"storage_root"`
 }
+
+// BlockSummaryModel is a db model for the roots of an eth.header_cids row and the number of rows indexed beneath it
+type BlockSummaryModel struct {
+	HeaderID     int64  `db:"id"`
+	StateRoot    string `db:"state_root"`
+	TxRoot       string `db:"tx_root"`
+	RctRoot      string `db:"receipt_root"`
+	UncleRoot    string `db:"uncle_root"`
+	UncleCount   int64  `db:"uncle_count"`
+	TxCount      int64  `db:"tx_count"`
+	RctCount     int64  `db:"rct_count"`
+	LogCount     int64  `db:"log_count"`
+	StateCount   int64  `db:"state_count"`
+	StorageCount int64  `db:"storage_count"`
+	// rows derived from the block, so that blocks indexed before a derived table existed are reindexed to fill it in
+	AccessListCount    int64 `db:"access_list_count"`
+	AddressTxCount     int64 `db:"address_tx_count"`
+	ContractCount      int64 `db:"contract_count"`
+	AccountChangeCount int64 `db:"account_change_count"`
+	TokenTransferCount int64 `db:"token_transfer_count"`
+	DecodedEventCount  int64 `db:"decoded_event_count"`
+	StatsCount         int64 `db:"stats_count"`
+}
+
+// BlockStatsModel is the db model for eth.block_stats
+type BlockStatsModel struct {
+	ID               int64  `db:"id"`
+	HeaderID         int64  `db:"header_id"`
+	BlockNumber      string `db:"block_number"`
+	Timestamp        uint64 `db:"timestamp"`
+	Canonical        bool   `db:"canonical"`
+	TxCount          int64  `db:"tx_count"`
+	GasUsed          uint64 `db:"gas_used"`
+	GasLimit         uint64 `db:"gas_limit"`
+	TotalFees        string `db:"total_fees"`
+	Reward           string `db:"reward"`
+	Producer         string `db:"producer"`
+	UncleCount       int64  `db:"uncle_count"`
+	StateNodeCount   int64  `db:"state_node_count"`
+	StorageNodeCount int64  `db:"storage_node_count"`
+}
+
+// BlockStatsRollupModel is the db model for eth.hourly_block_stats and eth.daily_block_stats
+type BlockStatsRollupModel struct {
+	PeriodStart      uint64 `db:"period_start"`
+	BlockCount       int64  `db:"block_count"`
+	TxCount          int64  `db:"tx_count"`
+	GasUsed          uint64 `db:"gas_used"`
+	GasLimit         uint64 `db:"gas_limit"`
+	TotalFees        string `db:"total_fees"`
+	Reward           string `db:"reward"`
+	UncleCount       int64  `db:"uncle_count"`
+	StateNodeCount   int64  `db:"state_node_count"`
+	StorageNodeCount int64  `db:"storage_node_count"`
+	FeeBlockCount    int64  `db:"fee_block_count"`
+}
+
+// CanonicalityModel is a db model for what is known about the place of an eth.header_cids row in the canonical chain
+type CanonicalityModel struct {
+	Canonical      bool `db:"canonical"`
+	ChildCanonical bool `db:"child_canonical"`
+	Heaviest       bool `db:"heaviest"`
+	FillsGap       bool `db:"fills_gap"`
+}
@@ -21,6 +21,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/statediff"
 )
@@ -89,3 +92,97 @@ func (fetcher *PayloadFetcher) FetchAt(blockHeights []uint64) ([]statediff.Paylo
 	}
 	return results, nil
 }
+
+const (
+	blockRlpMethod = "debug_getBlockRlp"
+	receiptMethod  = "eth_getTransactionReceipt"
+)
+
+// BlockFetcher satisfies the Fetcher interface by fetching only the block and receipts at each height,
+// without having the node build a statediff
+// The payloads it returns carry no state diff and no total difficulty, so they only suit transformers that need neither, such as the RewardTransformer
+type BlockFetcher struct {
+	// BlockFetcher is thread-safe as long as the underlying client is thread-safe, since it has/modifies no other state
+	client  BatchClient
+	timeout time.Duration
+}
+
+// NewBlockFetcher returns a BlockFetcher
+func NewBlockFetcher(bc BatchClient, timeout time.Duration) *BlockFetcher {
+	return &BlockFetcher{
+		client:  bc,
+		timeout: timeout,
+	}
+}
+
+// FetchAt fetches the blocks at the given block heights, and the receipts of their transactions
+// Calls debug_getBlockRlp(number uint64) (string, error) for each block in one batch,
+// then eth_getTransactionReceipt(hash common.Hash) (map[string]interface{}, error) for each of their transactions in another
+func (fetcher *BlockFetcher) FetchAt(blockHeights []uint64) ([]statediff.Payload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetcher.timeout)
+	defer cancel()
+	blockBatch := make([]rpc.BatchElem, 0, len(blockHeights))
+	for _, height := range blockHeights {
+		blockBatch = append(blockBatch, rpc.BatchElem{
+			Method: blockRlpMethod,
+			Args:   []interface{}{height},
+			Result: new(string),
+		})
+	}
+	if err := fetcher.client.BatchCallContext(ctx, blockBatch); err != nil {
+		return nil, fmt.Errorf("ethereum BlockFetcher batch err for block range %d-%d: %s", blockHeights[0], blockHeights[len(blockHeights)-1], err.Error())
+	}
+	blocks := make([]*types.Block, 0, len(blockHeights))
+	blockRlps := make([][]byte, 0, len(blockHeights))
+	rctBatch := make([]rpc.BatchElem, 0)
+	for _, batchElem := range blockBatch {
+		if batchElem.Error != nil {
+			return nil, fmt.Errorf("ethereum BlockFetcher err at blockheight %d: %s", batchElem.Args[0].(uint64), batchElem.Error.Error())
+		}
+		blockRlp := common.FromHex(*batchElem.Result.(*string))
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(blockRlp, block); err != nil {
+			return nil, fmt.Errorf("ethereum BlockFetcher err decoding block at blockheight %d: %s", batchElem.Args[0].(uint64), err.Error())
+		}
+		blocks = append(blocks, block)
+		blockRlps = append(blockRlps, blockRlp)
+		for _, trx := range block.Transactions() {
+			rctBatch = append(rctBatch, rpc.BatchElem{
+				Method: receiptMethod,
+				Args:   []interface{}{trx.Hash()},
+				Result: new(types.Receipt),
+			})
+		}
+	}
+	if len(rctBatch) > 0 {
+		if err := fetcher.client.BatchCallContext(ctx, rctBatch); err != nil {
+			return nil, fmt.Errorf("ethereum BlockFetcher receipt batch err for block range %d-%d: %s", blockHeights[0], blockHeights[len(blockHeights)-1], err.Error())
+		}
+	}
+	results := make([]statediff.Payload, 0, len(blocks))
+	for i, block := range blocks {
+		receipts := make(types.Receipts, 0, len(block.Transactions()))
+		for _, trx := range block.Transactions() {
+			batchElem := rctBatch[0]
+			rctBatch = rctBatch[1:]
+			if batchElem.Error != nil {
+				return nil, fmt.Errorf("ethereum BlockFetcher err fetching receipt of tx %s: %s", trx.Hash().Hex(), batchElem.Error.Error())
+			}
+			receipt := batchElem.Result.(*types.Receipt)
+			// a null result leaves the receipt empty
+			if receipt.TxHash != trx.Hash() {
+				return nil, fmt.Errorf("ethereum BlockFetcher found no receipt for tx %s at blockheight %d", trx.Hash().Hex(), block.NumberU64())
+			}
+			receipts = append(receipts, receipt)
+		}
+		receiptsRlp, err := rlp.EncodeToBytes(receipts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, statediff.Payload{
+			BlockRlp:    blockRlps[i],
+			ReceiptsRlp: receiptsRlp,
+		})
+	}
+	return results, nil
+}
@@ -19,6 +19,8 @@ package eth_test
 import (
 	"time"
 
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/statediff"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -61,3 +63,49 @@ var _ = Describe("StateDiffFetcher", func() {
 		})
 	})
 })
+
+var _ = Describe("BlockFetcher", func() {
+	expectReceipts := func(payload statediff.Payload, expected types.Receipts) {
+		receipts := make(types.Receipts, 0)
+		err := rlp.DecodeBytes(payload.ReceiptsRlp, &receipts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(receipts)).To(Equal(len(expected)))
+		for i, receipt := range receipts {
+			Expect(receipt.Type).To(Equal(expected[i].Type))
+			Expect(receipt.CumulativeGasUsed).To(Equal(expected[i].CumulativeGasUsed))
+			Expect(receipt.PostState).To(Equal(expected[i].PostState))
+			Expect(len(receipt.Logs)).To(Equal(len(expected[i].Logs)))
+		}
+	}
+
+	It("Batch calls debug_getBlockRlp and eth_getTransactionReceipt instead of statediff_stateDiffAt", func() {
+		mc := mocks.NewBlockClient(map[uint64]*types.Block{mocks.BlockNumber.Uint64(): mocks.MockBlock}, mocks.MockReceipts)
+		payloads, err := eth.NewBlockFetcher(mc, time.Second*60).FetchAt([]uint64{mocks.BlockNumber.Uint64()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(payloads)).To(Equal(1))
+		Expect(payloads[0].BlockRlp).To(Equal(mocks.MockBlockRlp))
+		Expect(payloads[0].StateObjectRlp).To(BeEmpty())
+		expectReceipts(payloads[0], mocks.MockReceipts)
+		Expect(mc.Methods).To(ConsistOf("debug_getBlockRlp", "eth_getTransactionReceipt", "eth_getTransactionReceipt", "eth_getTransactionReceipt"))
+	})
+
+	It("Fetches the receipts of typed txs", func() {
+		mc := mocks.NewBlockClient(map[uint64]*types.Block{mocks.BlockNumber.Uint64(): mocks.MockTypedBlock}, mocks.MockTypedReceipts)
+		payloads, err := eth.NewBlockFetcher(mc, time.Second*60).FetchAt([]uint64{mocks.BlockNumber.Uint64()})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(payloads)).To(Equal(1))
+		expectReceipts(payloads[0], mocks.MockTypedReceipts)
+	})
+
+	It("Errors when a tx has no receipt", func() {
+		mc := mocks.NewBlockClient(map[uint64]*types.Block{mocks.BlockNumber.Uint64(): mocks.MockBlock}, mocks.MockReceipts[:2])
+		_, err := eth.NewBlockFetcher(mc, time.Second*60).FetchAt([]uint64{mocks.BlockNumber.Uint64()})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Errors when a block is missing", func() {
+		mc := mocks.NewBlockClient(map[uint64]*types.Block{}, nil)
+		_, err := eth.NewBlockFetcher(mc, time.Second*60).FetchAt([]uint64{mocks.BlockNumber.Uint64()})
+		Expect(err).To(HaveOccurred())
+	})
+})
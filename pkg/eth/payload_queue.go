@@ -0,0 +1,194 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/statediff"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/prom"
+)
+
+// PayloadSize returns the number of bytes held by a statediff payload
+func PayloadSize(payload statediff.Payload) uint64 {
+	size := len(payload.BlockRlp) + len(payload.ReceiptsRlp) + len(payload.StateObjectRlp)
+	if payload.TotalDifficulty != nil {
+		size += len(payload.TotalDifficulty.Bytes())
+	}
+	return uint64(size)
+}
+
+// PayloadQueue is a FIFO queue of statediff payloads bounded by their total size in bytes
+// When a new payload does not fit, the oldest payloads are dropped to make room for it
+// It is safe for use by multiple producers and consumers
+type PayloadQueue struct {
+	lock     sync.Mutex
+	payloads []statediff.Payload
+	bytes    uint64
+	maxBytes uint64
+	ready    chan struct{}
+}
+
+// NewPayloadQueue returns a new PayloadQueue which holds up to maxBytes worth of payloads
+func NewPayloadQueue(maxBytes uint64) *PayloadQueue {
+	return &PayloadQueue{
+		payloads: make([]statediff.Payload, 0),
+		maxBytes: maxBytes,
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// Push adds a payload to the back of the queue and returns the number of old payloads dropped to make room for it
+// A payload larger than the whole queue is still accepted once every other payload has been dropped
+func (q *PayloadQueue) Push(payload statediff.Payload) int {
+	size := PayloadSize(payload)
+	q.lock.Lock()
+	dropped := 0
+	for len(q.payloads) > 0 && q.bytes+size > q.maxBytes {
+		q.bytes -= PayloadSize(q.payloads[0])
+		q.payloads[0] = statediff.Payload{}
+		q.payloads = q.payloads[1:]
+		dropped++
+	}
+	q.payloads = append(q.payloads, payload)
+	q.bytes += size
+	q.report()
+	q.lock.Unlock()
+	q.signal()
+	return dropped
+}
+
+// Pop removes and returns the payload at the front of the queue
+// It returns false if the queue is empty
+func (q *PayloadQueue) Pop() (statediff.Payload, bool) {
+	q.lock.Lock()
+	if len(q.payloads) == 0 {
+		q.lock.Unlock()
+		return statediff.Payload{}, false
+	}
+	payload := q.payloads[0]
+	q.payloads[0] = statediff.Payload{}
+	q.payloads = q.payloads[1:]
+	q.bytes -= PayloadSize(payload)
+	remaining := len(q.payloads)
+	q.report()
+	q.lock.Unlock()
+	// wake up another consumer if there is more work waiting
+	if remaining > 0 {
+		q.signal()
+	}
+	return payload, true
+}
+
+// Ready returns a channel that receives when there are payloads available to Pop
+func (q *PayloadQueue) Ready() <-chan struct{} {
+	return q.ready
+}
+
+// Len returns the number of payloads in the queue
+func (q *PayloadQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.payloads)
+}
+
+// Bytes returns the total size of the payloads in the queue
+func (q *PayloadQueue) Bytes() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.bytes
+}
+
+func (q *PayloadQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// report must be called with the lock held
+func (q *PayloadQueue) report() {
+	prom.SetLenPayloadChan(len(q.payloads))
+	prom.SetPayloadQueueBytes(q.bytes)
+}
+
+// ByteLimiter bounds the total size in bytes of the payloads held by a set of workers
+// Workers Wait for room before fetching, Acquire the size of what they fetched, and Release it once it is processed
+// A nil ByteLimiter places no bound
+type ByteLimiter struct {
+	cond     *sync.Cond
+	inUse    uint64
+	maxBytes uint64
+}
+
+// NewByteLimiter returns a new ByteLimiter which allows up to maxBytes to be held at once
+// A maxBytes of 0 places no bound, but the bytes held are still tracked
+func NewByteLimiter(maxBytes uint64) *ByteLimiter {
+	return &ByteLimiter{
+		cond:     sync.NewCond(new(sync.Mutex)),
+		maxBytes: maxBytes,
+	}
+}
+
+// Wait blocks until the bytes currently held are below the limit
+func (l *ByteLimiter) Wait() {
+	if l == nil || l.maxBytes == 0 {
+		return
+	}
+	l.cond.L.Lock()
+	for l.inUse >= l.maxBytes {
+		l.cond.Wait()
+	}
+	l.cond.L.Unlock()
+}
+
+// Acquire records that n more bytes are held
+func (l *ByteLimiter) Acquire(n uint64) {
+	if l == nil {
+		return
+	}
+	l.cond.L.Lock()
+	l.inUse += n
+	prom.SetHeldPayloadBytes(l.inUse)
+	l.cond.L.Unlock()
+}
+
+// Release records that n bytes are no longer held
+func (l *ByteLimiter) Release(n uint64) {
+	if l == nil {
+		return
+	}
+	l.cond.L.Lock()
+	if n > l.inUse {
+		n = l.inUse
+	}
+	l.inUse -= n
+	prom.SetHeldPayloadBytes(l.inUse)
+	l.cond.L.Unlock()
+	l.cond.Broadcast()
+}
+
+// InUse returns the number of bytes currently held
+func (l *ByteLimiter) InUse() uint64 {
+	if l == nil {
+		return 0
+	}
+	l.cond.L.Lock()
+	defer l.cond.L.Unlock()
+	return l.inUse
+}
@@ -0,0 +1,95 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/statediff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("PayloadQueue", func() {
+	payload := func(size int) statediff.Payload {
+		return statediff.Payload{BlockRlp: make([]byte, size)}
+	}
+	It("Drops the oldest payloads once the byte limit is reached", func() {
+		queue := eth.NewPayloadQueue(100)
+		Expect(queue.Push(payload(40))).To(Equal(0))
+		Expect(queue.Push(payload(50))).To(Equal(0))
+		Expect(queue.Bytes()).To(Equal(uint64(90)))
+		Expect(queue.Push(payload(30))).To(Equal(1))
+		Expect(queue.Len()).To(Equal(2))
+		Expect(queue.Bytes()).To(Equal(uint64(80)))
+		<-queue.Ready()
+		p, ok := queue.Pop()
+		Expect(ok).To(BeTrue())
+		Expect(len(p.BlockRlp)).To(Equal(50))
+		p, ok = queue.Pop()
+		Expect(ok).To(BeTrue())
+		Expect(len(p.BlockRlp)).To(Equal(30))
+		_, ok = queue.Pop()
+		Expect(ok).To(BeFalse())
+		Expect(queue.Bytes()).To(Equal(uint64(0)))
+	})
+
+	It("Accepts a payload larger than the limit when it is empty", func() {
+		queue := eth.NewPayloadQueue(10)
+		Expect(queue.Push(payload(5))).To(Equal(0))
+		Expect(queue.Push(payload(20))).To(Equal(1))
+		Expect(queue.Len()).To(Equal(1))
+		Expect(queue.Bytes()).To(Equal(uint64(20)))
+	})
+})
+
+var _ = Describe("ByteLimiter", func() {
+	It("Blocks Wait until enough bytes are released", func() {
+		limiter := eth.NewByteLimiter(100)
+		limiter.Wait()
+		limiter.Acquire(150)
+		done := make(chan struct{})
+		go func() {
+			limiter.Wait()
+			close(done)
+		}()
+		Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+		limiter.Release(100)
+		Eventually(done).Should(BeClosed())
+		Expect(limiter.InUse()).To(Equal(uint64(50)))
+	})
+
+	It("Does not block without a limit", func() {
+		limiter := eth.NewByteLimiter(0)
+		limiter.Wait()
+		limiter.Acquire(10)
+		limiter.Wait()
+		Expect(limiter.InUse()).To(Equal(uint64(10)))
+		limiter.Release(10)
+		Expect(limiter.InUse()).To(Equal(uint64(0)))
+	})
+
+	It("Does not block when nil", func() {
+		var limiter *eth.ByteLimiter
+		limiter.Acquire(10)
+		limiter.Wait()
+		limiter.Release(10)
+		Expect(limiter.InUse()).To(Equal(uint64(0)))
+	})
+})
@@ -0,0 +1,60 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// errMissingSeal is returned when the extra data of a header is too short to hold a Clique seal
+var errMissingSeal = errors.New("extra data is missing the 65 byte signer seal")
+
+// BlockProducer returns the address that produced the block with the provided header
+// On proof-of-authority (Clique) chains the coinbase is not the producer, it holds the signer vote of the block (and is usually zero),
+// so the producer is the signer recovered from the seal at the end of the header's extra data
+// It returns an empty string when no signer can be recovered, e.g. for the unsigned genesis block
+func BlockProducer(config *params.ChainConfig, header *types.Header) string {
+	if config.Clique == nil {
+		return header.Coinbase.String()
+	}
+	signer, err := CliqueSigner(header)
+	if err != nil {
+		return ""
+	}
+	return signer.String()
+}
+
+// CliqueSigner recovers the address of the signer that sealed the Clique header
+func CliqueSigner(header *types.Header) (common.Address, error) {
+	if len(header.Extra) < crypto.SignatureLength {
+		return common.Address{}, errMissingSeal
+	}
+	signature := header.Extra[len(header.Extra)-crypto.SignatureLength:]
+	pubkey, err := crypto.Ecrecover(clique.SealHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
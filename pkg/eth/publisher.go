@@ -21,6 +21,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 	"github.com/jmoiron/sqlx"
@@ -40,13 +42,15 @@ type Publisher interface {
 // It interfaces directly with the public.blocks table of PG-IPFS rather than going through an ipfs intermediary
 // It publishes and indexes IPLDs together in a single sqlx.Tx
 type IPLDPublisher struct {
-	indexer *CIDIndexer
+	chainConfig *params.ChainConfig
+	indexer     *CIDIndexer
 }
 
 // NewIPLDPublisher creates a pointer to a new IPLDPublisher which satisfies the IPLDPublisher interface
-func NewIPLDPublisher(db *postgres.DB) *IPLDPublisher {
+func NewIPLDPublisher(chainConfig *params.ChainConfig, db *postgres.DB) *IPLDPublisher {
 	return &IPLDPublisher{
-		indexer: NewCIDIndexer(db),
+		chainConfig: chainConfig,
+		indexer:     NewCIDIndexer(db),
 	}
 }
 
@@ -58,6 +62,10 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		return err
 	}
 
+	// Make sure there are partitions to write this block into
+	if err := pub.indexer.ensurePartitions(payload.Block.NumberU64()); err != nil {
+		return err
+	}
 	// Begin new db tx
 	tx, err := pub.indexer.db.Beginx()
 	if err != nil {
@@ -70,7 +78,7 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		} else if err != nil {
 			shared.Rollback(tx)
 		} else {
-			err = tx.Commit()
+			err = shared.Commit(tx)
 		}
 	}()
 
@@ -90,7 +98,7 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 	if err := shared.PublishIPLD(tx, headerNode); err != nil {
 		return err
 	}
-	reward := CalcEthBlockReward(payload.Block.Header(), payload.Block.Uncles(), payload.Block.Transactions(), payload.Receipts)
+	reward := CalcEthBlockReward(pub.chainConfig, payload.Block.Header(), payload.Block.Uncles(), payload.Block.Transactions(), payload.Receipts)
 	header := HeaderModel{
 		CID:             headerNode.Cid().String(),
 		MhKey:           shared.MultihashKeyFromCID(headerNode.Cid()),
@@ -105,7 +113,9 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		TxRoot:          payload.Block.TxHash().String(),
 		UncleRoot:       payload.Block.UncleHash().String(),
 		Timestamp:       payload.Block.Time(),
+		Producer:        BlockProducer(pub.chainConfig, payload.Block.Header()),
 	}
+	setHeaderMetaData(&header, payload.Block.Header())
 	headerID, err := pub.indexer.indexHeaderCID(tx, header)
 	if err != nil {
 		return err
@@ -116,7 +126,7 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		if err := shared.PublishIPLD(tx, uncleNode); err != nil {
 			return err
 		}
-		uncleReward := CalcUncleMinerReward(payload.Block.Number().Uint64(), uncleNode.Number.Uint64())
+		uncleReward := CalcUncleMinerReward(pub.chainConfig, payload.Block.Number().Uint64(), uncleNode.Number.Uint64())
 		uncle := UncleModel{
 			CID:        uncleNode.Cid().String(),
 			MhKey:      shared.MultihashKeyFromCID(uncleNode.Cid()),
@@ -124,12 +134,16 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 			BlockHash:  uncleNode.Hash().String(),
 			Reward:     uncleReward.String(),
 		}
+		setUncleMetaData(&uncle, uncleNode.Header)
 		if err := pub.indexer.indexUncleCID(tx, uncle, headerID); err != nil {
 			return err
 		}
 	}
 
 	// Publish and index txs and receipts
+	contracts := make([]ContractModel, 0)
+	var logIndex int64
+	var prevCumulativeGasUsed uint64
 	for i, txNode := range txNodes {
 		if err := shared.PublishIPLD(tx, txNode); err != nil {
 			return err
@@ -141,6 +155,8 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		txModel := payload.TxMetaData[i]
 		txModel.CID = txNode.Cid().String()
 		txModel.MhKey = shared.MultihashKeyFromCID(txNode.Cid())
+		setTxMetaData(&txModel, txNode.Transaction)
+		setTxFees(&txModel, txNode.Transaction, payload.Block.BaseFee(), payload.Receipts[i].CumulativeGasUsed-prevCumulativeGasUsed)
 		txID, err := pub.indexer.indexTransactionCID(tx, txModel, headerID)
 		if err != nil {
 			return err
@@ -148,53 +164,109 @@ func (pub *IPLDPublisher) Publish(payload ConvertedPayload) error {
 		rctModel := payload.ReceiptMetaData[i]
 		rctModel.CID = rctNode.Cid().String()
 		rctModel.MhKey = shared.MultihashKeyFromCID(rctNode.Cid())
+		rctModel.Logs = LogModels(payload.Receipts[i].Logs, logIndex)
+		logIndex += int64(len(rctModel.Logs))
+		setReceiptMetaData(&rctModel, payload.Receipts[i], prevCumulativeGasUsed)
+		prevCumulativeGasUsed = payload.Receipts[i].CumulativeGasUsed
 		if len(payload.Receipts[i].PostState) == 0 {
 			rctModel.PostStatus = payload.Receipts[i].Status
 		} else {
 			rctModel.PostState = common.Bytes2Hex(payload.Receipts[i].PostState)
 		}
-		if err := pub.indexer.indexReceiptCID(tx, rctModel, txID); err != nil {
+		if err := pub.indexer.indexReceiptCID(tx, rctModel, txID, header.BlockNumber); err != nil {
 			return err
 		}
+		if rctModel.Contract != "" {
+			contracts = append(contracts, ContractModel{
+				TxID:        txID,
+				BlockNumber: payload.Block.Number().String(),
+				Address:     rctModel.Contract,
+				Creator:     txModel.Src,
+			})
+		}
 	}
 
 	// Publish and index state and storage
-	err = pub.publishAndIndexStateAndStorage(tx, payload, headerID)
+	codeHashes, err := pub.publishAndIndexStateAndStorage(tx, payload, headerID)
+	if err != nil {
+		return err
+	}
 
+	// Index the contracts created in this block
+	for _, contract := range contracts {
+		leafKey := crypto.Keccak256Hash(common.HexToAddress(contract.Address).Bytes()).String()
+		if codeHash, ok := codeHashes[leafKey]; ok {
+			if err = setContractCode(&contract, codeHash); err != nil {
+				return err
+			}
+		}
+		if err = pub.indexer.indexContract(tx, contract); err != nil {
+			return err
+		}
+	}
+
+	// Summarize the block, and update the canonical chain
+	if err = pub.indexer.indexBlockStats(tx, headerID, header.BlockNumber); err != nil {
+		return err
+	}
+	err = pub.indexer.indexCanonicalState(tx, header.BlockNumber, header.BlockHash)
 	return err // return err variable explicitly so that we return the err = tx.Commit() assignment in the defer
 }
 
-func (pub *IPLDPublisher) publishAndIndexStateAndStorage(tx *sqlx.Tx, payload ConvertedPayload, headerID int64) error {
+// publishAndIndexStateAndStorage returns the code hashes of the accounts in the payload, keyed by their state leaf keys
+func (pub *IPLDPublisher) publishAndIndexStateAndStorage(tx *sqlx.Tx, payload ConvertedPayload, headerID int64) (map[string][]byte, error) {
 	// Publish and index state and storage
+	codeHashes := make(map[string][]byte)
+	// a removed leaf whose key is written elsewhere in the same payload was moved, not destroyed
+	writtenAccounts := make(map[common.Hash]bool)
 	for _, stateNode := range payload.StateNodes {
+		if stateNode.Type == sdtypes.Leaf {
+			writtenAccounts[stateNode.LeafKey] = true
+		}
+	}
+	for _, stateNode := range payload.StateNodes {
+		// removed nodes have no value to publish, so they are only recorded
+		if stateNode.Type == sdtypes.Removed {
+			removal := StateRemovalModel{
+				BlockNumber: payload.Block.Number().String(),
+				Path:        stateNode.Path,
+				StateKey:    stateNode.LeafKey.String(),
+				Destroyed:   stateNode.LeafKey != nullHash && !writtenAccounts[stateNode.LeafKey],
+			}
+			if err := pub.indexer.indexStateRemoval(tx, removal, headerID); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		stateCIDStr, err := shared.PublishRaw(tx, ipld.MEthStateTrie, multihash.KECCAK_256, stateNode.Value)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		mhKey, _ := shared.MultihashKeyFromCIDString(stateCIDStr)
 		stateModel := StateNodeModel{
-			Path:     stateNode.Path,
-			StateKey: stateNode.LeafKey.String(),
-			CID:      stateCIDStr,
-			MhKey:    mhKey,
-			NodeType: ResolveFromNodeType(stateNode.Type),
+			BlockNumber: payload.Block.Number().String(),
+			Path:        stateNode.Path,
+			StateKey:    stateNode.LeafKey.String(),
+			CID:         stateCIDStr,
+			MhKey:       mhKey,
+			NodeType:    ResolveFromNodeType(stateNode.Type),
 		}
 		stateID, err := pub.indexer.indexStateCID(tx, stateModel, headerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// If we have a leaf, decode and index the account data and any associated storage diffs
 		if stateNode.Type == sdtypes.Leaf {
 			var i []interface{}
 			if err := rlp.DecodeBytes(stateNode.Value, &i); err != nil {
-				return err
+				return nil, err
 			}
 			if len(i) != 2 {
-				return fmt.Errorf("eth IPLDPublisher expected state leaf node rlp to decode into two elements")
+				return nil, fmt.Errorf("eth IPLDPublisher expected state leaf node rlp to decode into two elements")
 			}
 			var account state.Account
 			if err := rlp.DecodeBytes(i[1].([]byte), &account); err != nil {
-				return err
+				return nil, err
 			}
 			accountModel := StateAccountModel{
 				Balance:     account.Balance.String(),
@@ -203,26 +275,61 @@ func (pub *IPLDPublisher) publishAndIndexStateAndStorage(tx *sqlx.Tx, payload Co
 				StorageRoot: account.Root.String(),
 			}
 			if err := pub.indexer.indexStateAccount(tx, accountModel, stateID); err != nil {
-				return err
+				return nil, err
+			}
+			if err := pub.indexer.indexAccountChange(tx, stateModel.StateKey, stateModel.BlockNumber, accountModel, headerID); err != nil {
+				return nil, err
+			}
+			codeHashes[stateModel.StateKey] = account.CodeHash
+			storageNodes := payload.StorageNodes[common.Bytes2Hex(stateNode.Path)]
+			writtenSlots := make(map[common.Hash]bool)
+			for _, storageNode := range storageNodes {
+				if storageNode.Type == sdtypes.Leaf {
+					writtenSlots[storageNode.LeafKey] = true
+				}
 			}
-			for _, storageNode := range payload.StorageNodes[common.Bytes2Hex(stateNode.Path)] {
+			for _, storageNode := range storageNodes {
+				if storageNode.Type == sdtypes.Removed {
+					removal := StorageRemovalModel{
+						BlockNumber: payload.Block.Number().String(),
+						StateKey:    stateModel.StateKey,
+						Path:        storageNode.Path,
+						StorageKey:  storageNode.LeafKey.Hex(),
+						Cleared:     storageNode.LeafKey != nullHash && !writtenSlots[storageNode.LeafKey],
+					}
+					if err := pub.indexer.indexStorageRemoval(tx, removal, headerID); err != nil {
+						return nil, err
+					}
+					continue
+				}
 				storageCIDStr, err := shared.PublishRaw(tx, ipld.MEthStorageTrie, multihash.KECCAK_256, storageNode.Value)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				mhKey, _ := shared.MultihashKeyFromCIDString(storageCIDStr)
 				storageModel := StorageNodeModel{
-					Path:       storageNode.Path,
-					StorageKey: storageNode.LeafKey.Hex(),
-					CID:        storageCIDStr,
-					MhKey:      mhKey,
-					NodeType:   ResolveFromNodeType(storageNode.Type),
+					BlockNumber: payload.Block.Number().String(),
+					Path:        storageNode.Path,
+					StorageKey:  storageNode.LeafKey.Hex(),
+					CID:         storageCIDStr,
+					MhKey:       mhKey,
+					NodeType:    ResolveFromNodeType(storageNode.Type),
+				}
+				if storageNode.Type == sdtypes.Leaf {
+					if storageModel.Value, err = storageLeafValue(storageNode.Value); err != nil {
+						return nil, err
+					}
 				}
 				if err := pub.indexer.indexStorageCID(tx, storageModel, stateID); err != nil {
-					return err
+					return nil, err
+				}
+				if storageNode.Type == sdtypes.Leaf {
+					if err := pub.indexer.indexDecodedStorage(tx, stateModel.StateKey, storageModel.StorageKey, storageModel.BlockNumber, storageModel.Value, headerID); err != nil {
+						return nil, err
+					}
 				}
 			}
 		}
 	}
-	return nil
+	return codeHashes, nil
 }
@@ -17,7 +17,13 @@
 package eth_test
 
 import (
+	"database/sql"
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
 	"github.com/ipfs/go-ipfs-ds-help"
@@ -41,7 +47,7 @@ var _ = Describe("PublishAndIndexer", func() {
 	BeforeEach(func() {
 		db, err = shared.SetupDB()
 		Expect(err).ToNot(HaveOccurred())
-		repo = eth.NewIPLDPublisher(db)
+		repo = eth.NewIPLDPublisher(params.MainnetChainConfig, db)
 	})
 	AfterEach(func() {
 		eth.TearDownDB(db)
@@ -66,7 +72,7 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(header.CID).To(Equal(mocks.HeaderCID.String()))
 			Expect(header.TD).To(Equal(mocks.MockBlock.Difficulty().String()))
-			Expect(header.Reward).To(Equal("5000000000000000000"))
+			Expect(header.Reward).To(Equal("5000000000000036250"))
 			dc, err := cid.Decode(header.CID)
 			Expect(err).ToNot(HaveOccurred())
 			mhKey := dshelp.MultihashToDsKey(dc.Hash())
@@ -229,5 +235,283 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(data).To(Equal(mocks.StorageLeafNode))
 		})
+
+		It("Publishes and indexes typed txs along with their access lists", func() {
+			err = repo.Publish(mocks.MockTypedConvertedPayload)
+			Expect(err).ToNot(HaveOccurred())
+			txs := make([]eth.TxModel, 0)
+			pgStr := `SELECT transaction_cids.id, transaction_cids.tx_hash, transaction_cids.tx_type, transaction_cids.mh_key
+				FROM eth.transaction_cids INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+				WHERE header_cids.block_number = $1
+				ORDER BY transaction_cids.index`
+			err = db.Select(&txs, pgStr, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(txs)).To(Equal(2))
+			for i, trx := range mocks.MockTypedTransactions {
+				Expect(txs[i].TxHash).To(Equal(trx.Hash().String()))
+				Expect(txs[i].Type).To(Equal(int64(trx.Type())))
+				// the IPLD is the EIP-2718 envelope of the tx
+				var data []byte
+				err = db.Get(&data, ipfsPgGet, txs[i].MhKey)
+				Expect(err).ToNot(HaveOccurred())
+				envelope, err := trx.MarshalBinary()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).To(Equal(envelope))
+
+				elements := make([]eth.AccessListElementModel, 0)
+				pgStr = `SELECT tx_id, index, address, storage_keys FROM eth.access_list_elements WHERE tx_id = $1 ORDER BY index`
+				err = db.Select(&elements, pgStr, txs[i].ID)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(elements).To(Equal([]eth.AccessListElementModel{
+					{
+						TxID:        txs[i].ID,
+						Index:       0,
+						Address:     mocks.AnotherAddress.String(),
+						StorageKeys: []string{common.HexToHash("0x01").Hex(), common.HexToHash("0x02").Hex()},
+					},
+					{
+						TxID:        txs[i].ID,
+						Index:       1,
+						Address:     mocks.ContractAddress.String(),
+						StorageKeys: []string{},
+					},
+				}))
+			}
+		})
+	})
+
+	Describe("Canonical flag", func() {
+		var (
+			block1    = newTimedMockBlock(1, 10, common.Hash{})
+			block2    = newTimedMockBlock(2, 20, block1.Hash())
+			forkBlock = newTimedMockBlock(2, 21, block1.Hash())
+			block3    = newTimedMockBlock(3, 30, block2.Hash())
+		)
+		publish := func(block *types.Block, td int64) {
+			payload := mocks.MockConvertedPayload
+			payload.Block = block
+			payload.TotalDifficulty = big.NewInt(td)
+			err := repo.Publish(payload)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		canonicalHashes := func() []string {
+			hashes := make([]string, 0)
+			err := db.Select(&hashes, `SELECT block_hash FROM eth.header_cids WHERE canonical ORDER BY block_number`)
+			Expect(err).ToNot(HaveOccurred())
+			return hashes
+		}
+
+		It("Moves the flag to the chain with the most total difficulty", func() {
+			publish(block1, 10)
+			publish(block2, 20)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), block2.Hash().String()}))
+			publish(forkBlock, 21)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), forkBlock.Hash().String()}))
+			publish(block3, 30)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), block2.Hash().String(), block3.Hash().String()}))
+		})
+
+		It("Flags headers that fill a gap beneath the canonical chain", func() {
+			publish(block3, 30)
+			publish(block1, 10)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), block3.Hash().String()}))
+			publish(forkBlock, 21)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), block3.Hash().String()}))
+			publish(block2, 20)
+			Expect(canonicalHashes()).To(Equal([]string{block1.Hash().String(), block2.Hash().String(), block3.Hash().String()}))
+		})
+
+		It("Rolls up the stats of the canonical blocks only", func() {
+			retriever := eth.NewGapRetriever(db)
+			txCount := int64(len(mocks.MockTransactions))
+			expectRollups := func(blocks int64) {
+				// the rollups only change when they are refreshed
+				_, err := eth.NewBlockStatsRoller(db, 0).Roll()
+				Expect(err).ToNot(HaveOccurred())
+				hourly, err := retriever.RetrieveHourlyBlockStats(0, 3600)
+				Expect(err).ToNot(HaveOccurred())
+				daily, err := retriever.RetrieveDailyBlockStats(0, 86400)
+				Expect(err).ToNot(HaveOccurred())
+				for _, rollups := range [][]eth.BlockStatsRollupModel{hourly, daily} {
+					Expect(len(rollups)).To(Equal(1))
+					Expect(rollups[0].PeriodStart).To(Equal(uint64(0)))
+					Expect(rollups[0].BlockCount).To(Equal(blocks))
+					Expect(rollups[0].TxCount).To(Equal(blocks * txCount))
+					Expect(rollups[0].GasUsed).To(Equal(uint64(blocks) * mocks.MockHeader.GasUsed))
+					Expect(rollups[0].FeeBlockCount).To(Equal(blocks))
+				}
+			}
+			publish(block1, 10)
+			publish(block2, 20)
+			expectRollups(2)
+
+			// the fork replaces block 2 in the rollups, and block 2 moves back in once it is extended
+			publish(forkBlock, 21)
+			expectRollups(2)
+			stats := make([]eth.BlockStatsModel, 0)
+			err := db.Select(&stats, `SELECT * FROM eth.block_stats WHERE block_number = 2 ORDER BY timestamp`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(stats)).To(Equal(2))
+			Expect(stats[0].Canonical).To(BeFalse())
+			Expect(stats[1].Canonical).To(BeTrue())
+			Expect(stats[1].TxCount).To(Equal(txCount))
+			Expect(stats[1].Producer).To(Equal(mocks.MockHeader.Coinbase.String()))
+			Expect(stats[1].StateNodeCount).To(Equal(int64(len(mocks.MockConvertedPayload.StateNodes))))
+			publish(block3, 30)
+			expectRollups(3)
+		})
+
+		It("Leaves the fees of a block unknown while a tx has no effective gas price, and rolls up only the known fees", func() {
+			publish(block1, 10)
+			publish(block2, 20)
+			_, err := eth.NewBlockStatsRoller(db, 0).Roll()
+			Expect(err).ToNot(HaveOccurred())
+
+			// block 1 is rederived as if indexed before the effective gas price was recorded
+			_, err = db.Exec(`UPDATE eth.transaction_cids SET effective_gas_price = NULL
+							FROM eth.header_cids WHERE transaction_cids.header_id = header_cids.id AND header_cids.block_hash = $1`, block1.Hash().Hex())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec(`SELECT eth.index_block_stats(id, block_number) FROM eth.header_cids WHERE block_hash = $1`, block1.Hash().Hex())
+			Expect(err).ToNot(HaveOccurred())
+			var fees sql.NullString
+			err = db.Get(&fees, `SELECT total_fees FROM eth.block_stats WHERE block_number = 1`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fees.Valid).To(BeFalse())
+
+			hours, err := eth.NewBlockStatsRoller(db, 0).Roll()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hours).To(Equal(int64(1)))
+			var block2Fees string
+			err = db.Get(&block2Fees, `SELECT total_fees FROM eth.block_stats WHERE block_number = 2`)
+			Expect(err).ToNot(HaveOccurred())
+			rollups, err := eth.NewGapRetriever(db).RetrieveHourlyBlockStats(0, 3600)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(rollups)).To(Equal(1))
+			Expect(rollups[0].BlockCount).To(Equal(int64(2)))
+			Expect(rollups[0].FeeBlockCount).To(Equal(int64(1)))
+			Expect(rollups[0].TotalFees).To(Equal(block2Fees))
+		})
+	})
+
+	Describe("Removals", func() {
+		var (
+			block1        = newTimedMockBlock(1, 10, common.Hash{})
+			block2        = newTimedMockBlock(2, 20, block1.Hash())
+			contractKey   = common.BytesToHash(mocks.ContractLeafKey)
+			accountKey    = common.BytesToHash(mocks.AccountLeafKey)
+			storageKey    = common.BytesToHash(mocks.StorageLeafKey)
+			removalsPgStr = `SELECT state_path, state_leaf_key, destroyed FROM eth.state_removals WHERE block_number = $1 ORDER BY state_path`
+		)
+		BeforeEach(func() {
+			payload := mocks.MockConvertedPayload
+			payload.Block = block1
+			err = repo.Publish(payload)
+			Expect(err).ToNot(HaveOccurred())
+
+			// the account is destroyed, the contract's slot is cleared, and an intermediate node is removed
+			payload.Block = block2
+			payload.TotalDifficulty = big.NewInt(20)
+			payload.StateNodes = []eth.TrieNode{
+				mocks.MockStateNodes[0],
+				{Path: []byte{'\x0c'}, LeafKey: accountKey, Value: []byte{}, Type: sdtypes.Removed},
+				{Path: []byte{'\x0d'}, Value: []byte{}, Type: sdtypes.Removed},
+			}
+			payload.StorageNodes = map[string][]eth.TrieNode{
+				common.Bytes2Hex([]byte{'\x06'}): {
+					{Path: []byte{}, LeafKey: storageKey, Value: []byte{}, Type: sdtypes.Removed},
+				},
+			}
+			err = repo.Publish(payload)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Records removed nodes instead of indexing them with a placeholder IPLD", func() {
+			var count int
+			err = db.Get(&count, `SELECT COUNT(*) FROM eth.state_cids WHERE block_number = $1`, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(1))
+			err = db.Get(&count, `SELECT COUNT(*) FROM eth.storage_cids WHERE block_number = $1`, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+			err = db.Get(&count, `SELECT COUNT(*) FROM public.blocks WHERE data = ''`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(0))
+
+			stateRemovals := make([]eth.StateRemovalModel, 0)
+			err = db.Select(&stateRemovals, removalsPgStr, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stateRemovals).To(Equal([]eth.StateRemovalModel{
+				{Path: []byte{'\x0c'}, StateKey: accountKey.Hex(), Destroyed: true},
+				{Path: []byte{'\x0d'}, StateKey: "", Destroyed: false},
+			}))
+			storageRemovals := make([]eth.StorageRemovalModel, 0)
+			err = db.Select(&storageRemovals, `SELECT state_leaf_key, storage_path, storage_leaf_key, cleared FROM eth.storage_removals WHERE block_number = $1`, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(storageRemovals).To(Equal([]eth.StorageRemovalModel{
+				{StateKey: contractKey.Hex(), Path: []byte{}, StorageKey: storageKey.Hex(), Cleared: true},
+			}))
+		})
+
+		It("Logs the balance and nonce changes of an account until it is destroyed", func() {
+			changes, err := eth.NewGapRetriever(db).RetrieveAccountHistory(mocks.AccountAddresss, 0, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(changes)).To(Equal(2))
+			Expect(changes[0].BlockNumber).To(Equal("1"))
+			Expect(changes[0].PrevBalance).To(Equal("0"))
+			Expect(changes[0].Balance).To(Equal("1000"))
+			Expect(changes[0].CodeChanged).To(BeFalse())
+			Expect(changes[0].StorageRootChanged).To(BeFalse())
+			Expect(changes[1].BlockNumber).To(Equal("2"))
+			Expect(changes[1].PrevBalance).To(Equal("1000"))
+			Expect(changes[1].Balance).To(Equal("0"))
+
+			var contractChange eth.AccountChangeModel
+			err = db.Get(&contractChange, `SELECT * FROM eth.account_changes WHERE state_leaf_key = $1 AND block_number = $2`, contractKey.Hex(), 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contractChange.PrevNonce).To(Equal(uint64(1)))
+			Expect(contractChange.Nonce).To(Equal(uint64(1)))
+			Expect(contractChange.CodeChanged).To(BeFalse())
+			Expect(contractChange.StorageRootChanged).To(BeFalse())
+		})
+
+		It("Takes the previous account from the parent before a concurrent worker has flagged it canonical", func() {
+			// block 2 is indexed again as if the worker indexing it had not yet seen block 1 flagged
+			_, err = db.Exec(`UPDATE eth.header_cids SET canonical = FALSE`)
+			Expect(err).ToNot(HaveOccurred())
+			payload := mocks.MockConvertedPayload
+			payload.Block = block2
+			payload.TotalDifficulty = big.NewInt(20)
+			err = repo.Publish(payload)
+			Expect(err).ToNot(HaveOccurred())
+			var accountChange eth.AccountChangeModel
+			err = db.Get(&accountChange, `SELECT * FROM eth.account_changes WHERE state_leaf_key = $1 AND block_number = $2`, accountKey.Hex(), 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accountChange.PrevBalance).To(Equal("1000"))
+			Expect(accountChange.Balance).To(Equal("1000"))
+		})
+
+		It("Answers whether accounts and slots exist at a height", func() {
+			retriever := eth.NewGapRetriever(db)
+			for _, check := range []struct {
+				key    common.Hash
+				height uint64
+				exists bool
+			}{
+				{accountKey, 1, true},
+				{accountKey, 2, false},
+				{contractKey, 2, true},
+				{contractKey, 0, false},
+			} {
+				exists, err := retriever.RetrieveAccountExistsAt(check.key, check.height)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(exists).To(Equal(check.exists))
+			}
+			exists, err := retriever.RetrieveStorageExistsAt(contractKey, storageKey, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			exists, err = retriever.RetrieveStorageExistsAt(contractKey, storageKey, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
 	})
 })
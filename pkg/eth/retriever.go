@@ -20,6 +20,8 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
@@ -58,12 +60,81 @@ func (ecr *GapRetriever) RetrieveLastBlockNumber() (int64, error) {
 	return blockNumber, err
 }
 
+// RetrieveBlockNumberAtTime is used to retrieve the number of the canonical block at or before the provided unix timestamp
+func (ecr *GapRetriever) RetrieveBlockNumberAtTime(timestamp uint64) (int64, error) {
+	var blockNumber int64
+	pgStr := `SELECT block_number FROM canonical_header_at_time($1) WHERE id IS NOT NULL`
+	err := ecr.db.Get(&blockNumber, pgStr, timestamp)
+	return blockNumber, err
+}
+
+// RetrieveBlockRangeForTimeWindow is used to retrieve the range of canonical blocks whose timestamps fall within the provided window
+// the range is inclusive at both ends and returns sql.ErrNoRows if no block falls within the window
+func (ecr *GapRetriever) RetrieveBlockRangeForTimeWindow(start, stop uint64) (BlockRange, error) {
+	var blockRange BlockRange
+	pgStr := `SELECT start, stop FROM canonical_block_range($1, $2) WHERE start IS NOT NULL`
+	err := ecr.db.Get(&blockRange, pgStr, start, stop)
+	return blockRange, err
+}
+
+// RetrieveAccountExistsAt is used to retrieve whether the account with the provided state leaf key exists in the canonical state at the provided height
+func (ecr *GapRetriever) RetrieveAccountExistsAt(stateKey common.Hash, height uint64) (bool, error) {
+	var exists bool
+	pgStr := `SELECT account_exists_at($1, $2)`
+	err := ecr.db.Get(&exists, pgStr, stateKey.Hex(), height)
+	return exists, err
+}
+
+// RetrieveStorageExistsAt is used to retrieve whether the storage slot with the provided storage leaf key exists
+// in the storage of the account with the provided state leaf key in the canonical state at the provided height
+func (ecr *GapRetriever) RetrieveStorageExistsAt(stateKey, storageKey common.Hash, height uint64) (bool, error) {
+	var exists bool
+	pgStr := `SELECT storage_exists_at($1, $2, $3)`
+	err := ecr.db.Get(&exists, pgStr, stateKey.Hex(), storageKey.Hex(), height)
+	return exists, err
+}
+
+// RetrieveAccountHistory is used to retrieve the changes to the account at the provided address in the canonical blocks of the provided range
+// ordered by block number, so that its balance and nonce history can be read off them
+func (ecr *GapRetriever) RetrieveAccountHistory(address common.Address, start, stop uint64) ([]AccountChangeModel, error) {
+	changes := make([]AccountChangeModel, 0)
+	pgStr := `SELECT account_changes.* FROM eth.account_changes
+			INNER JOIN eth.header_cids ON (account_changes.header_id = header_cids.id AND account_changes.block_number = header_cids.block_number)
+			WHERE account_changes.state_leaf_key = $1 AND account_changes.block_number BETWEEN $2 AND $3 AND header_cids.canonical
+			ORDER BY account_changes.block_number`
+	err := ecr.db.Select(&changes, pgStr, crypto.Keccak256Hash(address.Bytes()).Hex(), start, stop)
+	return changes, err
+}
+
+// RetrieveHourlyBlockStats is used to retrieve the hourly rollups of the canonical block stats for the hours starting within the provided window of unix timestamps
+func (ecr *GapRetriever) RetrieveHourlyBlockStats(start, stop uint64) ([]BlockStatsRollupModel, error) {
+	return ecr.retrieveBlockStatsRollups("hourly_block_stats", start, stop)
+}
+
+// RetrieveDailyBlockStats is used to retrieve the daily rollups of the canonical block stats for the days starting within the provided window of unix timestamps
+func (ecr *GapRetriever) RetrieveDailyBlockStats(start, stop uint64) ([]BlockStatsRollupModel, error) {
+	return ecr.retrieveBlockStatsRollups("daily_block_stats", start, stop)
+}
+
+func (ecr *GapRetriever) retrieveBlockStatsRollups(table string, start, stop uint64) ([]BlockStatsRollupModel, error) {
+	rollups := make([]BlockStatsRollupModel, 0)
+	pgStr := fmt.Sprintf(`SELECT * FROM eth.%s WHERE period_start BETWEEN $1 AND $2 ORDER BY period_start`, table)
+	err := ecr.db.Select(&rollups, pgStr, start, stop)
+	return rollups, err
+}
+
 // DBGap type for querying for gaps in db
 type DBGap struct {
 	Start uint64 `db:"start"`
 	Stop  uint64 `db:"stop"`
 }
 
+// BlockRange type for querying for the blocks within a time window
+type BlockRange struct {
+	Start uint64 `db:"start"`
+	Stop  uint64 `db:"stop"`
+}
+
 // RetrieveGapsInData is used to find the the block numbers at which we are missing data in the db
 // it finds the union of heights where no data exists and where the times_validated is lower than the validation level
 func (ecr *GapRetriever) RetrieveGapsInData(validationLevel int) ([]DBGap, error) {
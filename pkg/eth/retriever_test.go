@@ -17,7 +17,11 @@
 package eth_test
 
 import (
+	"database/sql"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -47,7 +51,7 @@ var _ = Describe("Retriever", func() {
 		var err error
 		db, err = shared.SetupDB()
 		Expect(err).ToNot(HaveOccurred())
-		repo = eth.NewIPLDPublisher(db)
+		repo = eth.NewIPLDPublisher(params.MainnetChainConfig, db)
 		retriever = eth.NewGapRetriever(db)
 	})
 	AfterEach(func() {
@@ -333,6 +337,90 @@ var _ = Describe("Retriever", func() {
 			Expect(ListContainsGap(gaps, eth.DBGap{Start: 1001, Stop: 1010100})).To(BeTrue())
 		})
 	})
+
+	Describe("Timestamp lookups", func() {
+		BeforeEach(func() {
+			// blocks 1 to 4 are 10 seconds apart, with a fork at height 3 that is never built upon
+			block1 := newTimedMockBlock(1, 10, common.Hash{})
+			block2 := newTimedMockBlock(2, 20, block1.Hash())
+			block3 := newTimedMockBlock(3, 30, block2.Hash())
+			forkBlock3 := newTimedMockBlock(3, 25, block2.Hash())
+			block4 := newTimedMockBlock(4, 40, block3.Hash())
+			for _, block := range []*types.Block{block1, block2, block3, forkBlock3, block4} {
+				payload := mocks.MockConvertedPayload
+				payload.Block = block
+				err := repo.Publish(payload)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("Gets the number of the canonical block at or before a timestamp", func() {
+			num, err := retriever.RetrieveBlockNumberAtTime(20)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(2)))
+			num, err = retriever.RetrieveBlockNumberAtTime(35)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(3)))
+			num, err = retriever.RetrieveBlockNumberAtTime(1000)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(4)))
+		})
+
+		It("Skips non-canonical blocks", func() {
+			num, err := retriever.RetrieveBlockNumberAtTime(27)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(2)))
+		})
+
+		It("Throws an error if there is no block at or before a timestamp", func() {
+			_, err := retriever.RetrieveBlockNumberAtTime(5)
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+
+		It("Gets the range of canonical blocks within a time window", func() {
+			blockRange, err := retriever.RetrieveBlockRangeForTimeWindow(15, 35)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockRange).To(Equal(eth.BlockRange{Start: 2, Stop: 3}))
+			blockRange, err = retriever.RetrieveBlockRangeForTimeWindow(10, 40)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockRange).To(Equal(eth.BlockRange{Start: 1, Stop: 4}))
+		})
+
+		It("Throws an error if no block falls within a time window", func() {
+			_, err := retriever.RetrieveBlockRangeForTimeWindow(21, 29)
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+
+		It("Searches across gaps in the canonical chain", func() {
+			// canonical blocks 6 and 9 lie beyond gaps, and a later fork at height 5 is never built upon
+			block6 := newTimedMockBlock(6, 60, common.HexToHash("0x06"))
+			block9 := newTimedMockBlock(9, 90, common.HexToHash("0x09"))
+			forkBlock5 := newTimedMockBlock(5, 95, common.HexToHash("0x05"))
+			for _, block := range []*types.Block{block6, block9, forkBlock5} {
+				payload := mocks.MockConvertedPayload
+				payload.Block = block
+				err := repo.Publish(payload)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			_, err := db.Exec(`UPDATE eth.header_cids SET canonical = (block_number <> 5) WHERE block_number > 4`)
+			Expect(err).ToNot(HaveOccurred())
+			num, err := retriever.RetrieveBlockNumberAtTime(75)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(6)))
+			// the latest header at or before the timestamp is the fork, so the canonical heights are searched
+			num, err = retriever.RetrieveBlockNumberAtTime(100)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(num).To(Equal(int64(9)))
+			blockRange, err := retriever.RetrieveBlockRangeForTimeWindow(41, 89)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockRange).To(Equal(eth.BlockRange{Start: 6, Stop: 6}))
+			blockRange, err = retriever.RetrieveBlockRangeForTimeWindow(61, 95)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blockRange).To(Equal(eth.BlockRange{Start: 9, Stop: 9}))
+			_, err = retriever.RetrieveBlockRangeForTimeWindow(61, 89)
+			Expect(err).To(Equal(sql.ErrNoRows))
+		})
+	})
 })
 
 func newMockBlock(blockNumber uint64) *types.Block {
@@ -341,6 +429,14 @@ func newMockBlock(blockNumber uint64) *types.Block {
 	return types.NewBlock(&mocks.MockHeader, mocks.MockTransactions, nil, mocks.MockReceipts, new(trie.Trie))
 }
 
+func newTimedMockBlock(blockNumber, timestamp uint64, parentHash common.Hash) *types.Block {
+	header := types.CopyHeader(&mocks.MockHeader)
+	header.Number.SetUint64(blockNumber)
+	header.Time = timestamp
+	header.ParentHash = parentHash
+	return types.NewBlock(header, mocks.MockTransactions, nil, mocks.MockReceipts, new(trie.Trie))
+}
+
 // ListContainsGap used to check if a list of Gaps contains a particular Gap
 func ListContainsGap(gapList []eth.DBGap, gap eth.DBGap) bool {
 	for _, listGap := range gapList {
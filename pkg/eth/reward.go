@@ -19,19 +19,25 @@ package eth
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 )
 
-func CalcEthBlockReward(header *types.Header, uncles []*types.Header, txs types.Transactions, receipts types.Receipts) *big.Int {
-	staticBlockReward := staticRewardByBlockNumber(header.Number.Uint64())
-	transactionFees := calcEthTransactionFees(txs, receipts)
-	uncleInclusionRewards := calcEthUncleInclusionRewards(header, uncles)
+// CalcEthBlockReward returns the total reward paid to the miner of the block: the static block reward, the uncle inclusion rewards,
+// and the transaction fees paid to the miner, which exclude the fees burnt by EIP-1559
+// The static and uncle inclusion rewards follow the forks of the provided chain config, and are zero on proof-of-authority chains
+func CalcEthBlockReward(config *params.ChainConfig, header *types.Header, uncles []*types.Header, txs types.Transactions, receipts types.Receipts) *big.Int {
+	staticBlockReward := staticBlockReward(config, header.Number)
+	transactionFees := calcEthTransactionFees(header.BaseFee, txs, receipts)
+	uncleInclusionRewards := calcEthUncleInclusionRewards(staticBlockReward, uncles)
 	tmp := transactionFees.Add(transactionFees, uncleInclusionRewards)
 	return tmp.Add(tmp, staticBlockReward)
 }
 
-func CalcUncleMinerReward(blockNumber, uncleBlockNumber uint64) *big.Int {
-	staticBlockReward := staticRewardByBlockNumber(blockNumber)
+// CalcUncleMinerReward returns the reward paid to the miner of an uncle included in the block at the provided height
+func CalcUncleMinerReward(config *params.ChainConfig, blockNumber, uncleBlockNumber uint64) *big.Int {
+	staticBlockReward := staticBlockReward(config, new(big.Int).SetUint64(blockNumber))
 	rewardDiv8 := staticBlockReward.Div(staticBlockReward, big.NewInt(8))
 	mainBlock := new(big.Int).SetUint64(blockNumber)
 	uncleBlock := new(big.Int).SetUint64(uncleBlockNumber)
@@ -40,37 +46,72 @@ func CalcUncleMinerReward(blockNumber, uncleBlockNumber uint64) *big.Int {
 	return rewardDiv8.Mul(rewardDiv8, uncleBlockPlus8MinusMainBlock)
 }
 
-func staticRewardByBlockNumber(blockNumber uint64) *big.Int {
-	staticBlockReward := new(big.Int)
-	//https://blog.ethereum.org/2017/10/12/byzantium-hf-announcement/
-	if blockNumber >= 7280000 {
-		staticBlockReward.SetString("2000000000000000000", 10)
-	} else if blockNumber >= 4370000 {
-		staticBlockReward.SetString("3000000000000000000", 10)
-	} else {
-		staticBlockReward.SetString("5000000000000000000", 10)
+// staticBlockReward returns the static reward for mining the block at the provided height, as ethash accumulates it
+// Proof-of-authority engines do not pay a block reward
+func staticBlockReward(config *params.ChainConfig, blockNumber *big.Int) *big.Int {
+	switch {
+	case config.Clique != nil:
+		return new(big.Int)
+	case config.IsConstantinople(blockNumber):
+		return new(big.Int).Set(ethash.ConstantinopleBlockReward)
+	case config.IsByzantium(blockNumber):
+		return new(big.Int).Set(ethash.ByzantiumBlockReward)
+	default:
+		return new(big.Int).Set(ethash.FrontierBlockReward)
 	}
-	return staticBlockReward
 }
 
-func calcEthTransactionFees(txs types.Transactions, receipts types.Receipts) *big.Int {
+// CalcEffectiveGasPrice returns the price per gas paid by the transaction
+// For dynamic fee transactions this is the base fee plus the tip, capped at the fee cap
+// A nil base fee means the block predates EIP-1559 and the gas price is paid in full
+func CalcEffectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if feeCap := tx.GasFeeCap(); price.Cmp(feeCap) > 0 {
+		return feeCap
+	}
+	return price
+}
+
+// CalcPriorityFee returns the fee paid to the miner by a transaction that used the provided amount of gas
+// that is the effective gas price less the base fee, which is burnt, multiplied by the gas used
+func CalcPriorityFee(tx *types.Transaction, baseFee *big.Int, gasUsed uint64) *big.Int {
+	tip := CalcEffectiveGasPrice(tx, baseFee)
+	if baseFee != nil {
+		tip.Sub(tip, baseFee)
+	}
+	return tip.Mul(tip, new(big.Int).SetUint64(gasUsed))
+}
+
+// CalcBurntFees returns the fees burnt by the block under EIP-1559, the base fee multiplied by the gas used by the block
+func CalcBurntFees(header *types.Header) *big.Int {
+	if header.BaseFee == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(header.BaseFee, new(big.Int).SetUint64(header.GasUsed))
+}
+
+// calcEthTransactionFees returns the sum of the fees paid to the miner by the transactions
+// the gas used by each transaction is derived from the cumulative gas used of its receipt, since it is not part of the consensus encoding of a receipt
+func calcEthTransactionFees(baseFee *big.Int, txs types.Transactions, receipts types.Receipts) *big.Int {
 	transactionFees := new(big.Int)
+	var prevCumulativeGasUsed uint64
 	for i, transaction := range txs {
 		receipt := receipts[i]
-		gasPrice := big.NewInt(transaction.GasPrice().Int64())
-		gasUsed := big.NewInt(int64(receipt.GasUsed))
-		transactionFee := gasPrice.Mul(gasPrice, gasUsed)
-		transactionFees = transactionFees.Add(transactionFees, transactionFee)
+		gasUsed := receipt.CumulativeGasUsed - prevCumulativeGasUsed
+		prevCumulativeGasUsed = receipt.CumulativeGasUsed
+		transactionFees.Add(transactionFees, CalcPriorityFee(transaction, baseFee, gasUsed))
 	}
 	return transactionFees
 }
 
-func calcEthUncleInclusionRewards(header *types.Header, uncles []*types.Header) *big.Int {
+func calcEthUncleInclusionRewards(staticBlockReward *big.Int, uncles []*types.Header) *big.Int {
 	uncleInclusionRewards := new(big.Int)
 	for range uncles {
-		staticBlockReward := staticRewardByBlockNumber(header.Number.Uint64())
-		staticBlockReward.Div(staticBlockReward, big.NewInt(32))
-		uncleInclusionRewards.Add(uncleInclusionRewards, staticBlockReward)
+		inclusionReward := new(big.Int).Div(staticBlockReward, big.NewInt(32))
+		uncleInclusionRewards.Add(uncleInclusionRewards, inclusionReward)
 	}
 	return uncleInclusionRewards
 }
@@ -0,0 +1,178 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("Block rewards", func() {
+	header := func(number int64) *types.Header {
+		return &types.Header{Number: big.NewInt(number)}
+	}
+	uncles := []*types.Header{header(9)}
+
+	It("Follows the reward forks of the chain config", func() {
+		Expect(eth.CalcEthBlockReward(params.MainnetChainConfig, header(4369999), nil, nil, nil).String()).To(Equal("5000000000000000000"))
+		Expect(eth.CalcEthBlockReward(params.MainnetChainConfig, header(4370000), nil, nil, nil).String()).To(Equal("3000000000000000000"))
+		Expect(eth.CalcEthBlockReward(params.MainnetChainConfig, header(7280000), nil, nil, nil).String()).To(Equal("2000000000000000000"))
+		Expect(eth.CalcEthBlockReward(params.RopstenChainConfig, header(1700000), nil, nil, nil).String()).To(Equal("3000000000000000000"))
+		Expect(eth.CalcEthBlockReward(params.RopstenChainConfig, header(4230000), nil, nil, nil).String()).To(Equal("2000000000000000000"))
+	})
+
+	It("Pays uncle inclusion and uncle miner rewards out of the static reward", func() {
+		Expect(eth.CalcEthBlockReward(params.MainnetChainConfig, header(10), uncles, nil, nil).String()).To(Equal("5156250000000000000"))
+		Expect(eth.CalcUncleMinerReward(params.MainnetChainConfig, 10, 9).String()).To(Equal("4375000000000000000"))
+	})
+
+	It("Pays no block or uncle rewards on proof-of-authority chains", func() {
+		Expect(eth.CalcEthBlockReward(params.RinkebyChainConfig, header(10), uncles, nil, nil).String()).To(Equal("0"))
+		Expect(eth.CalcEthBlockReward(params.GoerliChainConfig, header(10), nil, nil, nil).String()).To(Equal("0"))
+		Expect(eth.CalcUncleMinerReward(params.RinkebyChainConfig, 10, 9).String()).To(Equal("0"))
+	})
+})
+
+var _ = Describe("Block producers", func() {
+	coinbase := common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476592")
+	sealedHeader := func() (*types.Header, common.Address) {
+		key, err := crypto.GenerateKey()
+		Expect(err).ToNot(HaveOccurred())
+		header := &types.Header{
+			Number:     big.NewInt(10),
+			Difficulty: big.NewInt(2),
+			Coinbase:   coinbase,
+			Extra:      make([]byte, 32+crypto.SignatureLength),
+		}
+		seal, err := crypto.Sign(clique.SealHash(header).Bytes(), key)
+		Expect(err).ToNot(HaveOccurred())
+		copy(header.Extra[32:], seal)
+		return header, crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	It("Is the coinbase on proof-of-work chains", func() {
+		header, _ := sealedHeader()
+		Expect(eth.BlockProducer(params.MainnetChainConfig, header)).To(Equal(coinbase.String()))
+	})
+
+	It("Is the signer recovered from the seal on Clique chains", func() {
+		header, signer := sealedHeader()
+		recovered, err := eth.CliqueSigner(header)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recovered).To(Equal(signer))
+		Expect(eth.BlockProducer(params.RinkebyChainConfig, header)).To(Equal(signer.String()))
+		Expect(eth.BlockProducer(params.GoerliChainConfig, header)).To(Equal(signer.String()))
+	})
+
+	It("Is empty for Clique headers without a valid seal", func() {
+		header, _ := sealedHeader()
+		header.Extra = header.Extra[:32]
+		_, err := eth.CliqueSigner(header)
+		Expect(err).To(HaveOccurred())
+		Expect(eth.BlockProducer(params.RinkebyChainConfig, header)).To(BeEmpty())
+		genesis := &types.Header{Number: big.NewInt(0), Difficulty: big.NewInt(1), Extra: make([]byte, 32+common.AddressLength+crypto.SignatureLength)}
+		Expect(eth.BlockProducer(params.RinkebyChainConfig, genesis)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Fee accounting", func() {
+	legacyTx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(50), Gas: 21000})
+	dynamicFeeTx := types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(5), GasFeeCap: big.NewInt(40), Gas: 21000})
+
+	It("Uses the gas price of every transaction before London", func() {
+		Expect(eth.CalcEffectiveGasPrice(legacyTx, nil)).To(Equal(big.NewInt(50)))
+		Expect(eth.CalcPriorityFee(legacyTx, nil, 100)).To(Equal(big.NewInt(5000)))
+		Expect(eth.CalcBurntFees(&types.Header{GasUsed: 100})).To(Equal(new(big.Int)))
+	})
+
+	It("Pays only the price above the base fee to the miner after London", func() {
+		baseFee := big.NewInt(30)
+		Expect(eth.CalcEffectiveGasPrice(legacyTx, baseFee)).To(Equal(big.NewInt(50)))
+		Expect(eth.CalcPriorityFee(legacyTx, baseFee, 100)).To(Equal(big.NewInt(2000)))
+		Expect(eth.CalcEffectiveGasPrice(dynamicFeeTx, baseFee)).To(Equal(big.NewInt(35)))
+		Expect(eth.CalcPriorityFee(dynamicFeeTx, baseFee, 100)).To(Equal(big.NewInt(500)))
+		Expect(eth.CalcBurntFees(&types.Header{BaseFee: baseFee, GasUsed: 100})).To(Equal(big.NewInt(3000)))
+	})
+
+	It("Caps the effective gas price at the max fee per gas", func() {
+		baseFee := big.NewInt(38)
+		Expect(eth.CalcEffectiveGasPrice(dynamicFeeTx, baseFee)).To(Equal(big.NewInt(40)))
+		Expect(eth.CalcPriorityFee(dynamicFeeTx, baseFee, 100)).To(Equal(big.NewInt(200)))
+	})
+})
+
+var _ = Describe("Chain configs", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "chain_config")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+
+	It("Uses the built in config when no file is given", func() {
+		config, err := eth.LoadChainConfig(5, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config).To(Equal(params.GoerliChainConfig))
+		_, err = eth.LoadChainConfig(1337, "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Reads the chain config of a private Clique chain from its genesis file", func() {
+		path := write("genesis.json", `{"config": {"chainId": 1337, "homesteadBlock": 0, "eip155Block": 0, "byzantiumBlock": 0, "clique": {"period": 0, "epoch": 30000}}, "difficulty": "0x1", "gasLimit": "0x7a1200", "alloc": {}}`)
+		config, err := eth.LoadChainConfig(1337, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.ChainID.Uint64()).To(Equal(uint64(1337)))
+		Expect(config.Clique).To(Equal(&params.CliqueConfig{Period: 0, Epoch: 30000}))
+		Expect(eth.CalcEthBlockReward(config, &types.Header{Number: big.NewInt(10)}, nil, nil, nil).String()).To(Equal("0"))
+	})
+
+	It("Reads a bare chain config file", func() {
+		path := write("config.json", `{"chainId": 99, "clique": {"period": 5, "epoch": 100}}`)
+		config, err := eth.LoadChainConfig(99, path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Clique.Period).To(Equal(uint64(5)))
+	})
+
+	It("Rejects a file for another chain", func() {
+		path := write("genesis.json", `{"config": {"chainId": 1337, "clique": {"period": 0, "epoch": 30000}}}`)
+		_, err := eth.LoadChainConfig(1, path)
+		Expect(err).To(HaveOccurred())
+		path = write("empty.json", `{"alloc": {}}`)
+		_, err = eth.LoadChainConfig(1337, path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,103 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/statediff"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// RewardTransformer satisfies the Transformer interface by only recomputing the rewards and the producer of an already indexed block and its uncles
+// It is used to correct the reward and producer columns without rewriting the rest of the block
+type RewardTransformer struct {
+	chainConfig *params.ChainConfig
+	db          *postgres.DB
+}
+
+// NewRewardTransformer creates a pointer to a new RewardTransformer
+func NewRewardTransformer(chainConfig *params.ChainConfig, db *postgres.DB) *RewardTransformer {
+	return &RewardTransformer{
+		chainConfig: chainConfig,
+		db:          db,
+	}
+}
+
+// Transform recomputes the rewards and the producer of the block in the payload and updates its header, uncle, and block stats rows
+func (rt *RewardTransformer) Transform(workerID int, payload statediff.Payload) (uint64, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(payload.BlockRlp, block); err != nil {
+		return 0, fmt.Errorf("error decoding payload block rlp: %s", err.Error())
+	}
+	receipts := make(types.Receipts, 0)
+	if err := rlp.DecodeBytes(payload.ReceiptsRlp, &receipts); err != nil {
+		return 0, fmt.Errorf("error decoding payload receipts rlp: %s", err.Error())
+	}
+	height := block.NumberU64()
+	reward := CalcEthBlockReward(rt.chainConfig, block.Header(), block.Uncles(), block.Transactions(), receipts)
+	producer := nullString(BlockProducer(rt.chainConfig, block.Header()))
+
+	tx, err := rt.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(`UPDATE eth.header_cids SET (reward, producer) = ($1, $2) WHERE block_number = $3 AND block_hash = $4`,
+		reward.String(), producer, height, block.Hash().String())
+	if err != nil {
+		shared.Rollback(tx)
+		return 0, err
+	}
+	if updated, err := res.RowsAffected(); err != nil || updated == 0 {
+		shared.Rollback(tx)
+		if err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("worker %d found no indexed header at height %d with hash %s to update the reward of", workerID, height, block.Hash().String())
+	}
+	// the rollup triggers move the difference into the hourly and daily stats if the block is canonical
+	_, err = tx.Exec(`UPDATE eth.block_stats SET (reward, producer) = ($1, $2)
+			FROM eth.header_cids
+			WHERE block_stats.header_id = header_cids.id
+			AND header_cids.block_number = $3
+			AND header_cids.block_hash = $4`,
+		reward.String(), producer, height, block.Hash().String())
+	if err != nil {
+		shared.Rollback(tx)
+		return 0, err
+	}
+	for _, uncle := range block.Uncles() {
+		uncleReward := CalcUncleMinerReward(rt.chainConfig, height, uncle.Number.Uint64())
+		_, err := tx.Exec(`UPDATE eth.uncle_cids SET reward = $1
+				FROM eth.header_cids
+				WHERE uncle_cids.header_id = header_cids.id
+				AND header_cids.block_number = $2
+				AND header_cids.block_hash = $3
+				AND uncle_cids.block_hash = $4`,
+			uncleReward.String(), height, block.Hash().String(), uncle.Hash().String())
+		if err != nil {
+			shared.Rollback(tx)
+			return 0, err
+		}
+	}
+	return height, shared.Commit(tx)
+}
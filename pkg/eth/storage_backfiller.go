@@ -0,0 +1,123 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// DefaultStorageBatchSize is the number of storage slots decoded in each tx by the StorageBackfiller
+const DefaultStorageBatchSize uint64 = 1000
+
+// StorageBackfiller decodes the storage already indexed in eth.storage_cids and eth.storage_removals for the contracts with a registered storage layout,
+// e.g. for storage indexed before their contract's layout was registered or after it was changed
+type StorageBackfiller struct {
+	db        *postgres.DB
+	storage   *StorageDecoder
+	batchSize uint64
+}
+
+// NewStorageBackfiller returns a new StorageBackfiller
+func NewStorageBackfiller(db *postgres.DB, storage *StorageDecoder, batchSize uint64) *StorageBackfiller {
+	if batchSize == 0 {
+		batchSize = DefaultStorageBatchSize
+	}
+	return &StorageBackfiller{
+		db:        db,
+		storage:   storage,
+		batchSize: batchSize,
+	}
+}
+
+// storageSlot is the value written to, or cleared from, a storage slot at a block
+type storageSlot struct {
+	ID          int64  `db:"id"`
+	HeaderID    int64  `db:"header_id"`
+	BlockNumber string `db:"block_number"`
+	StateKey    string `db:"state_leaf_key"`
+	StorageKey  string `db:"storage_leaf_key"`
+	Value       []byte `db:"value"`
+}
+
+// Backfill decodes every indexed storage leaf and cleared slot of the registered contracts
+// The previously decoded variables of each slot are replaced, so that variables no longer located by the layout are dropped
+// Storage leaf nodes without a decoded slot value are skipped, these are filled in by the MetaDataBackfiller
+func (sb *StorageBackfiller) Backfill() error {
+	stateKeys := pq.StringArray(sb.storage.StateKeys())
+	leafStr := `SELECT storage_cids.id, state_cids.header_id, storage_cids.block_number, state_cids.state_leaf_key, storage_cids.storage_leaf_key, storage_cids.value
+			FROM eth.storage_cids
+			INNER JOIN eth.state_cids ON (storage_cids.state_id = state_cids.id AND storage_cids.block_number = state_cids.block_number)
+			WHERE state_cids.state_leaf_key = ANY($1)
+			AND storage_cids.node_type = 2
+			AND storage_cids.value IS NOT NULL
+			AND storage_cids.id > $2
+			ORDER BY storage_cids.id
+			LIMIT $3`
+	if err := sb.backfillSlots("storage leaves", leafStr, stateKeys); err != nil {
+		return err
+	}
+	clearedStr := `SELECT id, header_id, block_number, state_leaf_key, storage_leaf_key, NULL AS value
+			FROM eth.storage_removals
+			WHERE state_leaf_key = ANY($1)
+			AND cleared
+			AND id > $2
+			ORDER BY id
+			LIMIT $3`
+	return sb.backfillSlots("cleared slots", clearedStr, stateKeys)
+}
+
+// backfillSlots repeatedly selects a batch of slots after the last one with the provided query, and decodes each of them in a single tx
+// the query is passed the state leaf keys of the registered contracts as $1, the last id as $2, and the batch size as $3
+func (sb *StorageBackfiller) backfillSlots(name, pgStr string, stateKeys pq.StringArray) error {
+	var last int64
+	var decoded uint64
+	for {
+		slots := make([]storageSlot, 0, sb.batchSize)
+		if err := sb.db.Select(&slots, pgStr, stateKeys, last, sb.batchSize); err != nil {
+			return err
+		}
+		if len(slots) == 0 {
+			logrus.Infof("storage backfiller finished decoding %d %s", decoded, name)
+			return nil
+		}
+		tx, err := sb.db.Beginx()
+		if err != nil {
+			return err
+		}
+		for _, slot := range slots {
+			_, err := tx.Exec(`DELETE FROM eth.decoded_storage WHERE header_id = $1 AND state_leaf_key = $2 AND storage_leaf_key = $3`,
+				slot.HeaderID, slot.StateKey, slot.StorageKey)
+			if err == nil {
+				err = indexDecodedStorage(tx, sb.storage.Decode(slot.StateKey, slot.StorageKey, slot.Value), slot.BlockNumber, slot.HeaderID)
+			}
+			if err != nil {
+				shared.Rollback(tx)
+				return err
+			}
+		}
+		if err := shared.Commit(tx); err != nil {
+			return err
+		}
+		last = slots[len(slots)-1].ID
+		decoded += uint64(len(slots))
+		logrus.Infof("storage backfiller decoded %d %s", decoded, name)
+	}
+}
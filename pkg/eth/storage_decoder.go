@@ -0,0 +1,359 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// StorageDecoder decodes the storage slots of contracts with a registered solc storage layout into the variables they hold
+// The storage leaf keys of simple variables, struct members, static array elements, and the entries of mappings with known keys
+// are precomputed when the layouts are loaded
+type StorageDecoder struct {
+	// the variables held in each slot, by the state leaf key of their contract and the storage leaf key of the slot
+	slots     map[common.Hash]map[common.Hash][]storageVariable
+	contracts map[common.Hash]common.Address
+}
+
+// storageLayoutFile is the storageLayout output of solc for a contract, along with the addresses it applies to and the known keys of its mappings
+type storageLayoutFile struct {
+	Addresses []string                     `json:"addresses"`
+	Storage   []storageLayoutEntry         `json:"storage"`
+	Types     map[string]storageLayoutType `json:"types"`
+	// known keys by mapping variable name, each either a single key or the list of keys of a nested mapping
+	Keys map[string][]json.RawMessage `json:"keys"`
+}
+
+type storageLayoutEntry struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+type storageLayoutType struct {
+	Encoding      string               `json:"encoding"`
+	Label         string               `json:"label"`
+	NumberOfBytes string               `json:"numberOfBytes"`
+	Key           string               `json:"key"`
+	Value         string               `json:"value"`
+	Base          string               `json:"base"`
+	Members       []storageLayoutEntry `json:"members"`
+}
+
+// storageVariable is a variable, or the part of one, that is held in a slot
+type storageVariable struct {
+	name     string
+	keys     []string
+	label    string
+	encoding string
+	offset   int
+	size     int
+}
+
+// NewStorageDecoder loads every .json file in the provided directory as a contract storage layout
+// A file is registered for the contract address it is named after, e.g. 0x6B17...1d0F.json, or for each address in its "addresses" field
+// It returns nil if no directory is provided
+func NewStorageDecoder(dir string) (*StorageDecoder, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	decoder := &StorageDecoder{
+		slots:     make(map[common.Hash]map[common.Hash][]storageVariable),
+		contracts: make(map[common.Hash]common.Address),
+	}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.register(strings.TrimSuffix(filepath.Base(path), ".json"), data); err != nil {
+			return nil, fmt.Errorf("error loading storage layout file %s: %s", path, err.Error())
+		}
+	}
+	return decoder, nil
+}
+
+// register parses a storage layout file and registers the slots it can decode for the addresses it applies to
+func (d *StorageDecoder) register(name string, data []byte) error {
+	file := new(storageLayoutFile)
+	if err := json.Unmarshal(data, file); err != nil {
+		return err
+	}
+	addresses := file.Addresses
+	if len(addresses) == 0 {
+		addresses = []string{name}
+	}
+	slots := make(map[common.Hash][]storageVariable)
+	for _, entry := range file.Storage {
+		slot, ok := new(big.Int).SetString(entry.Slot, 10)
+		if !ok {
+			return fmt.Errorf("invalid slot %s of %s", entry.Slot, entry.Label)
+		}
+		paths := make([][]string, 0, len(file.Keys[entry.Label]))
+		for _, raw := range file.Keys[entry.Label] {
+			var path []string
+			if err := json.Unmarshal(raw, &path); err != nil {
+				var key string
+				if err := json.Unmarshal(raw, &key); err != nil {
+					return fmt.Errorf("invalid key %s of %s", string(raw), entry.Label)
+				}
+				path = []string{key}
+			}
+			paths = append(paths, path)
+		}
+		if err := expandStorageVariable(slots, file.Types, entry.Label, []string{}, slot, entry.Offset, entry.Type, paths); err != nil {
+			return err
+		}
+	}
+	for _, address := range addresses {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid contract address %s", address)
+		}
+		contract := common.HexToAddress(address)
+		stateKey := crypto.Keccak256Hash(contract.Bytes())
+		d.slots[stateKey] = slots
+		d.contracts[stateKey] = contract
+	}
+	return nil
+}
+
+// expandStorageVariable registers the slots that hold a variable of the provided type, starting at the provided slot and offset
+// Mappings are expanded for the first key of each of the provided key paths, passing the rest of the path on to their values
+func expandStorageVariable(slots map[common.Hash][]storageVariable, types map[string]storageLayoutType, name string, keys []string,
+	slot *big.Int, offset int, typeID string, paths [][]string) error {
+	t, ok := types[typeID]
+	if !ok {
+		return fmt.Errorf("unknown type %s of %s", typeID, name)
+	}
+	size, err := strconv.Atoi(t.NumberOfBytes)
+	if err != nil {
+		return fmt.Errorf("invalid size %s of type %s", t.NumberOfBytes, typeID)
+	}
+	switch {
+	case t.Encoding == "mapping":
+		keyType, ok := types[t.Key]
+		if !ok {
+			return fmt.Errorf("unknown key type %s of %s", t.Key, name)
+		}
+		for _, path := range paths {
+			if len(path) == 0 {
+				continue
+			}
+			encodedKey, err := storageMappingKey(keyType.Label, path[0])
+			if err != nil {
+				return fmt.Errorf("invalid key %s of %s: %s", path[0], name, err.Error())
+			}
+			entrySlot := new(big.Int).SetBytes(crypto.Keccak256(encodedKey, common.LeftPadBytes(slot.Bytes(), common.HashLength)))
+			entryKeys := append(append([]string{}, keys...), path[0])
+			if err := expandStorageVariable(slots, types, name, entryKeys, entrySlot, 0, t.Value, [][]string{path[1:]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case len(t.Members) > 0:
+		for _, member := range t.Members {
+			memberSlot, ok := new(big.Int).SetString(member.Slot, 10)
+			if !ok {
+				return fmt.Errorf("invalid slot %s of %s.%s", member.Slot, name, member.Label)
+			}
+			if err := expandStorageVariable(slots, types, name+"."+member.Label, keys, new(big.Int).Add(slot, memberSlot), member.Offset, member.Type, paths); err != nil {
+				return err
+			}
+		}
+		return nil
+	case t.Encoding == "inplace" && t.Base != "":
+		// static arrays pack elements smaller than a slot, and start every other element on a new slot
+		base, ok := types[t.Base]
+		if !ok {
+			return fmt.Errorf("unknown base type %s of %s", t.Base, name)
+		}
+		baseSize, err := strconv.Atoi(base.NumberOfBytes)
+		if err != nil || baseSize == 0 {
+			return fmt.Errorf("invalid size %s of type %s", base.NumberOfBytes, t.Base)
+		}
+		length, err := strconv.Atoi(t.Label[strings.LastIndex(t.Label, "[")+1 : len(t.Label)-1])
+		if err != nil {
+			return fmt.Errorf("invalid array type %s of %s", t.Label, name)
+		}
+		for i := 0; i < length; i++ {
+			elemSlot, elemOffset := new(big.Int).Set(slot), 0
+			if baseSize < common.HashLength {
+				perSlot := common.HashLength / baseSize
+				elemSlot.Add(elemSlot, big.NewInt(int64(i/perSlot)))
+				elemOffset = (i % perSlot) * baseSize
+			} else {
+				elemSlot.Add(elemSlot, big.NewInt(int64(i*((baseSize+common.HashLength-1)/common.HashLength))))
+			}
+			if err := expandStorageVariable(slots, types, fmt.Sprintf("%s[%d]", name, i), keys, elemSlot, elemOffset, t.Base, paths); err != nil {
+				return err
+			}
+		}
+		return nil
+	case t.Encoding == "dynamic_array":
+		// only the length of a dynamic array is held in its slot
+		name += ".length"
+		t.Label, size = "uint256", common.HashLength
+	}
+	storageKey := crypto.Keccak256Hash(common.LeftPadBytes(slot.Bytes(), common.HashLength))
+	for _, v := range slots[storageKey] {
+		if v.name == name && v.offset == offset && strings.Join(v.keys, ",") == strings.Join(keys, ",") {
+			return nil
+		}
+	}
+	slots[storageKey] = append(slots[storageKey], storageVariable{
+		name:     name,
+		keys:     keys,
+		label:    t.Label,
+		encoding: t.Encoding,
+		offset:   offset,
+		size:     size,
+	})
+	return nil
+}
+
+// storageMappingKey encodes a mapping key the way solidity hashes it into the slot of the mapping entry
+// value type keys are padded to 32 bytes, while string and bytes keys are hashed as they are
+func storageMappingKey(label, key string) ([]byte, error) {
+	switch {
+	case label == "string":
+		return []byte(key), nil
+	case label == "bytes":
+		return hexutil.Decode(key)
+	case label == "bool":
+		b, err := strconv.ParseBool(key)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return common.LeftPadBytes([]byte{1}, common.HashLength), nil
+		}
+		return make([]byte, common.HashLength), nil
+	case label == "address" || label == "address payable" || strings.HasPrefix(label, "contract "):
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("not an address")
+		}
+		return common.LeftPadBytes(common.HexToAddress(key).Bytes(), common.HashLength), nil
+	case strings.HasPrefix(label, "bytes"):
+		b, err := hexutil.Decode(key)
+		if err != nil {
+			return nil, err
+		}
+		return common.RightPadBytes(b, common.HashLength), nil
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "int") || strings.HasPrefix(label, "enum "):
+		n, ok := math.ParseBig256(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("not an integer")
+		}
+		return math.U256Bytes(n), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", label)
+	}
+}
+
+// StateKeys returns the state leaf keys of the contracts with a registered storage layout
+func (d *StorageDecoder) StateKeys() []string {
+	stateKeys := make([]string, 0, len(d.slots))
+	for stateKey := range d.slots {
+		stateKeys = append(stateKeys, stateKey.String())
+	}
+	return stateKeys
+}
+
+// Decode decodes the variables held in a storage slot of a contract with a registered storage layout
+// The value is the 32 byte slot value, or nil if the slot was cleared
+// It returns nothing if the contract has no registered layout, or the slot holds no variable that the layout can locate
+func (d *StorageDecoder) Decode(stateKey, storageKey string, value []byte) []DecodedStorageModel {
+	variables := d.slots[common.HexToHash(stateKey)][common.HexToHash(storageKey)]
+	if len(variables) == 0 {
+		return nil
+	}
+	word := common.LeftPadBytes(value, common.HashLength)
+	decoded := make([]DecodedStorageModel, 0, len(variables))
+	for _, v := range variables {
+		encoded, err := json.Marshal(storageVariableValue(v, word))
+		if err != nil {
+			continue
+		}
+		decoded = append(decoded, DecodedStorageModel{
+			StateKey:   common.HexToHash(stateKey).String(),
+			StorageKey: common.HexToHash(storageKey).String(),
+			Contract:   d.contracts[common.HexToHash(stateKey)].String(),
+			Variable:   v.name,
+			Keys:       v.keys,
+			Type:       v.label,
+			Value:      string(encoded),
+		})
+	}
+	return decoded
+}
+
+// storageVariableValue decodes the value of a variable from the slot that holds it, with the JSON types used for decoded events
+// short strings and bytes are decoded from the slot, while only the length of long ones is held there
+func storageVariableValue(v storageVariable, word []byte) interface{} {
+	if v.encoding == "bytes" {
+		if word[31]&1 == 1 {
+			length := new(big.Int).Rsh(new(big.Int).SetBytes(word), 1)
+			return map[string]interface{}{"length": length}
+		}
+		data := word[:word[31]/2]
+		if v.label == "string" {
+			return string(data)
+		}
+		return hexutil.Encode(data)
+	}
+	raw := word[common.HashLength-v.offset-v.size : common.HashLength-v.offset]
+	switch {
+	case v.label == "bool":
+		return new(big.Int).SetBytes(raw).Sign() != 0
+	case v.label == "address" || v.label == "address payable" || strings.HasPrefix(v.label, "contract "):
+		return common.BytesToAddress(raw).String()
+	case strings.HasPrefix(v.label, "uint") || strings.HasPrefix(v.label, "enum "):
+		return new(big.Int).SetBytes(raw)
+	case strings.HasPrefix(v.label, "int"):
+		return math.S256(new(big.Int).SetBytes(signExtend(raw)))
+	default:
+		return hexutil.Encode(raw)
+	}
+}
+
+// signExtend extends a two's complement integer to 32 bytes
+func signExtend(raw []byte) []byte {
+	extended := make([]byte, common.HashLength)
+	if len(raw) > 0 && raw[0]&0x80 != 0 {
+		for i := range extended {
+			extended[i] = 0xff
+		}
+	}
+	copy(extended[common.HashLength-len(raw):], raw)
+	return extended
+}
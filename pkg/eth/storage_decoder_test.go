@@ -0,0 +1,137 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("Storage decoding", func() {
+	var (
+		dir      string
+		decoder  *eth.StorageDecoder
+		token    = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+		holder   = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476592")
+		stateKey = crypto.Keccak256Hash(token.Bytes()).Hex()
+	)
+	layout := `{
+		"storage": [
+			{"label": "totalSupply", "offset": 0, "slot": "0", "type": "t_uint256"},
+			{"label": "owner", "offset": 0, "slot": "1", "type": "t_address"},
+			{"label": "paused", "offset": 20, "slot": "1", "type": "t_bool"},
+			{"label": "delta", "offset": 21, "slot": "1", "type": "t_int16"},
+			{"label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_uint256)"},
+			{"label": "name", "offset": 0, "slot": "3", "type": "t_string_storage"}
+		],
+		"types": {
+			"t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+			"t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+			"t_int16": {"encoding": "inplace", "label": "int16", "numberOfBytes": "2"},
+			"t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+			"t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+			"t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"}
+		},
+		"keys": {"balances": ["` + holder.Hex() + `"]}
+	}`
+	slotKey := func(slot int64) string {
+		return crypto.Keccak256Hash(common.LeftPadBytes(big.NewInt(slot).Bytes(), common.HashLength)).Hex()
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "layouts")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, token.Hex()+".json"), []byte(layout), 0644)).To(Succeed())
+		decoder, err = eth.NewStorageDecoder(dir)
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Is disabled without a layout directory", func() {
+		decoder, err := eth.NewStorageDecoder("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(decoder).To(BeNil())
+	})
+
+	It("Decodes simple variables and cleared slots", func() {
+		variables := decoder.Decode(stateKey, slotKey(0), common.LeftPadBytes(big.NewInt(1000).Bytes(), common.HashLength))
+		Expect(len(variables)).To(Equal(1))
+		Expect(variables[0].Contract).To(Equal(token.String()))
+		Expect(variables[0].Variable).To(Equal("totalSupply"))
+		Expect(variables[0].Type).To(Equal("uint256"))
+		Expect(variables[0].Keys).To(BeEmpty())
+		Expect(variables[0].Value).To(Equal("1000"))
+
+		variables = decoder.Decode(stateKey, slotKey(0), nil)
+		Expect(len(variables)).To(Equal(1))
+		Expect(variables[0].Value).To(Equal("0"))
+	})
+
+	It("Decodes the variables packed into a slot", func() {
+		// delta = -2 at offset 21, paused = true at offset 20, and owner in the lowest 20 bytes
+		value := append([]byte{0xff, 0xfe, 0x01}, holder.Bytes()...)
+		variables := decoder.Decode(stateKey, slotKey(1), value)
+		Expect(len(variables)).To(Equal(3))
+		values := make(map[string]string)
+		for _, v := range variables {
+			values[v.Variable] = v.Value
+		}
+		Expect(values).To(Equal(map[string]string{
+			"owner":  `"` + holder.String() + `"`,
+			"paused": "true",
+			"delta":  "-2",
+		}))
+	})
+
+	It("Decodes the entries of mappings with known keys", func() {
+		entryKey := crypto.Keccak256Hash(crypto.Keccak256(common.LeftPadBytes(holder.Bytes(), common.HashLength), common.LeftPadBytes([]byte{2}, common.HashLength))).Hex()
+		variables := decoder.Decode(stateKey, entryKey, common.LeftPadBytes(big.NewInt(42).Bytes(), common.HashLength))
+		Expect(len(variables)).To(Equal(1))
+		Expect(variables[0].Variable).To(Equal("balances"))
+		Expect([]string(variables[0].Keys)).To(Equal([]string{holder.Hex()}))
+		Expect(variables[0].Value).To(Equal("42"))
+	})
+
+	It("Decodes short strings and the length of long ones", func() {
+		short := make([]byte, common.HashLength)
+		copy(short, "Dai Stablecoin")
+		short[31] = byte(2 * len("Dai Stablecoin"))
+		variables := decoder.Decode(stateKey, slotKey(3), short)
+		Expect(len(variables)).To(Equal(1))
+		Expect(variables[0].Value).To(Equal(`"Dai Stablecoin"`))
+
+		variables = decoder.Decode(stateKey, slotKey(3), big.NewInt(2*100+1).Bytes())
+		Expect(variables[0].Value).To(MatchJSON(`{"length": 100}`))
+	})
+
+	It("Skips slots of unregistered contracts and unknown slots", func() {
+		Expect(decoder.Decode(crypto.Keccak256Hash(holder.Bytes()).Hex(), slotKey(0), []byte{1})).To(BeEmpty())
+		Expect(decoder.Decode(stateKey, slotKey(4), []byte{1})).To(BeEmpty())
+	})
+})
@@ -25,7 +25,8 @@ import (
 )
 
 const (
-	PayloadChanBufferSize = 10000 // half the max eth sub buffer size
+	// payloads are moved off of the subscription channel and into a PayloadQueue bounded by bytes as soon as they arrive
+	PayloadChanBufferSize = 100
 )
 
 // StreamClient is an interface for subscribing and streaming from geth
@@ -0,0 +1,186 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/postgres"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// DefaultTokenTransferBatchSize is the number of logs checked for transfers in each tx by the TokenTransferBackfiller
+const DefaultTokenTransferBatchSize uint64 = 1000
+
+var (
+	// Transfer(address indexed from, address indexed to, uint256 value) for ERC-20,
+	// and Transfer(address indexed from, address indexed to, uint256 indexed tokenId) for ERC-721
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")).Hex()
+	// TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value) for ERC-1155
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)")).Hex()
+	// TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids, uint256[] values) for ERC-1155
+	transferBatchTopic = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])")).Hex()
+
+	uint256ArrayType, _ = abi.NewType("uint256[]", "", nil)
+	transferBatchData   = abi.Arguments{{Type: uint256ArrayType}, {Type: uint256ArrayType}}
+)
+
+// TokenTransferModels extracts the token transfers of a standard ERC-20, ERC-721, or ERC-1155 transfer event log
+// ERC-20 and ERC-721 Transfer events share a signature, and are told apart by whether the value is indexed
+// A TransferBatch event yields a transfer for each of its token ids, and logs that do not follow the standard layout yield none
+// The tx id and block number of the returned transfers are left to the caller
+func TokenTransferModels(log LogModel) []TokenTransferModel {
+	transfer := TokenTransferModel{
+		LogIndex: log.Index,
+		Token:    common.HexToAddress(log.Address).String(),
+	}
+	switch log.Topic0 {
+	case transferTopic:
+		if log.Topic1 == "" || log.Topic2 == "" {
+			return nil
+		}
+		transfer.Src, transfer.Dst = topicAddress(log.Topic1), topicAddress(log.Topic2)
+		switch {
+		case log.Topic3 == "" && len(log.Data) == common.HashLength:
+			transfer.Standard = ERC20
+			transfer.Amount = new(big.Int).SetBytes(log.Data).String()
+		case log.Topic3 != "" && len(log.Data) == 0:
+			transfer.Standard = ERC721
+			transfer.TokenID = common.HexToHash(log.Topic3).Big().String()
+			transfer.Amount = "1"
+		default:
+			return nil
+		}
+		return []TokenTransferModel{transfer}
+	case transferSingleTopic:
+		if log.Topic3 == "" || len(log.Data) != 2*common.HashLength {
+			return nil
+		}
+		transfer.Standard = ERC1155
+		transfer.Operator, transfer.Src, transfer.Dst = topicAddress(log.Topic1), topicAddress(log.Topic2), topicAddress(log.Topic3)
+		transfer.TokenID = new(big.Int).SetBytes(log.Data[:common.HashLength]).String()
+		transfer.Amount = new(big.Int).SetBytes(log.Data[common.HashLength:]).String()
+		return []TokenTransferModel{transfer}
+	case transferBatchTopic:
+		if log.Topic3 == "" {
+			return nil
+		}
+		values, err := transferBatchData.UnpackValues(log.Data)
+		if err != nil {
+			return nil
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		if len(ids) != len(amounts) {
+			return nil
+		}
+		transfer.Standard = ERC1155
+		transfer.Operator, transfer.Src, transfer.Dst = topicAddress(log.Topic1), topicAddress(log.Topic2), topicAddress(log.Topic3)
+		transfers := make([]TokenTransferModel, len(ids))
+		for i := range ids {
+			transfers[i] = transfer
+			transfers[i].BatchIndex = int64(i)
+			transfers[i].TokenID = ids[i].String()
+			transfers[i].Amount = amounts[i].String()
+		}
+		return transfers
+	default:
+		return nil
+	}
+}
+
+// topicAddress returns the address held in the low 20 bytes of an indexed address topic
+func topicAddress(topic string) string {
+	return common.BytesToAddress(common.HexToHash(topic).Bytes()).String()
+}
+
+// TokenTransferBackfiller extracts the token transfers of the logs already indexed in eth.log_cids,
+// e.g. for logs indexed before token transfer extraction was enabled
+type TokenTransferBackfiller struct {
+	db        *postgres.DB
+	batchSize uint64
+}
+
+// NewTokenTransferBackfiller returns a new TokenTransferBackfiller
+func NewTokenTransferBackfiller(db *postgres.DB, batchSize uint64) *TokenTransferBackfiller {
+	if batchSize == 0 {
+		batchSize = DefaultTokenTransferBatchSize
+	}
+	return &TokenTransferBackfiller{
+		db:        db,
+		batchSize: batchSize,
+	}
+}
+
+// transferLog is an indexed log along with the tx and block it belongs to
+type transferLog struct {
+	LogModel
+	TxID        int64  `db:"tx_id"`
+	BlockNumber string `db:"block_number"`
+}
+
+// Backfill upserts the token transfers of every indexed log with a transfer event signature
+func (tb *TokenTransferBackfiller) Backfill() error {
+	topics := pq.StringArray{transferTopic, transferSingleTopic, transferBatchTopic}
+	var last int64
+	var checked, extracted uint64
+	for {
+		logs := make([]transferLog, 0, tb.batchSize)
+		pgStr := `SELECT log_cids.id, log_cids.log_index, log_cids.address, log_cids.topic0, log_cids.topic1, log_cids.topic2, log_cids.topic3, log_cids.log_data,
+				receipt_cids.tx_id, header_cids.block_number
+				FROM eth.log_cids
+				INNER JOIN eth.receipt_cids ON (log_cids.receipt_id = receipt_cids.id)
+				INNER JOIN eth.transaction_cids ON (receipt_cids.tx_id = transaction_cids.id)
+				INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+				WHERE log_cids.topic0 = ANY($1)
+				AND log_cids.id > $2
+				ORDER BY log_cids.id
+				LIMIT $3`
+		if err := tb.db.Select(&logs, pgStr, topics, last, tb.batchSize); err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			logrus.Infof("token transfer backfiller finished extracting %d transfers from %d logs", extracted, checked)
+			return nil
+		}
+		tx, err := tb.db.Beginx()
+		if err != nil {
+			return err
+		}
+		for _, log := range logs {
+			for _, transfer := range TokenTransferModels(log.LogModel) {
+				transfer.TxID, transfer.BlockNumber = log.TxID, log.BlockNumber
+				if err := indexTokenTransfer(tx, transfer, log.ID); err != nil {
+					shared.Rollback(tx)
+					return err
+				}
+				extracted++
+			}
+		}
+		if err := shared.Commit(tx); err != nil {
+			return err
+		}
+		last = logs[len(logs)-1].ID
+		checked += uint64(len(logs))
+		logrus.Infof("token transfer backfiller extracted %d transfers from %d logs", extracted, checked)
+	}
+}
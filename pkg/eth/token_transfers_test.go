@@ -0,0 +1,140 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package eth_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
+)
+
+var _ = Describe("Token transfers", func() {
+	var (
+		token    = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+		operator = common.HexToAddress("0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984")
+		from     = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476592")
+		to       = common.HexToAddress("0xaE9BEa628c4Ce503DcFD7E305CaB4e29E7476593")
+	)
+	topic := func(sig string) string {
+		return crypto.Keccak256Hash([]byte(sig)).Hex()
+	}
+	addressTopic := func(address common.Address) string {
+		return common.BytesToHash(address.Bytes()).Hex()
+	}
+	word := func(n int64) []byte {
+		return common.LeftPadBytes(big.NewInt(n).Bytes(), common.HashLength)
+	}
+
+	It("Extracts ERC-20 transfers", func() {
+		transfers := eth.TokenTransferModels(eth.LogModel{
+			Index:   3,
+			Address: token.String(),
+			Topic0:  topic("Transfer(address,address,uint256)"),
+			Topic1:  addressTopic(from),
+			Topic2:  addressTopic(to),
+			Data:    word(1000),
+		})
+		Expect(transfers).To(Equal([]eth.TokenTransferModel{{
+			LogIndex: 3,
+			Token:    token.String(),
+			Standard: eth.ERC20,
+			Src:      from.String(),
+			Dst:      to.String(),
+			Amount:   "1000",
+		}}))
+	})
+
+	It("Extracts ERC-721 transfers", func() {
+		transfers := eth.TokenTransferModels(eth.LogModel{
+			Address: token.String(),
+			Topic0:  topic("Transfer(address,address,uint256)"),
+			Topic1:  addressTopic(from),
+			Topic2:  addressTopic(to),
+			Topic3:  common.BigToHash(big.NewInt(42)).Hex(),
+		})
+		Expect(transfers).To(Equal([]eth.TokenTransferModel{{
+			Token:    token.String(),
+			Standard: eth.ERC721,
+			Src:      from.String(),
+			Dst:      to.String(),
+			TokenID:  "42",
+			Amount:   "1",
+		}}))
+	})
+
+	It("Extracts ERC-1155 single and batch transfers", func() {
+		transfers := eth.TokenTransferModels(eth.LogModel{
+			Address: token.String(),
+			Topic0:  topic("TransferSingle(address,address,address,uint256,uint256)"),
+			Topic1:  addressTopic(operator),
+			Topic2:  addressTopic(from),
+			Topic3:  addressTopic(to),
+			Data:    append(word(7), word(5)...),
+		})
+		Expect(transfers).To(Equal([]eth.TokenTransferModel{{
+			Token:    token.String(),
+			Standard: eth.ERC1155,
+			Operator: operator.String(),
+			Src:      from.String(),
+			Dst:      to.String(),
+			TokenID:  "7",
+			Amount:   "5",
+		}}))
+
+		// two dynamic arrays: ids [1, 2] and amounts [10, 20]
+		data := append(word(64), word(160)...)
+		data = append(data, append(word(2), append(word(1), word(2)...)...)...)
+		data = append(data, append(word(2), append(word(10), word(20)...)...)...)
+		transfers = eth.TokenTransferModels(eth.LogModel{
+			Address: token.String(),
+			Topic0:  topic("TransferBatch(address,address,address,uint256[],uint256[])"),
+			Topic1:  addressTopic(operator),
+			Topic2:  addressTopic(from),
+			Topic3:  addressTopic(to),
+			Data:    data,
+		})
+		Expect(len(transfers)).To(Equal(2))
+		Expect(transfers[0].BatchIndex).To(Equal(int64(0)))
+		Expect(transfers[0].TokenID).To(Equal("1"))
+		Expect(transfers[0].Amount).To(Equal("10"))
+		Expect(transfers[1].BatchIndex).To(Equal(int64(1)))
+		Expect(transfers[1].TokenID).To(Equal("2"))
+		Expect(transfers[1].Amount).To(Equal("20"))
+		Expect(transfers[1].Operator).To(Equal(operator.String()))
+	})
+
+	It("Skips logs that do not follow the standard layout", func() {
+		Expect(eth.TokenTransferModels(eth.LogModel{
+			Address: token.String(),
+			Topic0:  topic("Transfer(address,address,uint256)"),
+			Topic1:  addressTopic(from),
+			Data:    append(word(1), word(2)...),
+		})).To(BeEmpty())
+		Expect(eth.TokenTransferModels(eth.LogModel{
+			Address: token.String(),
+			Topic0:  topic("Approval(address,address,uint256)"),
+			Topic1:  addressTopic(from),
+			Topic2:  addressTopic(to),
+			Data:    word(1),
+		})).To(BeEmpty())
+	})
+})
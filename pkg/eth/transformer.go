@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"database/sql"
 	"fmt"
 	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 	"math/big"
@@ -45,17 +46,42 @@ type Transformer interface {
 	Transform(workerID int, payload statediff.Payload) (uint64, error)
 }
 
+// TransformerConfig holds the optional settings for a StateDiffTransformer
+type TransformerConfig struct {
+	// Rewrite every row of a block even if it is already completely indexed
+	ForceReindex bool
+	// Keep eth.latest_accounts and eth.latest_storage up to date
+	LatestState bool
+	// Decoder for the logs of contracts with a registered ABI (optional)
+	Events *EventDecoder
+	// Extract the standard ERC-20, ERC-721, and ERC-1155 transfer events into eth.token_transfers
+	TokenTransfers bool
+	// Decoder for the storage of contracts with a registered storage layout (optional)
+	Storage *StorageDecoder
+	// Worker tuner to report time spent waiting for a free postgres tx to (optional)
+	Tuner *shared.WorkerTuner
+}
+
 // StateDiffTransformer satisfies the Transformer interface for ethereum statediff objects
 type StateDiffTransformer struct {
-	chainConfig *params.ChainConfig
-	indexer     *CIDIndexer
+	chainConfig  *params.ChainConfig
+	indexer      *CIDIndexer
+	forceReindex bool
+	tuner        *shared.WorkerTuner
 }
 
 // NewStateDiffTransformer creates a pointer to a new PayloadConverter which satisfies the PayloadConverter interface
-func NewStateDiffTransformer(chainConfig *params.ChainConfig, db *postgres.DB) *StateDiffTransformer {
+func NewStateDiffTransformer(chainConfig *params.ChainConfig, db *postgres.DB, conf TransformerConfig) *StateDiffTransformer {
+	indexer := NewCIDIndexer(db)
+	indexer.latestState = conf.LatestState
+	indexer.events = conf.Events
+	indexer.tokenTransfers = conf.TokenTransfers
+	indexer.storage = conf.Storage
 	return &StateDiffTransformer{
-		chainConfig: chainConfig,
-		indexer:     NewCIDIndexer(db),
+		chainConfig:  chainConfig,
+		indexer:      indexer,
+		forceReindex: conf.ForceReindex,
+		tuner:        conf.Tuner,
 	}
 }
 
@@ -96,10 +122,14 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 		return 0, fmt.Errorf("expected number of transactions (%d), transaction trie nodes (%d), receipts (%d), and receipt trie nodes (%d)to be equal", len(txNodes), len(txTrieNodes), len(rctNodes), len(rctTrieNodes))
 	}
 	// Calculate reward
-	reward := CalcEthBlockReward(block.Header(), block.Uncles(), block.Transactions(), receipts)
+	reward := CalcEthBlockReward(sdt.chainConfig, block.Header(), block.Uncles(), block.Transactions(), receipts)
 	tDiff := time.Now().Sub(t)
 	prom.SetTimeMetric("t_payload_decode", tDiff)
 	traceMsg += fmt.Sprintf("payload decoding time: %s\r\n", tDiff.String())
+	// Make sure there are partitions to write this block into
+	if err := sdt.indexer.ensurePartitions(height); err != nil {
+		return 0, err
+	}
 	t = time.Now()
 	// Begin new db tx for everything
 	tx, err := sdt.indexer.db.Beginx()
@@ -113,8 +143,10 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 			panic(p)
 		} else if err != nil {
 			shared.Rollback(tx)
+			// the partitions may have been pruned out from under us
+			sdt.indexer.forgetPartitions(height)
 		} else {
-			err = tx.Commit()
+			err = shared.Commit(tx)
 			tDiff := time.Now().Sub(t)
 			prom.SetTimeMetric("t_postgres_commit", tDiff)
 			traceMsg += fmt.Sprintf("postgres transaction commit duration: %s\r\n", tDiff.String())
@@ -124,9 +156,24 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 	}()
 	tDiff = time.Now().Sub(t)
 	prom.SetTimeMetric("t_free_postgres", tDiff)
+	sdt.tuner.ObserveFreePostgres(tDiff)
 	traceMsg += fmt.Sprintf("time spent waiting for free postgres tx: %s:\r\n", tDiff.String())
 	t = time.Now()
 
+	// If this exact block is already completely indexed, only bump its validation level
+	if !sdt.forceReindex {
+		var validated bool
+		validated, err = sdt.revalidate(tx, block, receipts, stateDiff)
+		if err != nil {
+			return 0, err
+		}
+		if validated {
+			tDiff = time.Now().Sub(t)
+			traceMsg += fmt.Sprintf("block already indexed, revalidation time: %s\r\n", tDiff.String())
+			return height, err
+		}
+	}
+
 	// Publish and index header, collect headerID
 	headerID, err := sdt.processHeader(tx, block.Header(), headerNode, reward, payload.TotalDifficulty)
 	if err != nil {
@@ -145,16 +192,18 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 	traceMsg += fmt.Sprintf("uncle processing time: %s\r\n", tDiff.String())
 	t = time.Now()
 	// Publish and index receipts and txs
-	if err := sdt.processReceiptsAndTxs(tx, processArgs{
+	contracts, err := sdt.processReceiptsAndTxs(tx, processArgs{
 		headerID:     headerID,
 		blockNumber:  block.Number(),
+		baseFee:      block.BaseFee(),
 		receipts:     receipts,
 		txs:          transactions,
 		rctNodes:     rctNodes,
 		rctTrieNodes: rctTrieNodes,
 		txNodes:      txNodes,
 		txTrieNodes:  txTrieNodes,
-	}); err != nil {
+	})
+	if err != nil {
 		return 0, err
 	}
 	tDiff = time.Now().Sub(t)
@@ -162,7 +211,8 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 	traceMsg += fmt.Sprintf("tx and receipt processing time: %s\r\n", tDiff.String())
 	t = time.Now()
 	// Publish and index state and storage nodes
-	if err := sdt.processStateAndStorage(tx, headerID, stateDiff); err != nil {
+	codeHashes, err := sdt.processStateAndStorage(tx, headerID, block.Number().String(), stateDiff)
+	if err != nil {
 		return 0, err
 	}
 	tDiff = time.Now().Sub(t)
@@ -172,13 +222,117 @@ func (sdt *StateDiffTransformer) Transform(workerID int, payload statediff.Paylo
 	if err := sdt.processCodeAndCodeHashes(tx, stateDiff.CodeAndCodeHashes); err != nil {
 		return 0, err
 	}
+	if err := sdt.processContracts(tx, contracts, codeHashes); err != nil {
+		return 0, err
+	}
 	tDiff = time.Now().Sub(t)
 	prom.SetTimeMetric("t_code_codehash_processing", tDiff)
 	traceMsg += fmt.Sprintf("code and codehash processing time: %s\r\n", tDiff.String())
 	t = time.Now()
+	// Summarize the block, and update the canonical chain and the latest state if it is maintained
+	if err := sdt.indexer.indexBlockStats(tx, headerID, block.Number().String()); err != nil {
+		return 0, err
+	}
+	if err := sdt.indexer.indexCanonicalState(tx, block.Number().String(), block.Hash().String()); err != nil {
+		return 0, err
+	}
+	tDiff = time.Now().Sub(t)
+	prom.SetTimeMetric("t_canonical_state_processing", tDiff)
+	traceMsg += fmt.Sprintf("canonical state processing time: %s\r\n", tDiff.String())
+	t = time.Now()
 	return height, err // return error explicity so that the defer() assigns to it
 }
 
+// revalidate checks if the block is already indexed with matching roots and the expected number of uncle, transaction, receipt, log, state, and storage rows,
+// and of the rows derived from them (access list elements, address links, contracts, account changes, token transfers, decoded events, and block stats);
+// if so it increments the header's times_validated and updates its canonical state
+// it returns true if the block was revalidated, and false if it needs to be fully (re)indexed
+func (sdt *StateDiffTransformer) revalidate(tx *sqlx.Tx, block *types.Block, receipts types.Receipts, stateDiff *statediff.StateObject) (bool, error) {
+	summary, err := sdt.indexer.retrieveBlockSummary(tx, block.Number().String(), block.Hash().String())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	expected := BlockSummaryModel{
+		HeaderID:   summary.HeaderID,
+		StateRoot:  block.Root().String(),
+		TxRoot:     block.TxHash().String(),
+		RctRoot:    block.ReceiptHash().String(),
+		UncleRoot:  block.UncleHash().String(),
+		UncleCount: int64(len(block.Uncles())),
+		TxCount:    int64(len(block.Transactions())),
+		RctCount:   int64(len(receipts)),
+		StateCount: int64(len(stateDiff.Nodes)),
+		StatsCount: 1,
+	}
+	// an account change is written for every written leaf and every destroyed account, once per state leaf key
+	accounts := make(map[string]bool)
+	for _, stateNode := range stateDiff.Nodes {
+		expected.StorageCount += int64(len(stateNode.StorageNodes))
+		if stateNode.NodeType == sdtypes.Leaf {
+			accounts[common.BytesToHash(stateNode.LeafKey).String()] = true
+		}
+	}
+	destroyed := make(map[string]bool)
+	for _, stateNode := range stateDiff.Nodes {
+		stateKey := common.BytesToHash(stateNode.LeafKey).String()
+		if stateNode.NodeType == sdtypes.Removed && stateKey != nullHash.String() && !accounts[stateKey] {
+			destroyed[stateKey] = true
+		}
+	}
+	expected.AccountChangeCount = int64(len(accounts) + len(destroyed))
+	for _, trx := range block.Transactions() {
+		expected.AccessListCount += int64(len(trx.AccessList()))
+		// every tx has a sender, recovering it to rule out the zero address is not worth an ecrecover per tx
+		expected.AddressTxCount++
+		if shared.HandleZeroAddrPointer(trx.To()) != "" {
+			expected.AddressTxCount++
+		}
+	}
+	var logIndex int64
+	for _, receipt := range receipts {
+		if shared.HandleZeroAddr(receipt.ContractAddress) != "" {
+			expected.ContractCount++
+			expected.AddressTxCount++
+		}
+		// each distinct log contract of the receipt is linked to its tx once
+		logContracts := make(map[common.Address]bool)
+		for _, log := range receipt.Logs {
+			logContracts[log.Address] = true
+		}
+		expected.AddressTxCount += int64(len(logContracts))
+		logs := LogModels(receipt.Logs, logIndex)
+		logIndex += int64(len(logs))
+		expected.LogCount += int64(len(logs))
+		for _, logModel := range logs {
+			if sdt.indexer.tokenTransfers {
+				expected.TokenTransferCount += int64(len(TokenTransferModels(logModel)))
+			}
+			if sdt.indexer.events != nil {
+				if event, _ := sdt.indexer.events.Decode(logModel); event != nil {
+					expected.DecodedEventCount++
+				}
+			}
+		}
+	}
+	// rows of the optional tables are only expected when they are enabled, otherwise reindexing would not write them either
+	if !sdt.indexer.tokenTransfers {
+		expected.TokenTransferCount = summary.TokenTransferCount
+	}
+	if sdt.indexer.events == nil {
+		expected.DecodedEventCount = summary.DecodedEventCount
+	}
+	if *summary != expected {
+		return false, nil
+	}
+	if err := sdt.indexer.incrementValidation(tx, summary.HeaderID); err != nil {
+		return false, err
+	}
+	return true, sdt.indexer.indexCanonicalState(tx, block.Number().String(), block.Hash().String())
+}
+
 // processHeader publishes and indexes a header IPLD in Postgres
 // it returns the headerID
 func (sdt *StateDiffTransformer) processHeader(tx *sqlx.Tx, header *types.Header, headerNode node.Node, reward, td *big.Int) (int64, error) {
@@ -187,7 +341,7 @@ func (sdt *StateDiffTransformer) processHeader(tx *sqlx.Tx, header *types.Header
 		return 0, err
 	}
 	// index header
-	return sdt.indexer.indexHeaderCID(tx, HeaderModel{
+	model := HeaderModel{
 		CID:             headerNode.Cid().String(),
 		MhKey:           shared.MultihashKeyFromCID(headerNode.Cid()),
 		ParentHash:      header.ParentHash.String(),
@@ -201,7 +355,10 @@ func (sdt *StateDiffTransformer) processHeader(tx *sqlx.Tx, header *types.Header
 		TxRoot:          header.TxHash.String(),
 		UncleRoot:       header.UncleHash.String(),
 		Timestamp:       header.Time,
-	})
+		Producer:        BlockProducer(sdt.chainConfig, header),
+	}
+	setHeaderMetaData(&model, header)
+	return sdt.indexer.indexHeaderCID(tx, model)
 }
 
 func (sdt *StateDiffTransformer) processUncles(tx *sqlx.Tx, headerID int64, blockNumber uint64, uncleNodes []*ipld.EthHeader) error {
@@ -210,7 +367,7 @@ func (sdt *StateDiffTransformer) processUncles(tx *sqlx.Tx, headerID int64, bloc
 		if err := shared.PublishIPLD(tx, uncleNode); err != nil {
 			return err
 		}
-		uncleReward := CalcUncleMinerReward(blockNumber, uncleNode.Number.Uint64())
+		uncleReward := CalcUncleMinerReward(sdt.chainConfig, blockNumber, uncleNode.Number.Uint64())
 		uncle := UncleModel{
 			CID:        uncleNode.Cid().String(),
 			MhKey:      shared.MultihashKeyFromCID(uncleNode.Cid()),
@@ -218,6 +375,7 @@ func (sdt *StateDiffTransformer) processUncles(tx *sqlx.Tx, headerID int64, bloc
 			BlockHash:  uncleNode.Hash().String(),
 			Reward:     uncleReward.String(),
 		}
+		setUncleMetaData(&uncle, uncleNode.Header)
 		if err := sdt.indexer.indexUncleCID(tx, uncle, headerID); err != nil {
 			return err
 		}
@@ -229,6 +387,7 @@ func (sdt *StateDiffTransformer) processUncles(tx *sqlx.Tx, headerID int64, bloc
 type processArgs struct {
 	headerID     int64
 	blockNumber  *big.Int
+	baseFee      *big.Int
 	receipts     types.Receipts
 	txs          types.Transactions
 	rctNodes     []*ipld.EthReceipt
@@ -238,32 +397,36 @@ type processArgs struct {
 }
 
 // processReceiptsAndTxs publishes and indexes receipt and transaction IPLDs in Postgres
-func (sdt *StateDiffTransformer) processReceiptsAndTxs(tx *sqlx.Tx, args processArgs) error {
+// It returns the contracts created by the txs, which are indexed once the code hashes of their accounts are known
+func (sdt *StateDiffTransformer) processReceiptsAndTxs(tx *sqlx.Tx, args processArgs) ([]ContractModel, error) {
 	// Process receipts and txs
 	signer := types.MakeSigner(sdt.chainConfig, args.blockNumber)
+	contracts := make([]ContractModel, 0)
+	var logIndex int64
+	var prevCumulativeGasUsed uint64
 	for i, receipt := range args.receipts {
 		// tx that corresponds with this receipt
 		trx := args.txs[i]
 		from, err := types.Sender(signer, trx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Publishing
 		// publish trie nodes, these aren't indexed directly
 		if err := shared.PublishIPLD(tx, args.txTrieNodes[i]); err != nil {
-			return err
+			return nil, err
 		}
 		if err := shared.PublishIPLD(tx, args.rctTrieNodes[i]); err != nil {
-			return err
+			return nil, err
 		}
 		// publish the txs and receipts
 		txNode, rctNode := args.txNodes[i], args.rctNodes[i]
 		if err := shared.PublishIPLD(tx, txNode); err != nil {
-			return err
+			return nil, err
 		}
 		if err := shared.PublishIPLD(tx, rctNode); err != nil {
-			return err
+			return nil, err
 		}
 
 		// Indexing
@@ -297,9 +460,11 @@ func (sdt *StateDiffTransformer) processReceiptsAndTxs(tx *sqlx.Tx, args process
 			CID:    txNode.Cid().String(),
 			MhKey:  shared.MultihashKeyFromCID(txNode.Cid()),
 		}
+		setTxMetaData(&txModel, trx)
+		setTxFees(&txModel, trx, args.baseFee, receipt.CumulativeGasUsed-prevCumulativeGasUsed)
 		txID, err := sdt.indexer.indexTransactionCID(tx, txModel, args.headerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// index the receipt
 		rctModel := ReceiptModel{
@@ -310,54 +475,91 @@ func (sdt *StateDiffTransformer) processReceiptsAndTxs(tx *sqlx.Tx, args process
 			Contract:     contract,
 			ContractHash: contractHash,
 			LogContracts: logContracts,
+			Logs:         LogModels(receipt.Logs, logIndex),
 			CID:          rctNode.Cid().String(),
 			MhKey:        shared.MultihashKeyFromCID(rctNode.Cid()),
 		}
+		logIndex += int64(len(receipt.Logs))
+		setReceiptMetaData(&rctModel, receipt, prevCumulativeGasUsed)
+		prevCumulativeGasUsed = receipt.CumulativeGasUsed
 		if len(receipt.PostState) == 0 {
 			rctModel.PostStatus = receipt.Status
 		} else {
 			rctModel.PostState = common.Bytes2Hex(receipt.PostState)
 		}
-		if err := sdt.indexer.indexReceiptCID(tx, rctModel, txID); err != nil {
-			return err
+		if err := sdt.indexer.indexReceiptCID(tx, rctModel, txID, args.blockNumber.String()); err != nil {
+			return nil, err
+		}
+		if contract != "" {
+			contracts = append(contracts, ContractModel{
+				TxID:        txID,
+				BlockNumber: args.blockNumber.String(),
+				Address:     contract,
+				Creator:     txModel.Src,
+			})
 		}
 	}
-	return nil
+	return contracts, nil
 }
 
 // processStateAndStorage publishes and indexes state and storage nodes in Postgres
-func (sdt *StateDiffTransformer) processStateAndStorage(tx *sqlx.Tx, headerID int64, stateDiff *statediff.StateObject) error {
+// It returns the code hashes of the accounts in the diff, keyed by their state leaf keys
+func (sdt *StateDiffTransformer) processStateAndStorage(tx *sqlx.Tx, headerID int64, blockNumber string, stateDiff *statediff.StateObject) (map[string][]byte, error) {
+	codeHashes := make(map[string][]byte)
+	// a removed leaf whose key is written elsewhere in the same diff was moved, not destroyed
+	writtenAccounts := make(map[string]bool)
 	for _, stateNode := range stateDiff.Nodes {
+		if stateNode.NodeType == sdtypes.Leaf {
+			writtenAccounts[common.BytesToHash(stateNode.LeafKey).String()] = true
+		}
+	}
+	for _, stateNode := range stateDiff.Nodes {
+		stateKey := common.BytesToHash(stateNode.LeafKey).String()
+		// removed nodes have no value to publish, so they are only recorded
+		// the statediff does not carry storage nodes for them
+		if stateNode.NodeType == sdtypes.Removed {
+			removal := StateRemovalModel{
+				BlockNumber: blockNumber,
+				Path:        stateNode.Path,
+				StateKey:    stateKey,
+				Destroyed:   stateKey != nullHash.String() && !writtenAccounts[stateKey],
+			}
+			if err := sdt.indexer.indexStateRemoval(tx, removal, headerID); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		// publish the state node
 		stateCIDStr, err := shared.PublishRaw(tx, ipld.MEthStateTrie, multihash.KECCAK_256, stateNode.NodeValue)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		mhKey, _ := shared.MultihashKeyFromCIDString(stateCIDStr)
 		stateModel := StateNodeModel{
-			Path:     stateNode.Path,
-			StateKey: common.BytesToHash(stateNode.LeafKey).String(),
-			CID:      stateCIDStr,
-			MhKey:    mhKey,
-			NodeType: ResolveFromNodeType(stateNode.NodeType),
+			BlockNumber: blockNumber,
+			Path:        stateNode.Path,
+			StateKey:    stateKey,
+			CID:         stateCIDStr,
+			MhKey:       mhKey,
+			NodeType:    ResolveFromNodeType(stateNode.NodeType),
 		}
 		// index the state node, collect the stateID to reference by FK
 		stateID, err := sdt.indexer.indexStateCID(tx, stateModel, headerID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		// if we have a leaf, decode and index the account data
 		if stateNode.NodeType == sdtypes.Leaf {
 			var i []interface{}
 			if err := rlp.DecodeBytes(stateNode.NodeValue, &i); err != nil {
-				return fmt.Errorf("error decoding state leaf node rlp: %s", err.Error())
+				return nil, fmt.Errorf("error decoding state leaf node rlp: %s", err.Error())
 			}
 			if len(i) != 2 {
-				return fmt.Errorf("eth IPLDPublisher expected state leaf node rlp to decode into two elements")
+				return nil, fmt.Errorf("eth IPLDPublisher expected state leaf node rlp to decode into two elements")
 			}
 			var account state.Account
 			if err := rlp.DecodeBytes(i[1].([]byte), &account); err != nil {
-				return fmt.Errorf("error decoding state account rlp: %s", err.Error())
+				return nil, fmt.Errorf("error decoding state account rlp: %s", err.Error())
 			}
 			accountModel := StateAccountModel{
 				Balance:     account.Balance.String(),
@@ -366,29 +568,65 @@ func (sdt *StateDiffTransformer) processStateAndStorage(tx *sqlx.Tx, headerID in
 				StorageRoot: account.Root.String(),
 			}
 			if err := sdt.indexer.indexStateAccount(tx, accountModel, stateID); err != nil {
-				return err
+				return nil, err
+			}
+			if err := sdt.indexer.indexAccountChange(tx, stateModel.StateKey, stateModel.BlockNumber, accountModel, headerID); err != nil {
+				return nil, err
 			}
+			codeHashes[stateModel.StateKey] = account.CodeHash
 		}
 		// if there are any storage nodes associated with this node, publish and index them
+		writtenSlots := make(map[string]bool)
+		for _, storageNode := range stateNode.StorageNodes {
+			if storageNode.NodeType == sdtypes.Leaf {
+				writtenSlots[common.BytesToHash(storageNode.LeafKey).String()] = true
+			}
+		}
 		for _, storageNode := range stateNode.StorageNodes {
+			storageKey := common.BytesToHash(storageNode.LeafKey).String()
+			if storageNode.NodeType == sdtypes.Removed {
+				removal := StorageRemovalModel{
+					BlockNumber: blockNumber,
+					StateKey:    stateKey,
+					Path:        storageNode.Path,
+					StorageKey:  storageKey,
+					Cleared:     storageKey != nullHash.String() && !writtenSlots[storageKey],
+				}
+				if err := sdt.indexer.indexStorageRemoval(tx, removal, headerID); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			storageCIDStr, err := shared.PublishRaw(tx, ipld.MEthStorageTrie, multihash.KECCAK_256, storageNode.NodeValue)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			mhKey, _ := shared.MultihashKeyFromCIDString(storageCIDStr)
 			storageModel := StorageNodeModel{
-				Path:       storageNode.Path,
-				StorageKey: common.BytesToHash(storageNode.LeafKey).String(),
-				CID:        storageCIDStr,
-				MhKey:      mhKey,
-				NodeType:   ResolveFromNodeType(storageNode.NodeType),
+				BlockNumber: blockNumber,
+				Path:        storageNode.Path,
+				StorageKey:  storageKey,
+				CID:         storageCIDStr,
+				MhKey:       mhKey,
+				NodeType:    ResolveFromNodeType(storageNode.NodeType),
+			}
+			// if we have a leaf, decode the slot value
+			if storageNode.NodeType == sdtypes.Leaf {
+				if storageModel.Value, err = storageLeafValue(storageNode.NodeValue); err != nil {
+					return nil, fmt.Errorf("error decoding storage leaf node rlp: %s", err.Error())
+				}
 			}
 			if err := sdt.indexer.indexStorageCID(tx, storageModel, stateID); err != nil {
-				return err
+				return nil, err
+			}
+			if storageNode.NodeType == sdtypes.Leaf {
+				if err := sdt.indexer.indexDecodedStorage(tx, stateKey, storageKey, blockNumber, storageModel.Value, headerID); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
-	return nil
+	return codeHashes, nil
 }
 
 // processCodeAndCodeHashes publishes code and codehash pairs to the ipld database
@@ -405,3 +643,19 @@ func (sdt *StateDiffTransformer) processCodeAndCodeHashes(tx *sqlx.Tx, codeAndCo
 	}
 	return nil
 }
+
+// processContracts indexes the contracts created in the block, along with the code hashes of their accounts when they are in the diff
+func (sdt *StateDiffTransformer) processContracts(tx *sqlx.Tx, contracts []ContractModel, codeHashes map[string][]byte) error {
+	for _, contract := range contracts {
+		leafKey := crypto.Keccak256Hash(common.HexToAddress(contract.Address).Bytes()).String()
+		if codeHash, ok := codeHashes[leafKey]; ok {
+			if err := setContractCode(&contract, codeHash); err != nil {
+				return err
+			}
+		}
+		if err := sdt.indexer.indexContract(tx, contract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
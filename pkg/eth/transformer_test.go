@@ -17,8 +17,13 @@
 package eth_test
 
 import (
+	"strconv"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/statediff/testhelpers"
+	sdtypes "github.com/ethereum/go-ethereum/statediff/types"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-ipfs-blockstore"
 	"github.com/ipfs/go-ipfs-ds-help"
@@ -42,7 +47,7 @@ var _ = Describe("PublishAndIndexer", func() {
 	BeforeEach(func() {
 		db, err = shared.SetupDB()
 		Expect(err).ToNot(HaveOccurred())
-		transformer = eth.NewStateDiffTransformer(params.MainnetChainConfig, db)
+		transformer = eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{})
 		var blockNumber uint64
 		blockNumber, err = transformer.Transform(1, mocks.MockStateDiffPayload)
 		Expect(err).ToNot(HaveOccurred())
@@ -69,7 +74,7 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(header.CID).To(Equal(mocks.HeaderCID.String()))
 			Expect(header.TD).To(Equal(mocks.MockBlock.Difficulty().String()))
-			Expect(header.Reward).To(Equal("5000000000000011250"))
+			Expect(header.Reward).To(Equal("5000000000000036250"))
 			dc, err := cid.Decode(header.CID)
 			Expect(err).ToNot(HaveOccurred())
 			mhKey := dshelp.MultihashToDsKey(dc.Hash())
@@ -80,6 +85,22 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(data).To(Equal(mocks.MockHeaderRlp))
 		})
 
+		It("Indexes the decoded header fields", func() {
+			header := new(eth.HeaderModel)
+			pgStr := `SELECT coinbase, difficulty, gas_limit, gas_used, extra_data, mix_digest, nonce
+				FROM eth.header_cids
+				WHERE block_number = $1`
+			err = db.Get(header, pgStr, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Coinbase).To(Equal(mocks.MockHeader.Coinbase.String()))
+			Expect(header.Difficulty).To(Equal(mocks.MockHeader.Difficulty.String()))
+			Expect(header.GasLimit).To(Equal(mocks.MockHeader.GasLimit))
+			Expect(header.GasUsed).To(Equal(mocks.MockHeader.GasUsed))
+			Expect(header.ExtraData).To(Equal(mocks.MockHeader.Extra))
+			Expect(header.MixDigest).To(Equal(mocks.MockHeader.MixDigest.String()))
+			Expect(header.Nonce).To(Equal(strconv.FormatUint(mocks.MockHeader.Nonce.Uint64(), 10)))
+		})
+
 		It("Publishes and indexes transaction IPLDs in a single tx", func() {
 			// check that txs were properly indexed
 			trxs := make([]string, 0)
@@ -159,6 +180,120 @@ var _ = Describe("PublishAndIndexer", func() {
 			}
 		})
 
+		It("Indexes the decoded transaction and receipt metadata", func() {
+			type metaData struct {
+				Gas               uint64 `db:"gas"`
+				GasPrice          string `db:"gas_price"`
+				Value             string `db:"value"`
+				Nonce             uint64 `db:"nonce"`
+				Type              int64  `db:"tx_type"`
+				GasUsed           uint64 `db:"gas_used"`
+				CumulativeGasUsed uint64 `db:"cumulative_gas_used"`
+				LogBloom          []byte `db:"log_bloom"`
+			}
+			pgStr := `SELECT transaction_cids.gas, transaction_cids.gas_price, transaction_cids.value, transaction_cids.nonce, transaction_cids.tx_type,
+				receipt_cids.gas_used, receipt_cids.cumulative_gas_used, receipt_cids.log_bloom
+				FROM eth.transaction_cids INNER JOIN eth.receipt_cids ON (receipt_cids.tx_id = transaction_cids.id)
+				WHERE transaction_cids.tx_hash = $1`
+			var prevCumulativeGasUsed uint64
+			for i, trx := range mocks.MockTransactions {
+				res := new(metaData)
+				err = db.Get(res, pgStr, trx.Hash().String())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(res.Gas).To(Equal(trx.Gas()))
+				Expect(res.GasPrice).To(Equal(trx.GasPrice().String()))
+				Expect(res.Value).To(Equal(trx.Value().String()))
+				Expect(res.Nonce).To(Equal(trx.Nonce()))
+				Expect(res.Type).To(Equal(int64(trx.Type())))
+				receipt := mocks.MockReceipts[i]
+				Expect(res.GasUsed).To(Equal(receipt.CumulativeGasUsed - prevCumulativeGasUsed))
+				Expect(res.CumulativeGasUsed).To(Equal(receipt.CumulativeGasUsed))
+				Expect(res.LogBloom).To(Equal(receipt.Bloom.Bytes()))
+				prevCumulativeGasUsed = receipt.CumulativeGasUsed
+			}
+		})
+
+		It("Indexes the fee caps, effective gas price and priority fee of each transaction", func() {
+			pgStr := `SELECT max_fee_per_gas, max_priority_fee_per_gas, effective_gas_price, priority_fee
+				FROM eth.transaction_cids
+				WHERE tx_hash = $1`
+			var prevCumulativeGasUsed uint64
+			for i, trx := range mocks.MockTransactions {
+				res := new(eth.TxModel)
+				err = db.Get(res, pgStr, trx.Hash().String())
+				Expect(err).ToNot(HaveOccurred())
+				gasUsed := mocks.MockReceipts[i].CumulativeGasUsed - prevCumulativeGasUsed
+				Expect(res.MaxFeePerGas).To(Equal(trx.GasFeeCap().String()))
+				Expect(res.MaxPriorityFeePerGas).To(Equal(trx.GasTipCap().String()))
+				Expect(res.EffectiveGasPrice).To(Equal(eth.CalcEffectiveGasPrice(trx, mocks.MockHeader.BaseFee).String()))
+				Expect(res.PriorityFee).To(Equal(eth.CalcPriorityFee(trx, mocks.MockHeader.BaseFee, gasUsed).String()))
+				prevCumulativeGasUsed = mocks.MockReceipts[i].CumulativeGasUsed
+			}
+		})
+
+		It("Indexes each log of the receipts", func() {
+			logs := make([]eth.LogModel, 0)
+			pgStr := `SELECT log_cids.log_index, log_cids.address, log_cids.topic0, log_cids.topic1, log_cids.topic2, log_cids.topic3, log_cids.log_data
+				FROM eth.log_cids, eth.receipt_cids, eth.transaction_cids, eth.header_cids
+				WHERE log_cids.receipt_id = receipt_cids.id
+				AND receipt_cids.tx_id = transaction_cids.id
+				AND transaction_cids.header_id = header_cids.id
+				AND header_cids.block_number = $1
+				ORDER BY log_cids.log_index`
+			err = db.Select(&logs, pgStr, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(logs)).To(Equal(2))
+			Expect(logs[0].Index).To(Equal(int64(0)))
+			Expect(logs[0].Address).To(Equal(mocks.MockLog1.Address.String()))
+			Expect(logs[0].Topic0).To(Equal(mocks.MockLog1.Topics[0].String()))
+			Expect(logs[0].Topic1).To(Equal(mocks.MockLog1.Topics[1].String()))
+			Expect(logs[0].Topic2).To(BeEmpty())
+			Expect(logs[1].Index).To(Equal(int64(1)))
+			Expect(logs[1].Address).To(Equal(mocks.MockLog2.Address.String()))
+			Expect(logs[1].Topic0).To(Equal(mocks.MockLog2.Topics[0].String()))
+			Expect(logs[1].Topic1).To(Equal(mocks.MockLog2.Topics[1].String()))
+		})
+
+		It("Indexes the contracts created by the transactions", func() {
+			contracts := make([]eth.ContractModel, 0)
+			pgStr := `SELECT contracts.block_number, contracts.address, contracts.creator, contracts.code_hash, contracts.code_mh_key
+				FROM eth.contracts INNER JOIN eth.transaction_cids ON (contracts.tx_id = transaction_cids.id)
+				WHERE transaction_cids.tx_hash = $1`
+			err = db.Select(&contracts, pgStr, mocks.MockTransactions[2].Hash().String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(contracts)).To(Equal(1))
+			codeMhKey, err := shared.MultihashKeyFromKeccak256(mocks.ContractCodeHash)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contracts[0].BlockNumber).To(Equal("1"))
+			Expect(contracts[0].Address).To(Equal(mocks.ContractAddress.String()))
+			Expect(contracts[0].Creator).To(Equal(mocks.SenderAddr.String()))
+			Expect(contracts[0].CodeHash).To(Equal(mocks.ContractCodeHash.Bytes()))
+			Expect(contracts[0].CodeMhKey).To(Equal(codeMhKey))
+		})
+
+		It("Links each address to the transactions it took part in", func() {
+			links := make([]eth.AddressTransactionModel, 0)
+			pgStr := `SELECT address_transactions.address, address_transactions.role
+				FROM eth.address_transactions INNER JOIN eth.transaction_cids ON (address_transactions.tx_id = transaction_cids.id)
+				WHERE transaction_cids.tx_hash = $1
+				ORDER BY address_transactions.role`
+			err = db.Select(&links, pgStr, mocks.MockTransactions[0].Hash().String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(links).To(Equal([]eth.AddressTransactionModel{
+				{Address: mocks.SenderAddr.String(), Role: eth.SenderRole},
+				{Address: mocks.Address.String(), Role: eth.RecipientRole},
+				{Address: mocks.Address.String(), Role: eth.LogEmitterRole},
+			}))
+
+			links = make([]eth.AddressTransactionModel, 0)
+			err = db.Select(&links, pgStr, mocks.MockTransactions[2].Hash().String())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(links).To(Equal([]eth.AddressTransactionModel{
+				{Address: mocks.SenderAddr.String(), Role: eth.SenderRole},
+				{Address: mocks.ContractAddress.String(), Role: eth.ContractRole},
+			}))
+		})
+
 		It("Publishes and indexes state IPLDs in a single tx", func() {
 			// check that state nodes were properly indexed and published
 			stateNodes := make([]eth.StateNodeModel, 0)
@@ -240,6 +375,14 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(data).To(Equal(mocks.StorageLeafNode))
 		})
 
+		It("Decodes the value of storage leaf nodes", func() {
+			var value []byte
+			err = db.Get(&value, `SELECT storage_cids.value FROM eth.storage_cids WHERE storage_leaf_key = $1`,
+				common.BytesToHash(mocks.StorageLeafKey).Hex())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal(common.LeftPadBytes(mocks.StorageValue, common.HashLength)))
+		})
+
 		It("Publishes code and codehash", func() {
 			code := make([]byte, 0)
 			key, err := shared.MultihashKeyFromKeccak256(mocks.MockCodeHash)
@@ -250,4 +393,197 @@ var _ = Describe("PublishAndIndexer", func() {
 			Expect(code).To(Equal(mocks.MockContractByteCode))
 		})
 	})
+
+	Describe("Revalidation", func() {
+		It("Increments times_validated without rewriting an already complete block", func() {
+			// the block has logs and a contract creation, which are linked to their txs as well
+			var logs, contracts, logEmitters int
+			err = db.Get(&logs, `SELECT COUNT(*) FROM eth.log_cids`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logs).To(Equal(2))
+			err = db.Get(&contracts, `SELECT COUNT(*) FROM eth.address_transactions WHERE role = $1`, eth.ContractRole)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contracts).To(Equal(1))
+			err = db.Get(&logEmitters, `SELECT COUNT(*) FROM eth.address_transactions WHERE role = $1`, eth.LogEmitterRole)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(logEmitters).To(Equal(2))
+
+			var txID int64
+			pgStr := `SELECT transaction_cids.id FROM eth.transaction_cids INNER JOIN eth.header_cids ON (transaction_cids.header_id = header_cids.id)
+				WHERE header_cids.block_number = $1 AND transaction_cids.index = 0`
+			err = db.Get(&txID, pgStr, 1)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec(`UPDATE eth.transaction_cids SET tx_data = NULL WHERE id = $1`, txID)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = transformer.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			var timesValidated int64
+			err = db.Get(&timesValidated, `SELECT times_validated FROM eth.header_cids WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timesValidated).To(Equal(int64(2)))
+			var data []byte
+			err = db.Get(&data, `SELECT tx_data FROM eth.transaction_cids WHERE id = $1`, txID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(BeNil())
+		})
+
+		It("Increments times_validated without rewriting a complete block that destroyed an account", func() {
+			stateDiff := mocks.MockStateDiff
+			stateDiff.Nodes = append(append([]sdtypes.StateNode{}, mocks.StateDiffs...), sdtypes.StateNode{
+				Path:     []byte{'\x0d'},
+				NodeType: sdtypes.Removed,
+				LeafKey:  testhelpers.AddressToLeafKey(mocks.AnotherAddress),
+			})
+			payload := mocks.MockStateDiffPayload
+			payload.StateObjectRlp, err = rlp.EncodeToBytes(stateDiff)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = transformer.Transform(1, payload)
+			Expect(err).ToNot(HaveOccurred())
+			var changes int
+			err = db.Get(&changes, `SELECT COUNT(*) FROM eth.account_changes WHERE state_leaf_key = $1`,
+				common.BytesToHash(testhelpers.AddressToLeafKey(mocks.AnotherAddress)).Hex())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(Equal(1))
+			_, err = db.Exec(`UPDATE eth.transaction_cids SET tx_data = NULL`)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = transformer.Transform(1, payload)
+			Expect(err).ToNot(HaveOccurred())
+			var timesValidated int64
+			err = db.Get(&timesValidated, `SELECT times_validated FROM eth.header_cids WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timesValidated).To(Equal(int64(3)))
+			var rewritten int
+			err = db.Get(&rewritten, `SELECT COUNT(*) FROM eth.transaction_cids WHERE tx_data IS NOT NULL`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rewritten).To(BeZero())
+		})
+
+		It("Rewrites an already complete block when forced to", func() {
+			forced := eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{ForceReindex: true})
+			_, err = db.Exec(`UPDATE eth.header_cids SET reward = 0 WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = forced.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			var header eth.HeaderModel
+			err = db.Get(&header, `SELECT reward, times_validated FROM eth.header_cids WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Reward).To(Equal("5000000000000036250"))
+			Expect(header.TimesValidated).To(Equal(int64(2)))
+		})
+
+		It("Rewrites a block that is missing rows", func() {
+			_, err = db.Exec(`DELETE FROM eth.storage_cids`)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec(`UPDATE eth.header_cids SET reward = 0 WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = transformer.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			var reward string
+			err = db.Get(&reward, `SELECT reward FROM eth.header_cids WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reward).To(Equal("5000000000000036250"))
+		})
+
+		It("Rewrites a block that is missing derived rows", func() {
+			// as for a block indexed before the derived tables existed
+			_, err = db.Exec(`DELETE FROM eth.address_transactions`)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = db.Exec(`DELETE FROM eth.block_stats`)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = transformer.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			var addressTxs, stats int
+			err = db.Get(&addressTxs, `SELECT COUNT(*) FROM eth.address_transactions`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(addressTxs).ToNot(BeZero())
+			err = db.Get(&stats, `SELECT COUNT(*) FROM eth.block_stats WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal(1))
+		})
+
+		It("Recomputes only the rewards of an indexed block", func() {
+			_, err = db.Exec(`UPDATE eth.header_cids SET reward = 0 WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			rewards := eth.NewRewardTransformer(params.RinkebyChainConfig, db)
+			_, err = rewards.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			var header eth.HeaderModel
+			err = db.Get(&header, `SELECT reward, times_validated FROM eth.header_cids WHERE block_number = $1`, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(header.Reward).To(Equal("36250"))
+			Expect(header.TimesValidated).To(Equal(int64(1)))
+		})
+	})
+
+	Describe("Latest state", func() {
+		expectLatestState := func(accounts, slots int) {
+			balances := make([]string, 0)
+			err = db.Select(&balances, `SELECT balance FROM eth.latest_accounts ORDER BY balance`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(balances)).To(Equal(accounts))
+			storageKeys := make([]string, 0)
+			err = db.Select(&storageKeys, `SELECT storage_leaf_key FROM eth.latest_storage WHERE state_leaf_key = $1`,
+				common.BytesToHash(mocks.ContractLeafKey).Hex())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(storageKeys)).To(Equal(slots))
+			if accounts > 0 {
+				Expect(balances).To(Equal([]string{"0", "1000"}))
+			}
+			if slots > 0 {
+				Expect(storageKeys[0]).To(Equal(common.BytesToHash(mocks.StorageLeafKey).Hex()))
+			}
+		}
+
+		It("Keeps the latest accounts and storage up to date when enabled", func() {
+			expectLatestState(0, 0)
+			latest := eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{LatestState: true})
+			_, err = latest.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			expectLatestState(2, 1)
+		})
+
+		It("Applies the diffs of each canonical block the same as a rebuild", func() {
+			latestRows := func() []string {
+				rows := make([]string, 0)
+				err = db.Select(&rows, `SELECT state_leaf_key || ':' || block_number || ':' || header_id || ':' || balance
+					FROM eth.latest_accounts ORDER BY state_leaf_key`)
+				Expect(err).ToNot(HaveOccurred())
+				slots := make([]string, 0)
+				err = db.Select(&slots, `SELECT state_leaf_key || ':' || storage_leaf_key || ':' || block_number || ':' || cid
+					FROM eth.latest_storage ORDER BY state_leaf_key, storage_leaf_key`)
+				Expect(err).ToNot(HaveOccurred())
+				return append(rows, slots...)
+			}
+			latest := eth.NewStateDiffTransformer(params.MainnetChainConfig, db, eth.TransformerConfig{LatestState: true})
+			_, err = latest.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			// applying the same block again leaves the tables as they are
+			_, err = latest.Transform(1, mocks.MockStateDiffPayload)
+			Expect(err).ToNot(HaveOccurred())
+			applied := latestRows()
+			Expect(applied).To(HaveLen(3))
+
+			err = eth.RebuildLatestState(db)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(latestRows()).To(Equal(applied))
+		})
+
+		It("Rebuilds the latest state from the canonical history", func() {
+			err = eth.RebuildLatestState(db)
+			Expect(err).ToNot(HaveOccurred())
+			expectLatestState(2, 1)
+
+			_, err = db.Exec(`UPDATE eth.header_cids SET canonical = FALSE`)
+			Expect(err).ToNot(HaveOccurred())
+			err = eth.RebuildLatestState(db)
+			Expect(err).ToNot(HaveOccurred())
+			expectLatestState(0, 0)
+		})
+	})
 })
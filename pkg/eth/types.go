@@ -56,4 +56,6 @@ type CIDPayload struct {
 	StateNodeCIDs   []StateNodeModel
 	StateAccounts   map[string]StateAccountModel
 	StorageNodeCIDs map[string][]StorageNodeModel
+	StateRemovals   []StateRemovalModel
+	StorageRemovals []StorageRemovalModel
 }
@@ -36,6 +36,10 @@ const (
 	BACKFILL_BATCH_SIZE       = "BACKFILL_BATCH_SIZE"
 	BACKFILL_WORKERS          = "BACKFILL_WORKERS"
 	BACKFILL_VALIDATION_LEVEL = "BACKFILL_VALIDATION_LEVEL"
+	BACKFILL_MAX_QUEUE_MB     = "BACKFILL_MAX_QUEUE_MB"
+	BACKFILL_AUTO_TUNE        = "BACKFILL_AUTO_TUNE"
+	BACKFILL_MIN_WORKERS      = "BACKFILL_MIN_WORKERS"
+	BACKFILL_MAX_WORKERS      = "BACKFILL_MAX_WORKERS"
 
 	BACKFILL_MAX_IDLE_CONNECTIONS = "BACKFILL_MAX_IDLE_CONNECTIONS"
 	BACKFILL_MAX_OPEN_CONNECTIONS = "BACKFILL_MAX_OPEN_CONNECTIONS"
@@ -51,9 +55,26 @@ type Config struct {
 	Frequency       time.Duration
 	BatchSize       uint64
 	Workers         uint64
+	MaxQueue        uint64 // Max total size of fetched payloads held by the workers, in bytes
 	ValidationLevel int
 	Timeout         time.Duration // HTTP connection timeout in seconds
 	NodeInfo        node.Info
+	// Genesis or chain config file for chains without a built in config (optional)
+	ChainConfigPath string
+	// Worker tuning settings
+	AutoTune   bool
+	MinWorkers uint64
+	MaxWorkers uint64
+	// Keep the latest state tables up to date
+	LatestState bool
+	// How often to refresh the hourly and daily block stats rollups
+	RollupInterval time.Duration
+	// Directory of contract ABIs to decode logs with (optional)
+	EventABIDir string
+	// Extract the standard token transfer events
+	TokenTransfers bool
+	// Directory of contract storage layouts to decode storage with (optional)
+	StorageLayoutDir string
 }
 
 // NewConfig is used to initialize a historical config from a .toml file
@@ -62,11 +83,21 @@ func NewConfig() (*Config, error) {
 	var err error
 
 	viper.BindEnv("ethereum.httpPath", shared.ETH_HTTP_PATH)
+	viper.BindEnv("ethereum.chainConfig", shared.ETH_CHAIN_CONFIG)
 	viper.BindEnv("backfill.frequency", BACKFILL_FREQUENCY)
 	viper.BindEnv("backfill.batchSize", BACKFILL_BATCH_SIZE)
 	viper.BindEnv("backfill.workers", BACKFILL_WORKERS)
 	viper.BindEnv("backfill.validationLevel", BACKFILL_VALIDATION_LEVEL)
+	viper.BindEnv("backfill.maxQueueMB", BACKFILL_MAX_QUEUE_MB)
+	viper.BindEnv("backfill.autoTune", BACKFILL_AUTO_TUNE)
+	viper.BindEnv("backfill.minWorkers", BACKFILL_MIN_WORKERS)
+	viper.BindEnv("backfill.maxWorkers", BACKFILL_MAX_WORKERS)
 	viper.BindEnv("backfill.timeout", shared.HTTP_TIMEOUT)
+	viper.BindEnv("state.latest", shared.STATE_LATEST)
+	viper.BindEnv("stats.rollupInterval", shared.STATS_ROLLUP_INTERVAL)
+	viper.BindEnv("events.abiDir", shared.EVENTS_ABI_DIR)
+	viper.BindEnv("tokens.transfers", shared.TOKEN_TRANSFERS)
+	viper.BindEnv("storage.layoutDir", shared.STORAGE_LAYOUT_DIR)
 
 	timeout := viper.GetInt("backfill.timeout")
 	if timeout < 15 {
@@ -84,7 +115,17 @@ func NewConfig() (*Config, error) {
 	c.Frequency = frequency
 	c.BatchSize = uint64(viper.GetInt64("backfill.batchSize"))
 	c.Workers = uint64(viper.GetInt64("backfill.workers"))
+	c.MaxQueue = shared.MBToBytes(uint64(viper.GetInt64("backfill.maxQueueMB")))
+	c.AutoTune = viper.GetBool("backfill.autoTune")
+	c.MinWorkers = uint64(viper.GetInt64("backfill.minWorkers"))
+	c.MaxWorkers = uint64(viper.GetInt64("backfill.maxWorkers"))
 	c.ValidationLevel = viper.GetInt("backfill.validationLevel")
+	c.LatestState = viper.GetBool("state.latest")
+	c.RollupInterval = time.Second * time.Duration(viper.GetInt("stats.rollupInterval"))
+	c.EventABIDir = viper.GetString("events.abiDir")
+	c.TokenTransfers = viper.GetBool("tokens.transfers")
+	c.StorageLayoutDir = viper.GetString("storage.layoutDir")
+	c.ChainConfigPath = viper.GetString("ethereum.chainConfig")
 
 	ethHTTP := viper.GetString("ethereum.httpPath")
 	c.NodeInfo, c.HTTPClient, err = shared.GetEthNodeAndClient(fmt.Sprintf("http://%s", ethHTTP))
@@ -49,6 +49,12 @@ type Service struct {
 	BatchSize uint64
 	// Number of goroutines
 	Workers int64
+	// Bounds the total size of the payloads held by the workers (nil for no bound)
+	Limiter *eth.ByteLimiter
+	// Adjusts the number of active workers (nil to keep all Workers active)
+	Tuner *shared.WorkerTuner
+	// Periodically refreshes the block stats rollups (nil to leave them to another process)
+	Roller *eth.BlockStatsRoller
 	// Channel for receiving quit signal
 	QuitChan chan bool
 	// Chain config
@@ -62,11 +68,10 @@ func NewBackfillService(settings *Config) (Backfill, error) {
 	bs := new(Service)
 	var err error
 	bs.Fetcher = eth.NewPayloadFetcher(settings.HTTPClient, settings.Timeout)
-	bs.ChainConfig, err = eth.ChainConfig(settings.NodeInfo.ChainID)
+	bs.ChainConfig, err = eth.LoadChainConfig(settings.NodeInfo.ChainID, settings.ChainConfigPath)
 	if err != nil {
 		return nil, err
 	}
-	bs.Transformer = eth.NewStateDiffTransformer(bs.ChainConfig, settings.DB)
 	bs.Retriever = eth.NewGapRetriever(settings.DB)
 	bs.BatchSize = settings.BatchSize
 	if bs.BatchSize == 0 {
@@ -76,6 +81,32 @@ func NewBackfillService(settings *Config) (Backfill, error) {
 	if bs.Workers == 0 {
 		bs.Workers = shared.DefaultMaxBatchNumber
 	}
+	if settings.AutoTune {
+		bs.Tuner = shared.NewWorkerTuner(shared.WorkerTunerConfig{
+			Name:  "ethereum backfill",
+			Min:   int64(settings.MinWorkers),
+			Max:   int64(settings.MaxWorkers),
+			Start: bs.Workers,
+			DB:    settings.DB,
+		})
+	}
+	events, err := eth.NewEventDecoder(settings.EventABIDir)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := eth.NewStorageDecoder(settings.StorageLayoutDir)
+	if err != nil {
+		return nil, err
+	}
+	bs.Transformer = eth.NewStateDiffTransformer(bs.ChainConfig, settings.DB, eth.TransformerConfig{
+		LatestState:    settings.LatestState,
+		Events:         events,
+		TokenTransfers: settings.TokenTransfers,
+		Storage:        storage,
+		Tuner:          bs.Tuner,
+	})
+	bs.Limiter = eth.NewByteLimiter(settings.MaxQueue)
+	bs.Roller = eth.NewBlockStatsRoller(settings.DB, settings.RollupInterval)
 	bs.QuitChan = make(chan bool)
 	bs.validationLevel = settings.ValidationLevel
 	bs.GapCheckFrequency = settings.Frequency
@@ -85,9 +116,14 @@ func NewBackfillService(settings *Config) (Backfill, error) {
 // Sync periodically checks for and fills in gaps in the watcher db
 func (bfs *Service) Sync(wg *sync.WaitGroup) {
 	ticker := time.NewTicker(bfs.GapCheckFrequency)
+	workers := int(bfs.Tuner.PoolSize(bfs.Workers))
+	bfs.Tuner.Start()
+	bfs.Roller.Start(wg)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		defer bfs.Tuner.Stop()
+		defer bfs.Roller.Stop()
 		for {
 			select {
 			case <-bfs.QuitChan:
@@ -103,7 +139,7 @@ func (bfs *Service) Sync(wg *sync.WaitGroup) {
 				// we start and kill a new batch of workers for each pass
 				// so that we know each of the previous workers is done before we search for new gaps
 				heightsChan := make(chan []uint64)
-				for i := 1; i <= int(bfs.Workers); i++ {
+				for i := 1; i <= workers; i++ {
 					go bfs.backFill(wg, i, heightsChan)
 				}
 				for _, gap := range gaps {
@@ -125,7 +161,7 @@ func (bfs *Service) Sync(wg *sync.WaitGroup) {
 				}
 				// send a quit signal to each worker
 				// this blocks until each worker has finished its current task and is free to receive from the quit channel
-				for i := 1; i <= int(bfs.Workers); i++ {
+				for i := 1; i <= workers; i++ {
 					bfs.QuitChan <- true
 				}
 			}
@@ -138,17 +174,33 @@ func (bfs *Service) backFill(wg *sync.WaitGroup, id int, heightChan chan []uint6
 	wg.Add(1)
 	defer wg.Done()
 	for {
+		// wait until the tuner has this worker among the active ones
+		if !bfs.Tuner.Wait(id, bfs.QuitChan) {
+			log.Infof("ethereum backfill worker %d shutting down", id)
+			return
+		}
 		select {
 		case heights := <-heightChan:
 			log.Debugf("ethereum backfill worker %d processing section from %d to %d", id, heights[0], heights[len(heights)-1])
+			// wait until the payloads held by the other workers fall below the limit before fetching more
+			bfs.Limiter.Wait()
 			payloads, err := bfs.Fetcher.FetchAt(heights)
+			bfs.Tuner.ObserveRPC(err)
 			if err != nil {
 				log.Errorf("ethereum backfill worker %d fetcher error: %s", id, err.Error())
 			}
-			for _, payload := range payloads {
+			sizes := make([]uint64, len(payloads))
+			for i, payload := range payloads {
+				sizes[i] = eth.PayloadSize(payload)
+				bfs.Limiter.Acquire(sizes[i])
+			}
+			for i, payload := range payloads {
 				blockNumber, err := bfs.Transformer.Transform(id, payload)
+				bfs.Limiter.Release(sizes[i])
 				if err != nil {
 					log.Errorf("ethereum backfill worker %d transformer error: %s", id, err.Error())
+				} else {
+					bfs.Tuner.ObserveProcessed()
 				}
 				log.Infof("ethereum backfill worker %d transformed data at height %d", id, blockNumber)
 			}
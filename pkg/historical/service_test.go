@@ -112,6 +112,53 @@ var _ = Describe("BackFiller", func() {
 			Expect(mockFetcher.CalledAtBlockHeights[0]).To(Equal([]uint64{100}))
 		})
 
+		It("Only hands work to the workers made active by the tuner", func() {
+			mockTransformer := &mocks.IterativeTransformer{
+				ReturnErr:     nil,
+				ReturnHeights: []uint64{100, 101},
+			}
+			mockRetriever := &mocks.Retriever{
+				FirstBlockNumberToReturn: 0,
+				GapsToRetrieve: []eth.DBGap{
+					{
+						Start: 100, Stop: 101,
+					},
+				},
+			}
+			mockFetcher := &mocks.PayloadFetcher{
+				PayloadsToReturn: map[uint64]statediff.Payload{
+					100: mocks.MockStateDiffPayload,
+					101: mocks.MockStateDiffPayload,
+				},
+			}
+			quitChan := make(chan bool, 1)
+			backfiller := &historical.Service{
+				Transformer:       mockTransformer,
+				Fetcher:           mockFetcher,
+				Retriever:         mockRetriever,
+				GapCheckFrequency: time.Second * 2,
+				BatchSize:         1,
+				Workers:           1,
+				Tuner: shared.NewWorkerTuner(shared.WorkerTunerConfig{
+					Name:  "test",
+					Min:   1,
+					Max:   4,
+					Start: 1,
+				}),
+				QuitChan: quitChan,
+			}
+			wg := &sync.WaitGroup{}
+			backfiller.Sync(wg)
+			time.Sleep(time.Second * 3)
+			quitChan <- true
+			Expect(backfiller.Tuner.Active()).To(Equal(int64(1)))
+			Expect(len(mockTransformer.PassedStateDiffs)).To(Equal(2))
+			Expect(mockRetriever.CalledTimes).To(Equal(1))
+			Expect(len(mockFetcher.CalledAtBlockHeights)).To(Equal(2))
+			Expect(mockFetcher.CalledAtBlockHeights[0]).To(Equal([]uint64{100}))
+			Expect(mockFetcher.CalledAtBlockHeights[1]).To(Equal([]uint64{101}))
+		})
+
 		It("Finds beginning gap", func() {
 			mockTransformer := &mocks.IterativeTransformer{
 				ReturnErr:     nil,
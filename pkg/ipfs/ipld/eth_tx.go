@@ -115,6 +115,10 @@ func (t *EthTx) Resolve(p []string) (interface{}, []string, error) {
 		return t, nil, nil
 	}
 
+	if p[0] == "accessList" {
+		return t.resolveAccessList(p[1:])
+	}
+
 	if len(p) > 1 {
 		return nil, nil, fmt.Errorf("unexpected path elements past %s", p[0])
 	}
@@ -142,6 +146,46 @@ func (t *EthTx) Resolve(p []string) (interface{}, []string, error) {
 		return hexutil.EncodeBig(v), nil, nil
 	case "value":
 		return hexutil.EncodeBig(t.Value()), nil, nil
+	case "type":
+		return t.Type(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("no such link")
+	}
+}
+
+// resolveAccessList resolves a path through the EIP-2930 access list of the transaction
+// e.g. accessList/0/address or accessList/0/storageKeys/1
+func (t *EthTx) resolveAccessList(p []string) (interface{}, []string, error) {
+	accessList := t.AccessList()
+	if len(p) == 0 {
+		return accessList, nil, nil
+	}
+	i, err := strconv.Atoi(p[0])
+	if err != nil || i < 0 || i >= len(accessList) {
+		return nil, nil, fmt.Errorf("no such access list element %s", p[0])
+	}
+	tuple := accessList[i]
+	if len(p) == 1 {
+		return tuple, nil, nil
+	}
+	switch p[1] {
+	case "address":
+		if len(p) > 2 {
+			return nil, nil, fmt.Errorf("unexpected path elements past %s", p[1])
+		}
+		return tuple.Address, nil, nil
+	case "storageKeys":
+		if len(p) == 2 {
+			return tuple.StorageKeys, nil, nil
+		}
+		if len(p) > 3 {
+			return nil, nil, fmt.Errorf("unexpected path elements past %s", p[2])
+		}
+		j, err := strconv.Atoi(p[2])
+		if err != nil || j < 0 || j >= len(tuple.StorageKeys) {
+			return nil, nil, fmt.Errorf("no such storage key %s", p[2])
+		}
+		return tuple.StorageKeys[j], nil, nil
 	default:
 		return nil, nil, fmt.Errorf("no such link")
 	}
@@ -153,7 +197,7 @@ func (t *EthTx) Tree(p string, depth int) []string {
 	if p != "" || depth == 0 {
 		return nil
 	}
-	return []string{"gas", "gasPrice", "input", "nonce", "r", "s", "toAddress", "v", "value"}
+	return []string{"accessList", "gas", "gasPrice", "input", "nonce", "r", "s", "toAddress", "type", "v", "value"}
 }
 
 // ResolveLink is a helper function that calls resolve and asserts the
@@ -200,15 +244,17 @@ func (t *EthTx) MarshalJSON() ([]byte, error) {
 	v, r, s := t.RawSignatureValues()
 
 	out := map[string]interface{}{
-		"gas":       t.Gas(),
-		"gasPrice":  hexutil.EncodeBig(t.GasPrice()),
-		"input":     fmt.Sprintf("%x", t.Data()),
-		"nonce":     t.Nonce(),
-		"r":         hexutil.EncodeBig(r),
-		"s":         hexutil.EncodeBig(s),
-		"toAddress": t.To(),
-		"v":         hexutil.EncodeBig(v),
-		"value":     hexutil.EncodeBig(t.Value()),
+		"accessList": t.AccessList(),
+		"gas":        t.Gas(),
+		"gasPrice":   hexutil.EncodeBig(t.GasPrice()),
+		"input":      fmt.Sprintf("%x", t.Data()),
+		"nonce":      t.Nonce(),
+		"r":          hexutil.EncodeBig(r),
+		"s":          hexutil.EncodeBig(s),
+		"toAddress":  t.To(),
+		"type":       t.Type(),
+		"v":          hexutil.EncodeBig(v),
+		"value":      hexutil.EncodeBig(t.Value()),
 	}
 	return json.Marshal(out)
 }
@@ -0,0 +1,132 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package ipld_test
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	mh "github.com/multiformats/go-multihash"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/eth/mocks"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/ipfs/ipld"
+)
+
+var _ = Describe("EthTx", func() {
+	var (
+		accessListTx = mocks.MockTypedTransactions[0]
+		dynamicFeeTx = mocks.MockTypedTransactions[1]
+	)
+
+	Describe("NewEthTx", func() {
+		It("Encodes a typed tx as its EIP-2718 envelope", func() {
+			for _, trx := range []*types.Transaction{accessListTx, dynamicFeeTx} {
+				node, err := ipld.NewEthTx(trx)
+				Expect(err).ToNot(HaveOccurred())
+				envelope, err := trx.MarshalBinary()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(node.RawData()).To(Equal(envelope))
+				Expect(node.RawData()[0]).To(Equal(trx.Type()))
+
+				c, err := ipld.RawdataToCid(ipld.MEthTx, envelope, mh.KECCAK_256)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(node.Cid()).To(Equal(c))
+			}
+		})
+
+		It("Encodes a legacy tx as plain RLP", func() {
+			node, err := ipld.NewEthTx(mocks.MockTransactions[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(node.RawData()).To(Equal(mocks.Tx1))
+			Expect(node.Resolve([]string{"type"})).To(Equal(uint8(types.LegacyTxType)))
+		})
+	})
+
+	Describe("DecodeEthTx", func() {
+		It("Decodes a typed tx envelope back into the same tx", func() {
+			for _, trx := range []*types.Transaction{accessListTx, dynamicFeeTx} {
+				node, err := ipld.NewEthTx(trx)
+				Expect(err).ToNot(HaveOccurred())
+				decoded, err := ipld.DecodeEthTx(node.Cid(), node.RawData())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decoded.Hash()).To(Equal(trx.Hash()))
+				Expect(decoded.Type()).To(Equal(trx.Type()))
+				Expect(decoded.AccessList()).To(Equal(mocks.MockAccessList))
+				Expect(decoded.Cid()).To(Equal(node.Cid()))
+			}
+		})
+	})
+
+	Describe("Resolve", func() {
+		var node *ipld.EthTx
+		BeforeEach(func() {
+			var err error
+			node, err = ipld.NewEthTx(dynamicFeeTx)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		table.DescribeTable("resolves the type and access list of the tx",
+			func(path []string, expected interface{}) {
+				resolved, rest, err := node.Resolve(path)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rest).To(BeEmpty())
+				Expect(resolved).To(Equal(expected))
+			},
+			table.Entry("type", []string{"type"}, uint8(types.DynamicFeeTxType)),
+			table.Entry("the whole access list", []string{"accessList"}, mocks.MockAccessList),
+			table.Entry("an access list element", []string{"accessList", "0"}, mocks.MockAccessList[0]),
+			table.Entry("the address of an element", []string{"accessList", "1", "address"}, mocks.ContractAddress),
+			table.Entry("the storage keys of an element", []string{"accessList", "0", "storageKeys"},
+				[]common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}),
+			table.Entry("a storage key of an element", []string{"accessList", "0", "storageKeys", "1"}, common.HexToHash("0x02")),
+		)
+
+		table.DescribeTable("rejects paths outside of the access list",
+			func(path []string) {
+				_, _, err := node.Resolve(path)
+				Expect(err).To(HaveOccurred())
+			},
+			table.Entry("an element past the end", []string{"accessList", "2"}),
+			table.Entry("an element that is not an index", []string{"accessList", "first"}),
+			table.Entry("a storage key past the end", []string{"accessList", "1", "storageKeys", "0"}),
+			table.Entry("a path past the address", []string{"accessList", "0", "address", "0"}),
+			table.Entry("an unknown element field", []string{"accessList", "0", "value"}),
+			table.Entry("a path past the type", []string{"type", "0"}),
+		)
+
+		It("lists the access list among its paths", func() {
+			Expect(node.Tree("", -1)).To(ContainElements("accessList", "type"))
+		})
+	})
+
+	Describe("FromBlockAndReceipts", func() {
+		It("Builds the tx and receipt nodes of a block of typed txs that match its roots", func() {
+			_, _, txNodes, txTrieNodes, rctNodes, rctTrieNodes, err := ipld.FromBlockAndReceipts(mocks.MockTypedBlock, mocks.MockTypedReceipts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(txNodes).To(HaveLen(2))
+			Expect(rctNodes).To(HaveLen(2))
+			Expect(txTrieNodes).ToNot(BeEmpty())
+			Expect(rctTrieNodes).ToNot(BeEmpty())
+			for i, rctNode := range rctNodes {
+				Expect(txNodes[i].Hash()).To(Equal(mocks.MockTypedTransactions[i].Hash()))
+				Expect(rctNode.RawData()[0]).To(Equal(mocks.MockTypedTransactions[i].Type()))
+			}
+		})
+	})
+})
@@ -33,7 +33,13 @@ var (
 	transactions prometheus.Counter
 	blocks       prometheus.Counter
 
-	lenPayloadChan prometheus.Gauge
+	keyCacheHits   prometheus.Counter
+	keyCacheMisses prometheus.Counter
+
+	lenPayloadChan    prometheus.Gauge
+	payloadQueueBytes prometheus.Gauge
+	heldPayloadBytes  prometheus.Gauge
+	activeWorkers     prometheus.Gauge
 
 	tPayloadDecode             prometheus.Histogram
 	tFreePostgres              prometheus.Histogram
@@ -43,6 +49,7 @@ var (
 	tTxAndRecProcessing        prometheus.Histogram
 	tStateAndStoreProcessing   prometheus.Histogram
 	tCodeAndCodeHashProcessing prometheus.Histogram
+	tCanonicalStateProcessing  prometheus.Histogram
 )
 
 // Init module initialization
@@ -65,11 +72,37 @@ func Init() {
 		Help:      "The total number of processed receipts",
 	})
 
+	keyCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "key_cache_hits",
+		Help:      "The total number of IPLD writes skipped because the key was already known to be published",
+	})
+	keyCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "key_cache_misses",
+		Help:      "The total number of IPLD keys not found in the known key cache",
+	})
+
 	lenPayloadChan = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: namespace,
 		Name:      "len_payload_chan",
 		Help:      "Current length of publishPayload",
 	})
+	payloadQueueBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "payload_queue_bytes",
+		Help:      "Current total size in bytes of the statediff payloads queued for the sync workers",
+	})
+	heldPayloadBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "held_payload_bytes",
+		Help:      "Current total size in bytes of the statediff payloads fetched and held by the backfill or resync workers",
+	})
+	activeWorkers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_workers",
+		Help:      "Current number of active workers chosen by the worker tuner",
+	})
 
 	tPayloadDecode = promauto.NewHistogram(prometheus.HistogramOpts{
 		Namespace: namespace,
@@ -119,6 +152,12 @@ func Init() {
 		Name:      "t_code_codehash_processing",
 		Help:      "Code and codehash processing time",
 	})
+	tCanonicalStateProcessing = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: statsSubsystem,
+		Name:      "t_canonical_state_processing",
+		Help:      "Canonical flag and latest state processing time",
+	})
 }
 
 // RegisterDBCollector create metric colletor for given connection
@@ -149,6 +188,20 @@ func ReceiptInc() {
 	}
 }
 
+// KeyCacheHit known key cache hit counter increment
+func KeyCacheHit() {
+	if metrics {
+		keyCacheHits.Inc()
+	}
+}
+
+// KeyCacheMiss known key cache miss counter increment
+func KeyCacheMiss() {
+	if metrics {
+		keyCacheMisses.Inc()
+	}
+}
+
 // SetLenPayloadChan set chan length
 func SetLenPayloadChan(ln int) {
 	if metrics {
@@ -156,6 +209,27 @@ func SetLenPayloadChan(ln int) {
 	}
 }
 
+// SetPayloadQueueBytes set payload queue size in bytes
+func SetPayloadQueueBytes(bytes uint64) {
+	if metrics {
+		payloadQueueBytes.Set(float64(bytes))
+	}
+}
+
+// SetHeldPayloadBytes set size in bytes of the payloads held by the backfill or resync workers
+func SetHeldPayloadBytes(bytes uint64) {
+	if metrics {
+		heldPayloadBytes.Set(float64(bytes))
+	}
+}
+
+// SetActiveWorkers set number of active workers
+func SetActiveWorkers(n int) {
+	if metrics {
+		activeWorkers.Set(float64(n))
+	}
+}
+
 // SetTimeMetric time metric observation
 func SetTimeMetric(name string, t time.Duration) {
 	if !metrics {
@@ -179,5 +253,7 @@ func SetTimeMetric(name string, t time.Duration) {
 		tStateAndStoreProcessing.Observe(tAsF64)
 	case "t_code_codehash_processing":
 		tCodeAndCodeHashProcessing.Observe(tAsF64)
+	case "t_canonical_state_processing":
+		tCanonicalStateProcessing.Observe(tAsF64)
 	}
 }
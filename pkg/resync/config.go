@@ -39,6 +39,11 @@ const (
 	RESYNC_CLEAR_OLD_CACHE  = "RESYNC_CLEAR_OLD_CACHE"
 	RESYNC_TYPE             = "RESYNC_TYPE"
 	RESYNC_RESET_VALIDATION = "RESYNC_RESET_VALIDATION"
+	RESYNC_FORCE_REINDEX    = "RESYNC_FORCE_REINDEX"
+	RESYNC_MAX_QUEUE_MB     = "RESYNC_MAX_QUEUE_MB"
+	RESYNC_AUTO_TUNE        = "RESYNC_AUTO_TUNE"
+	RESYNC_MIN_WORKERS      = "RESYNC_MIN_WORKERS"
+	RESYNC_MAX_WORKERS      = "RESYNC_MAX_WORKERS"
 
 	RESYNC_MAX_IDLE_CONNECTIONS = "RESYNC_MAX_IDLE_CONNECTIONS"
 	RESYNC_MAX_OPEN_CONNECTIONS = "RESYNC_MAX_OPEN_CONNECTIONS"
@@ -50,6 +55,7 @@ type Config struct {
 	ResyncType      shared.DataType // The type of data to resync
 	ClearOldCache   bool            // Resync will first clear all the data within the range
 	ResetValidation bool            // If true, resync will reset the validation level to 0 for the given range
+	ForceReindex    bool            // If true, resync will rewrite all rows for blocks that are already completely indexed
 
 	// DB info
 	DB       *postgres.DB
@@ -61,6 +67,21 @@ type Config struct {
 	BatchSize  uint64        // BatchSize for the resync http calls (client has to support batch sizing)
 	Timeout    time.Duration // HTTP connection timeout in seconds
 	Workers    uint64
+	MaxQueue   uint64 // Max total size of fetched payloads held by the workers, in bytes
+	// Genesis or chain config file for chains without a built in config (optional)
+	ChainConfigPath string
+	// Worker tuning settings
+	AutoTune   bool
+	MinWorkers uint64
+	MaxWorkers uint64
+	// Keep the latest state tables up to date
+	LatestState bool
+	// Directory of contract ABIs to decode logs with (optional)
+	EventABIDir string
+	// Extract the standard token transfer events
+	TokenTransfers bool
+	// Directory of contract storage layouts to decode storage with (optional)
+	StorageLayoutDir string
 }
 
 // NewConfig fills and returns a resync config from toml parameters
@@ -69,6 +90,7 @@ func NewConfig() (*Config, error) {
 	var err error
 
 	viper.BindEnv("ethereum.httpPath", shared.ETH_HTTP_PATH)
+	viper.BindEnv("ethereum.chainConfig", shared.ETH_CHAIN_CONFIG)
 	viper.BindEnv("resync.start", RESYNC_START)
 	viper.BindEnv("resync.stop", RESYNC_STOP)
 	viper.BindEnv("resync.clearOldCache", RESYNC_CLEAR_OLD_CACHE)
@@ -76,7 +98,16 @@ func NewConfig() (*Config, error) {
 	viper.BindEnv("resync.batchSize", RESYNC_BATCH_SIZE)
 	viper.BindEnv("resync.workers", RESYNC_WORKERS)
 	viper.BindEnv("resync.resetValidation", RESYNC_RESET_VALIDATION)
+	viper.BindEnv("resync.forceReindex", RESYNC_FORCE_REINDEX)
+	viper.BindEnv("resync.maxQueueMB", RESYNC_MAX_QUEUE_MB)
+	viper.BindEnv("resync.autoTune", RESYNC_AUTO_TUNE)
+	viper.BindEnv("resync.minWorkers", RESYNC_MIN_WORKERS)
+	viper.BindEnv("resync.maxWorkers", RESYNC_MAX_WORKERS)
 	viper.BindEnv("resync.timeout", shared.HTTP_TIMEOUT)
+	viper.BindEnv("state.latest", shared.STATE_LATEST)
+	viper.BindEnv("events.abiDir", shared.EVENTS_ABI_DIR)
+	viper.BindEnv("tokens.transfers", shared.TOKEN_TRANSFERS)
+	viper.BindEnv("storage.layoutDir", shared.STORAGE_LAYOUT_DIR)
 
 	timeout := viper.GetInt("resync.timeout")
 	if timeout < 5 {
@@ -89,8 +120,18 @@ func NewConfig() (*Config, error) {
 	c.Ranges = [][2]uint64{{start, stop}}
 	c.ClearOldCache = viper.GetBool("resync.clearOldCache")
 	c.ResetValidation = viper.GetBool("resync.resetValidation")
+	c.ForceReindex = viper.GetBool("resync.forceReindex")
 	c.BatchSize = uint64(viper.GetInt64("resync.batchSize"))
 	c.Workers = uint64(viper.GetInt64("resync.workers"))
+	c.MaxQueue = shared.MBToBytes(uint64(viper.GetInt64("resync.maxQueueMB")))
+	c.AutoTune = viper.GetBool("resync.autoTune")
+	c.MinWorkers = uint64(viper.GetInt64("resync.minWorkers"))
+	c.MaxWorkers = uint64(viper.GetInt64("resync.maxWorkers"))
+	c.LatestState = viper.GetBool("state.latest")
+	c.EventABIDir = viper.GetString("events.abiDir")
+	c.TokenTransfers = viper.GetBool("tokens.transfers")
+	c.StorageLayoutDir = viper.GetString("storage.layoutDir")
+	c.ChainConfigPath = viper.GetString("ethereum.chainConfig")
 
 	resyncType := viper.GetString("resync.type")
 	c.ResyncType, err = shared.GenerateDataTypeFromString(resyncType)
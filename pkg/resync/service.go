@@ -42,6 +42,12 @@ type Service struct {
 	BatchSize uint64
 	// Number of goroutines
 	Workers int64
+	// Bounds the total size of the payloads held by the workers (nil for no bound)
+	Limiter *eth.ByteLimiter
+	// Adjusts the number of active workers (nil to keep all Workers active)
+	Tuner *shared.WorkerTuner
+	// Refreshes the block stats rollups once the ranges are resynced (nil to leave them to another process)
+	Roller *eth.BlockStatsRoller
 	// Channel for receiving quit signal
 	quitChan chan bool
 	// Chain config
@@ -61,11 +67,10 @@ func NewResyncService(settings *Config) (Resync, error) {
 	rs := new(Service)
 	var err error
 	rs.Fetcher = eth.NewPayloadFetcher(settings.HTTPClient, settings.Timeout)
-	rs.ChainConfig, err = eth.ChainConfig(settings.NodeInfo.ChainID)
+	rs.ChainConfig, err = eth.LoadChainConfig(settings.NodeInfo.ChainID, settings.ChainConfigPath)
 	if err != nil {
 		return nil, err
 	}
-	rs.Transformer = eth.NewStateDiffTransformer(rs.ChainConfig, settings.DB)
 	rs.Cleaner = eth.NewDBCleaner(settings.DB)
 	rs.BatchSize = settings.BatchSize
 	if rs.BatchSize == 0 {
@@ -75,6 +80,39 @@ func NewResyncService(settings *Config) (Resync, error) {
 	if rs.Workers == 0 {
 		rs.Workers = shared.DefaultMaxBatchNumber
 	}
+	if settings.AutoTune {
+		rs.Tuner = shared.NewWorkerTuner(shared.WorkerTunerConfig{
+			Name:  "ethereum resync",
+			Min:   int64(settings.MinWorkers),
+			Max:   int64(settings.MaxWorkers),
+			Start: rs.Workers,
+			DB:    settings.DB,
+		})
+	}
+	if settings.ResyncType == shared.Rewards {
+		// rewards only need the block and its receipts, so the node is not asked to build the statediffs
+		rs.Fetcher = eth.NewBlockFetcher(settings.HTTPClient, settings.Timeout)
+		rs.Transformer = eth.NewRewardTransformer(rs.ChainConfig, settings.DB)
+	} else {
+		events, err := eth.NewEventDecoder(settings.EventABIDir)
+		if err != nil {
+			return nil, err
+		}
+		storage, err := eth.NewStorageDecoder(settings.StorageLayoutDir)
+		if err != nil {
+			return nil, err
+		}
+		rs.Transformer = eth.NewStateDiffTransformer(rs.ChainConfig, settings.DB, eth.TransformerConfig{
+			ForceReindex:   settings.ForceReindex,
+			LatestState:    settings.LatestState,
+			Events:         events,
+			TokenTransfers: settings.TokenTransfers,
+			Storage:        storage,
+			Tuner:          rs.Tuner,
+		})
+	}
+	rs.Limiter = eth.NewByteLimiter(settings.MaxQueue)
+	rs.Roller = eth.NewBlockStatsRoller(settings.DB, 0)
 	rs.resetValidation = settings.ResetValidation
 	rs.clearOldCache = settings.ClearOldCache
 	rs.quitChan = make(chan bool)
@@ -91,7 +129,9 @@ func (rs *Service) Sync() error {
 			return fmt.Errorf("validation reset failed: %v", err)
 		}
 	}
-	if rs.clearOldCache {
+	if rs.clearOldCache && rs.data == shared.Rewards {
+		logrus.Infof("rewards are recomputed in place, skipping cleaning out old data")
+	} else if rs.clearOldCache {
 		logrus.Infof("cleaning out old data from Postgres")
 		if err := rs.Cleaner.Clean(rs.ranges, rs.data); err != nil {
 			return fmt.Errorf("ethereum %s data resync cleaning error: %v", rs.data.String(), err)
@@ -99,7 +139,10 @@ func (rs *Service) Sync() error {
 	}
 	// spin up worker goroutines
 	heightsChan := make(chan []uint64)
-	for i := 1; i <= int(rs.Workers); i++ {
+	workers := int(rs.Tuner.PoolSize(rs.Workers))
+	rs.Tuner.Start()
+	defer rs.Tuner.Stop()
+	for i := 1; i <= workers; i++ {
 		go rs.resync(i, heightsChan)
 	}
 	for _, rng := range rs.ranges {
@@ -119,25 +162,44 @@ func (rs *Service) Sync() error {
 	}
 	// send a quit signal to each worker
 	// this blocks until each worker has finished its current task and can receive from the quit channel
-	for i := 1; i <= int(rs.Workers); i++ {
+	for i := 1; i <= workers; i++ {
 		rs.quitChan <- true
 	}
+	if _, err := rs.Roller.Roll(); err != nil {
+		return fmt.Errorf("ethereum %s data resync block stats rollup error: %v", rs.data.String(), err)
+	}
 	return nil
 }
 
 func (rs *Service) resync(id int, heightChan chan []uint64) {
 	for {
+		// wait until the tuner has this worker among the active ones
+		if !rs.Tuner.Wait(id, rs.quitChan) {
+			logrus.Infof("ethereum resync worker %d goroutine shutting down", id)
+			return
+		}
 		select {
 		case heights := <-heightChan:
 			logrus.Debugf("ethereum resync worker %d processing section from %d to %d", id, heights[0], heights[len(heights)-1])
+			// wait until the payloads held by the other workers fall below the limit before fetching more
+			rs.Limiter.Wait()
 			payloads, err := rs.Fetcher.FetchAt(heights)
+			rs.Tuner.ObserveRPC(err)
 			if err != nil {
 				logrus.Errorf("ethereum resync worker %d fetcher error: %s", id, err.Error())
 			}
-			for _, payload := range payloads {
+			sizes := make([]uint64, len(payloads))
+			for i, payload := range payloads {
+				sizes[i] = eth.PayloadSize(payload)
+				rs.Limiter.Acquire(sizes[i])
+			}
+			for i, payload := range payloads {
 				blockNumber, err := rs.Transformer.Transform(id, payload)
+				rs.Limiter.Release(sizes[i])
 				if err != nil {
 					logrus.Errorf("ethereum resync worker %d transformer error: %s", id, err.Error())
+				} else {
+					rs.Tuner.ObserveProcessed()
 				}
 				logrus.Infof("ethereum resync worker %d transformed data at height %d", id, blockNumber)
 			}
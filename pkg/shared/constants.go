@@ -19,4 +19,13 @@ package shared
 const (
 	DefaultMaxBatchSize   uint64 = 100
 	DefaultMaxBatchNumber int64  = 50
+	DefaultMaxQueueMB     uint64 = 1024
 )
+
+// MBToBytes converts a size in megabytes into bytes, using the default queue size if mb is 0
+func MBToBytes(mb uint64) uint64 {
+	if mb == 0 {
+		mb = DefaultMaxQueueMB
+	}
+	return mb * 1024 * 1024
+}
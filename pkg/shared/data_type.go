@@ -33,6 +33,7 @@ const (
 	Receipts
 	State
 	Storage
+	Rewards
 )
 
 // String() method to resolve ReSyncType enum
@@ -52,6 +53,8 @@ func (r DataType) String() string {
 		return "state"
 	case Storage:
 		return "storage"
+	case Rewards:
+		return "rewards"
 	default:
 		return "unknown"
 	}
@@ -74,6 +77,8 @@ func GenerateDataTypeFromString(str string) (DataType, error) {
 		return State, nil
 	case "storage":
 		return Storage, nil
+	case "rewards", "reward":
+		return Rewards, nil
 	default:
 		return UnknownDataType, fmt.Errorf("unrecognized resync type: %s", str)
 	}
@@ -95,6 +100,8 @@ func SupportedDataType(d DataType) (bool, error) {
 		return true, nil
 	case Storage:
 		return true, nil
+	case Rewards:
+		return true, nil
 	default:
 		return true, nil
 	}
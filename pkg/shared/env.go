@@ -34,6 +34,17 @@ const (
 	ETH_GENESIS_BLOCK = "ETH_GENESIS_BLOCK"
 	ETH_NETWORK_ID    = "ETH_NETWORK_ID"
 	ETH_CHAIN_ID      = "ETH_CHAIN_ID"
+	ETH_CHAIN_CONFIG  = "ETH_CHAIN_CONFIG"
+
+	STATE_LATEST = "STATE_LATEST"
+
+	STATS_ROLLUP_INTERVAL = "STATS_ROLLUP_INTERVAL"
+
+	EVENTS_ABI_DIR = "EVENTS_ABI_DIR"
+
+	TOKEN_TRANSFERS = "TOKEN_TRANSFERS"
+
+	STORAGE_LAYOUT_DIR = "STORAGE_LAYOUT_DIR"
 )
 
 // GetEthNodeAndClient returns eth node info and client from path url
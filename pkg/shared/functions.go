@@ -46,18 +46,33 @@ func HandleZeroAddr(to common.Address) string {
 
 // Rollback sql transaction and log any error
 func Rollback(tx *sqlx.Tx) {
+	if keyCache != nil {
+		keyCache.Discard(tx)
+	}
 	if err := tx.Rollback(); err != nil {
 		logrus.Error(err)
 	}
 }
 
+// Commit sql transaction and add any IPLD keys it published to the key cache
+func Commit(tx *sqlx.Tx) error {
+	if err := tx.Commit(); err != nil {
+		if keyCache != nil {
+			keyCache.Discard(tx)
+		}
+		return err
+	}
+	if keyCache != nil {
+		keyCache.Commit(tx)
+	}
+	return nil
+}
+
 // PublishIPLD is used to insert an ipld into Postgres blockstore with the provided tx
 func PublishIPLD(tx *sqlx.Tx, i node.Node) error {
 	dbKey := dshelp.MultihashToDsKey(i.Cid().Hash())
 	prefixedKey := blockstore.BlockPrefix.String() + dbKey.String()
-	raw := i.RawData()
-	_, err := tx.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`, prefixedKey, raw)
-	return err
+	return PublishDirect(tx, prefixedKey, i.RawData())
 }
 
 // FetchIPLD is used to retrieve an ipld from Postgres blockstore with the provided tx and cid string
@@ -102,8 +117,7 @@ func PublishRaw(tx *sqlx.Tx, codec, mh uint64, raw []byte) (string, error) {
 	}
 	dbKey := dshelp.MultihashToDsKey(c.Hash())
 	prefixedKey := blockstore.BlockPrefix.String() + dbKey.String()
-	_, err = tx.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`, prefixedKey, raw)
-	return c.String(), err
+	return c.String(), PublishDirect(tx, prefixedKey, raw)
 }
 
 // MultihashKeyFromKeccak256 converts keccak256 hash bytes into a blockstore-prefixed multihash db key string
@@ -117,7 +131,14 @@ func MultihashKeyFromKeccak256(hash common.Hash) (string, error) {
 }
 
 // PublishDirect diretly writes a previously derived mhkey => value pair to the ipld database
+// If the key cache is enabled, keys already known to be in the database are not written again
 func PublishDirect(tx *sqlx.Tx, key string, value []byte) error {
+	if keyCache != nil && keyCache.Known(key) {
+		return nil
+	}
 	_, err := tx.Exec(`INSERT INTO public.blocks (key, data) VALUES ($1, $2) ON CONFLICT (key) DO NOTHING`, key, value)
+	if err == nil && keyCache != nil {
+		keyCache.Pend(tx, key)
+	}
 	return err
 }
@@ -0,0 +1,141 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/prom"
+)
+
+// keyCache is the process-wide cache of multihash keys known to be present in public.blocks
+// it is nil (disabled) until InitKeyCache is called
+var keyCache *KeyCache
+
+// KeyCache is a bounded LRU of multihash keys that have been committed to public.blocks
+// Keys published within a db tx are held as pending until that tx commits, so that a rolled back tx
+// can never leave a key in the cache that is not actually present in the database
+// Each purge starts a new generation, and the keys a tx pended in an earlier generation are dropped when it commits,
+// since the rows may have been deleted from under the tx before the purge
+type KeyCache struct {
+	known *lru.Cache
+
+	pendingLock sync.Mutex
+	generation  uint64
+	pending     map[*sqlx.Tx]*pendingKeys
+}
+
+// pendingKeys are the keys written within a tx, and the cache generation the first of them was written in
+type pendingKeys struct {
+	generation uint64
+	keys       []string
+}
+
+// InitKeyCache initializes the process-wide key cache with room for the provided number of keys
+// A size <= 0 leaves the cache disabled
+func InitKeyCache(size int) error {
+	if size <= 0 {
+		keyCache = nil
+		return nil
+	}
+	c, err := NewKeyCache(size)
+	if err != nil {
+		return err
+	}
+	keyCache = c
+	return nil
+}
+
+// NewKeyCache returns a new KeyCache that holds up to size keys
+func NewKeyCache(size int) (*KeyCache, error) {
+	known, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyCache{
+		known:   known,
+		pending: make(map[*sqlx.Tx]*pendingKeys),
+	}, nil
+}
+
+// PurgeKeyCache clears the process-wide key cache
+// This needs to be called whenever rows are deleted from public.blocks
+func PurgeKeyCache() {
+	if keyCache != nil {
+		keyCache.Purge()
+	}
+}
+
+// Known returns true if the key has been committed to public.blocks, and records a cache hit or miss
+// A hit marks the key as recently used, so that frequently repeated keys are the last to be evicted
+func (kc *KeyCache) Known(key string) bool {
+	if _, ok := kc.known.Get(key); ok {
+		prom.KeyCacheHit()
+		return true
+	}
+	prom.KeyCacheMiss()
+	return false
+}
+
+// Pend marks a key as written within the provided tx
+func (kc *KeyCache) Pend(tx *sqlx.Tx, key string) {
+	kc.pendingLock.Lock()
+	defer kc.pendingLock.Unlock()
+	pending, ok := kc.pending[tx]
+	if !ok {
+		pending = &pendingKeys{generation: kc.generation}
+		kc.pending[tx] = pending
+	}
+	pending.keys = append(pending.keys, key)
+}
+
+// Commit moves the keys pending on the provided tx into the cache, unless the cache was purged since they were written
+func (kc *KeyCache) Commit(tx *sqlx.Tx) {
+	kc.pendingLock.Lock()
+	defer kc.pendingLock.Unlock()
+	pending, ok := kc.pending[tx]
+	delete(kc.pending, tx)
+	if !ok || pending.generation != kc.generation {
+		return
+	}
+	for _, key := range pending.keys {
+		kc.known.Add(key, nil)
+	}
+}
+
+// Discard drops the keys pending on the provided tx
+func (kc *KeyCache) Discard(tx *sqlx.Tx) {
+	kc.pendingLock.Lock()
+	delete(kc.pending, tx)
+	kc.pendingLock.Unlock()
+}
+
+// Purge removes all keys from the cache, and starts a new generation so that the keys pending on open txs are dropped
+func (kc *KeyCache) Purge() {
+	kc.pendingLock.Lock()
+	defer kc.pendingLock.Unlock()
+	kc.generation++
+	kc.known.Purge()
+}
+
+// Len returns the number of keys in the cache
+func (kc *KeyCache) Len() int {
+	return kc.known.Len()
+}
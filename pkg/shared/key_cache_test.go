@@ -0,0 +1,98 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared_test
+
+import (
+	"github.com/jmoiron/sqlx"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+var _ = Describe("KeyCache", func() {
+	var (
+		cache *shared.KeyCache
+		tx1   *sqlx.Tx
+		tx2   *sqlx.Tx
+	)
+	BeforeEach(func() {
+		var err error
+		cache, err = shared.NewKeyCache(2)
+		Expect(err).ToNot(HaveOccurred())
+		// the cache only uses the tx as a key for its pending keys
+		tx1, tx2 = new(sqlx.Tx), new(sqlx.Tx)
+	})
+
+	It("Only knows the keys of committed txs", func() {
+		cache.Pend(tx1, "key1")
+		cache.Pend(tx2, "key2")
+		Expect(cache.Known("key1")).To(BeFalse())
+		cache.Commit(tx1)
+		Expect(cache.Known("key1")).To(BeTrue())
+		Expect(cache.Known("key2")).To(BeFalse())
+		Expect(cache.Len()).To(Equal(1))
+	})
+
+	It("Drops the keys of rolled back txs", func() {
+		cache.Pend(tx1, "key1")
+		cache.Discard(tx1)
+		cache.Commit(tx1)
+		Expect(cache.Known("key1")).To(BeFalse())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("Evicts the least recently used keys once full", func() {
+		cache.Pend(tx1, "key1")
+		cache.Pend(tx1, "key2")
+		cache.Commit(tx1)
+		Expect(cache.Known("key1")).To(BeTrue())
+		cache.Pend(tx2, "key3")
+		cache.Commit(tx2)
+		Expect(cache.Len()).To(Equal(2))
+		Expect(cache.Known("key1")).To(BeTrue())
+		Expect(cache.Known("key2")).To(BeFalse())
+		Expect(cache.Known("key3")).To(BeTrue())
+	})
+
+	It("Forgets every key when purged", func() {
+		cache.Pend(tx1, "key1")
+		cache.Commit(tx1)
+		cache.Purge()
+		Expect(cache.Known("key1")).To(BeFalse())
+		Expect(cache.Len()).To(Equal(0))
+	})
+
+	It("Drops the keys of txs that were open when it was purged", func() {
+		// the rows of keys that were already present may be deleted before the tx commits
+		cache.Pend(tx1, "key1")
+		cache.Purge()
+		cache.Pend(tx1, "key2")
+		cache.Pend(tx2, "key3")
+		cache.Commit(tx1)
+		cache.Commit(tx2)
+		Expect(cache.Known("key1")).To(BeFalse())
+		Expect(cache.Known("key2")).To(BeFalse())
+		Expect(cache.Known("key3")).To(BeTrue())
+	})
+
+	It("Is disabled with a size of 0", func() {
+		Expect(shared.InitKeyCache(0)).To(Succeed())
+		_, err := shared.NewKeyCache(0)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,298 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/prom"
+)
+
+const (
+	// DefaultTuneInterval is how often the worker tuner measures the pool and adjusts it
+	DefaultTuneInterval = 30 * time.Second
+
+	// back off when more than this fraction of rpc calls fail
+	maxRPCErrorRate = 0.05
+	// back off when the average time a block waits for a free postgres tx, or for a connection from the db pool, is longer than this
+	maxFreePostgresWait = 100 * time.Millisecond
+	// an added worker has to improve throughput by more than this fraction to be kept
+	minThroughputGain = 0.05
+	// number of intervals to hold after backing off before probing with more workers again
+	settleIntervals = 10
+)
+
+// WorkerTunerConfig holds the settings for a WorkerTuner
+type WorkerTunerConfig struct {
+	// Name of the worker pool, used in logs
+	Name string
+	// Bounds on the number of active workers
+	Min int64
+	Max int64
+	// Number of workers active at the start
+	Start int64
+	// How often to measure the pool and adjust it
+	Interval time.Duration
+	// Connection pool used by the workers
+	DB prom.DBStatsGetter
+}
+
+// WorkerTuner adjusts the number of active workers in a pool based on their measured throughput,
+// the time they spend waiting for a free postgres tx, saturation of the db connection pool, and the rpc error rate
+// The pool spins up Max workers and each one calls Wait before taking on work; only the first Active workers proceed
+// A nil WorkerTuner leaves every worker active
+type WorkerTuner struct {
+	name     string
+	min, max int64
+	interval time.Duration
+	db       prom.DBStatsGetter
+
+	lock    sync.Mutex
+	active  int64
+	changed chan struct{}
+
+	// counters for the current interval
+	processed        uint64
+	rpcCalls         uint64
+	rpcErrors        uint64
+	freePostgresWait int64
+
+	// state carried between intervals
+	lastTune       time.Time
+	lastThroughput float64
+	lastDirection  int64
+	settle         int
+	lastDBWait     time.Duration
+
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWorkerTuner returns a new WorkerTuner
+// Min defaults to 1, Max defaults to DefaultMaxBatchNumber, and Start is kept within those bounds
+func NewWorkerTuner(conf WorkerTunerConfig) *WorkerTuner {
+	if conf.Min < 1 {
+		conf.Min = 1
+	}
+	if conf.Max == 0 {
+		conf.Max = DefaultMaxBatchNumber
+	}
+	if conf.Max < conf.Min {
+		conf.Max = conf.Min
+	}
+	if conf.Start < conf.Min {
+		conf.Start = conf.Min
+	}
+	if conf.Start > conf.Max {
+		conf.Start = conf.Max
+	}
+	if conf.Interval == 0 {
+		conf.Interval = DefaultTuneInterval
+	}
+	wt := &WorkerTuner{
+		name:     conf.Name,
+		min:      conf.Min,
+		max:      conf.Max,
+		interval: conf.Interval,
+		db:       conf.DB,
+		active:   conf.Start,
+		changed:  make(chan struct{}),
+		lastTune: time.Now(),
+		quit:     make(chan struct{}),
+	}
+	if wt.db != nil {
+		wt.lastDBWait = wt.db.Stats().WaitDuration
+	}
+	prom.SetActiveWorkers(int(wt.active))
+	return wt
+}
+
+// PoolSize returns the number of workers a pool should spin up
+func (wt *WorkerTuner) PoolSize(workers int64) int64 {
+	if wt == nil {
+		return workers
+	}
+	return wt.max
+}
+
+// Active returns the number of workers that are currently allowed to take on work
+func (wt *WorkerTuner) Active() int64 {
+	if wt == nil {
+		return 0
+	}
+	wt.lock.Lock()
+	defer wt.lock.Unlock()
+	return wt.active
+}
+
+// Wait blocks the worker with the given id (starting at 1) until it is among the active workers
+// It returns false if a quit signal is received while waiting
+func (wt *WorkerTuner) Wait(id int, quit <-chan bool) bool {
+	if wt == nil {
+		return true
+	}
+	for {
+		wt.lock.Lock()
+		active, changed := wt.active, wt.changed
+		wt.lock.Unlock()
+		if int64(id) <= active {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-quit:
+			return false
+		}
+	}
+}
+
+// ObserveProcessed records a block successfully processed by a worker
+func (wt *WorkerTuner) ObserveProcessed() {
+	if wt != nil {
+		atomic.AddUint64(&wt.processed, 1)
+	}
+}
+
+// ObserveRPC records the outcome of an rpc call made by a worker
+func (wt *WorkerTuner) ObserveRPC(err error) {
+	if wt == nil {
+		return
+	}
+	atomic.AddUint64(&wt.rpcCalls, 1)
+	if err != nil {
+		atomic.AddUint64(&wt.rpcErrors, 1)
+	}
+}
+
+// ObserveFreePostgres records time spent waiting for a free postgres tx
+func (wt *WorkerTuner) ObserveFreePostgres(wait time.Duration) {
+	if wt != nil {
+		atomic.AddInt64(&wt.freePostgresWait, int64(wait))
+	}
+}
+
+// Start begins tuning the pool every interval until Stop is called
+func (wt *WorkerTuner) Start() {
+	if wt == nil {
+		return
+	}
+	log.Infof("%s worker tuner starting with %d active workers (min %d, max %d)", wt.name, wt.Active(), wt.min, wt.max)
+	go func() {
+		ticker := time.NewTicker(wt.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				wt.Tune()
+			case <-wt.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends tuning
+func (wt *WorkerTuner) Stop() {
+	if wt != nil {
+		wt.stopOnce.Do(func() { close(wt.quit) })
+	}
+}
+
+// Tune measures the pool over the interval since it was last called and adjusts the number of active workers by at most one
+// It backs off when rpc calls are failing or workers are waiting on postgres, otherwise it adds a worker
+// and keeps it only if throughput improves
+func (wt *WorkerTuner) Tune() int64 {
+	now := time.Now()
+	elapsed := now.Sub(wt.lastTune).Seconds()
+	wt.lastTune = now
+	processed := atomic.SwapUint64(&wt.processed, 0)
+	rpcCalls := atomic.SwapUint64(&wt.rpcCalls, 0)
+	rpcErrors := atomic.SwapUint64(&wt.rpcErrors, 0)
+	freePostgresWait := time.Duration(atomic.SwapInt64(&wt.freePostgresWait, 0))
+	dbSaturated := wt.dbSaturated(processed)
+	throughput := 0.0
+	if elapsed > 0 {
+		throughput = float64(processed) / elapsed
+	}
+
+	var direction int64
+	var reason string
+	switch {
+	case rpcCalls > 0 && float64(rpcErrors)/float64(rpcCalls) > maxRPCErrorRate:
+		direction, reason = -1, "rpc error rate is too high"
+		wt.settle = settleIntervals
+	case dbSaturated:
+		direction, reason = -1, "db connection pool is saturated"
+		wt.settle = settleIntervals
+	case processed > 0 && freePostgresWait/time.Duration(processed) > maxFreePostgresWait:
+		direction, reason = -1, "workers are waiting for free postgres txs"
+		wt.settle = settleIntervals
+	case processed == 0:
+		// nothing to measure
+	case wt.lastDirection > 0:
+		if throughput > wt.lastThroughput*(1+minThroughputGain) {
+			direction, reason = 1, "throughput improved"
+		} else {
+			direction, reason = -1, "added worker did not improve throughput"
+			wt.settle = settleIntervals
+		}
+	case wt.settle > 0:
+		wt.settle--
+	default:
+		direction, reason = 1, "probing for more throughput"
+	}
+	wt.lastThroughput = throughput
+
+	wt.lock.Lock()
+	defer wt.lock.Unlock()
+	active := wt.active + direction
+	if active < wt.min {
+		active = wt.min
+	}
+	if active > wt.max {
+		active = wt.max
+	}
+	wt.lastDirection = active - wt.active
+	if active != wt.active {
+		log.Infof("%s worker tuner changing active workers from %d to %d: %s (%.2f blocks/s)", wt.name, wt.active, active, reason, throughput)
+		wt.active = active
+		close(wt.changed)
+		wt.changed = make(chan struct{})
+		prom.SetActiveWorkers(int(active))
+	}
+	return wt.active
+}
+
+// dbSaturated returns true if the time spent waiting for a connection from the db pool since the last check,
+// averaged over the blocks processed in that time, is longer than maxFreePostgresWait
+// an occasional wait for a connection is expected with a busy pool, and is not a reason to back off
+func (wt *WorkerTuner) dbSaturated(processed uint64) bool {
+	if wt.db == nil {
+		return false
+	}
+	waitDuration := wt.db.Stats().WaitDuration
+	wait := waitDuration - wt.lastDBWait
+	wt.lastDBWait = waitDuration
+	if processed == 0 {
+		processed = 1
+	}
+	return wait/time.Duration(processed) > maxFreePostgresWait
+}
@@ -0,0 +1,108 @@
+// VulcanizeDB
+// Copyright © 2021 Vulcanize
+
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package shared_test
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
+)
+
+// mockDBStats returns the configured connection pool stats
+type mockDBStats struct {
+	stats sql.DBStats
+}
+
+func (m *mockDBStats) Stats() sql.DBStats {
+	return m.stats
+}
+
+// interval is what the workers of a pool observed over one tune interval
+type interval struct {
+	processed        int
+	rpcCalls         int
+	rpcErrors        int
+	freePostgresWait time.Duration
+	dbWait           time.Duration
+}
+
+var _ = Describe("WorkerTuner", func() {
+	var db *mockDBStats
+	newTuner := func(min, max, start int64) *shared.WorkerTuner {
+		return shared.NewWorkerTuner(shared.WorkerTunerConfig{Name: "test", Min: min, Max: max, Start: start, DB: db})
+	}
+	observe := func(tuner *shared.WorkerTuner, i interval) {
+		for n := 0; n < i.processed; n++ {
+			tuner.ObserveProcessed()
+		}
+		for n := 0; n < i.rpcCalls; n++ {
+			var err error
+			if n < i.rpcErrors {
+				err = errors.New("rpc error")
+			}
+			tuner.ObserveRPC(err)
+		}
+		tuner.ObserveFreePostgres(i.freePostgresWait)
+		db.stats.WaitCount++
+		db.stats.WaitDuration += i.dbWait
+	}
+	BeforeEach(func() {
+		db = new(mockDBStats)
+	})
+
+	table.DescribeTable("Tune",
+		func(min, max, start int64, i interval, expected int64) {
+			tuner := newTuner(min, max, start)
+			observe(tuner, i)
+			Expect(tuner.Tune()).To(Equal(expected))
+			Expect(tuner.Active()).To(Equal(expected))
+		},
+		table.Entry("probes for more throughput", int64(1), int64(4), int64(2), interval{processed: 10}, int64(3)),
+		table.Entry("stays at the max", int64(1), int64(4), int64(4), interval{processed: 10}, int64(4)),
+		table.Entry("holds without processed blocks", int64(1), int64(4), int64(2), interval{}, int64(2)),
+		table.Entry("backs off when rpc calls are failing", int64(1), int64(4), int64(2), interval{processed: 10, rpcCalls: 10, rpcErrors: 1}, int64(1)),
+		table.Entry("tolerates a few failed rpc calls", int64(1), int64(4), int64(2), interval{processed: 10, rpcCalls: 100, rpcErrors: 1}, int64(3)),
+		table.Entry("backs off when workers wait for free postgres txs", int64(1), int64(4), int64(2), interval{processed: 10, freePostgresWait: 2 * time.Second}, int64(1)),
+		table.Entry("backs off when the db pool is saturated", int64(1), int64(4), int64(2), interval{processed: 10, dbWait: 2 * time.Second}, int64(1)),
+		table.Entry("tolerates a single wait for a db connection", int64(1), int64(4), int64(2), interval{processed: 10, dbWait: 50 * time.Millisecond}, int64(3)),
+		table.Entry("stays at the min", int64(2), int64(4), int64(2), interval{processed: 10, rpcCalls: 10, rpcErrors: 5}, int64(2)),
+	)
+
+	It("Settles after backing off before probing again", func() {
+		tuner := newTuner(1, 4, 3)
+		observe(tuner, interval{processed: 10, rpcCalls: 10, rpcErrors: 5})
+		Expect(tuner.Tune()).To(Equal(int64(2)))
+		for n := 0; n < 10; n++ {
+			observe(tuner, interval{processed: 10})
+			Expect(tuner.Tune()).To(Equal(int64(2)))
+		}
+		observe(tuner, interval{processed: 10})
+		Expect(tuner.Tune()).To(Equal(int64(3)))
+	})
+
+	It("Leaves every worker active when nil", func() {
+		var tuner *shared.WorkerTuner
+		Expect(tuner.PoolSize(5)).To(Equal(int64(5)))
+		Expect(tuner.Wait(5, nil)).To(BeTrue())
+	})
+})
@@ -18,6 +18,7 @@ package sync
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/spf13/viper"
@@ -30,7 +31,11 @@ import (
 
 // Env variables
 const (
-	SYNC_WORKERS = "SYNC_WORKERS"
+	SYNC_WORKERS      = "SYNC_WORKERS"
+	SYNC_MAX_QUEUE_MB = "SYNC_MAX_QUEUE_MB"
+	SYNC_AUTO_TUNE    = "SYNC_AUTO_TUNE"
+	SYNC_MIN_WORKERS  = "SYNC_MIN_WORKERS"
+	SYNC_MAX_WORKERS  = "SYNC_MAX_WORKERS"
 
 	SYNC_MAX_IDLE_CONNECTIONS = "SYNC_MAX_IDLE_CONNECTIONS"
 	SYNC_MAX_OPEN_CONNECTIONS = "SYNC_MAX_OPEN_CONNECTIONS"
@@ -42,8 +47,25 @@ type Config struct {
 	DB       *postgres.DB
 	DBConfig postgres.Config
 	Workers  int64
+	MaxQueue uint64 // Max total size of queued payloads, in bytes
 	WSClient *rpc.Client
 	NodeInfo node.Info
+	// Genesis or chain config file for chains without a built in config (optional)
+	ChainConfigPath string
+	// Worker tuning settings
+	AutoTune   bool
+	MinWorkers int64
+	MaxWorkers int64
+	// Keep the latest state tables up to date
+	LatestState bool
+	// How often to refresh the hourly and daily block stats rollups
+	RollupInterval time.Duration
+	// Directory of contract ABIs to decode logs with (optional)
+	EventABIDir string
+	// Extract the standard token transfer events
+	TokenTransfers bool
+	// Directory of contract storage layouts to decode storage with (optional)
+	StorageLayoutDir string
 }
 
 // NewConfig is used to initialize a sync config from a .toml file
@@ -51,13 +73,33 @@ func NewConfig() (*Config, error) {
 	c := new(Config)
 	var err error
 	viper.BindEnv("sync.workers", SYNC_WORKERS)
+	viper.BindEnv("sync.maxQueueMB", SYNC_MAX_QUEUE_MB)
+	viper.BindEnv("sync.autoTune", SYNC_AUTO_TUNE)
+	viper.BindEnv("sync.minWorkers", SYNC_MIN_WORKERS)
+	viper.BindEnv("sync.maxWorkers", SYNC_MAX_WORKERS)
 	viper.BindEnv("ethereum.wsPath", shared.ETH_WS_PATH)
+	viper.BindEnv("ethereum.chainConfig", shared.ETH_CHAIN_CONFIG)
+	viper.BindEnv("state.latest", shared.STATE_LATEST)
+	viper.BindEnv("stats.rollupInterval", shared.STATS_ROLLUP_INTERVAL)
+	viper.BindEnv("events.abiDir", shared.EVENTS_ABI_DIR)
+	viper.BindEnv("tokens.transfers", shared.TOKEN_TRANSFERS)
+	viper.BindEnv("storage.layoutDir", shared.STORAGE_LAYOUT_DIR)
 
 	workers := viper.GetInt64("sync.workers")
 	if workers < 1 {
 		workers = 1
 	}
 	c.Workers = workers
+	c.MaxQueue = shared.MBToBytes(uint64(viper.GetInt64("sync.maxQueueMB")))
+	c.AutoTune = viper.GetBool("sync.autoTune")
+	c.MinWorkers = viper.GetInt64("sync.minWorkers")
+	c.MaxWorkers = viper.GetInt64("sync.maxWorkers")
+	c.LatestState = viper.GetBool("state.latest")
+	c.RollupInterval = time.Second * time.Duration(viper.GetInt("stats.rollupInterval"))
+	c.EventABIDir = viper.GetString("events.abiDir")
+	c.TokenTransfers = viper.GetBool("tokens.transfers")
+	c.StorageLayoutDir = viper.GetString("storage.layoutDir")
+	c.ChainConfigPath = viper.GetString("ethereum.chainConfig")
 
 	ethWS := viper.GetString("ethereum.wsPath")
 	c.NodeInfo, c.WSClient, err = shared.GetEthNodeAndClient(fmt.Sprintf("ws://%s", ethWS))
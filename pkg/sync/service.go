@@ -27,7 +27,6 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/vulcanize/ipld-eth-indexer/pkg/eth"
-	"github.com/vulcanize/ipld-eth-indexer/pkg/prom"
 	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
 )
 
@@ -56,6 +55,12 @@ type Service struct {
 	QuitChan chan bool
 	// Number of sync workers
 	Workers int64
+	// Max total size in bytes of the payloads waiting to be transformed
+	MaxQueue uint64
+	// Adjusts the number of active sync workers (nil to keep all Workers active)
+	Tuner *shared.WorkerTuner
+	// Periodically refreshes the block stats rollups (nil to leave them to another process)
+	Roller *eth.BlockStatsRoller
 	// chain type for this service
 	ChainConfig *params.ChainConfig
 }
@@ -66,13 +71,38 @@ func NewIndexerService(settings *Config) (Indexer, error) {
 	var err error
 	sn.PayloadChan = make(chan statediff.Payload, eth.PayloadChanBufferSize)
 	sn.Streamer = eth.NewPayloadStreamer(settings.WSClient)
-	sn.ChainConfig, err = eth.ChainConfig(settings.NodeInfo.ChainID)
+	sn.ChainConfig, err = eth.LoadChainConfig(settings.NodeInfo.ChainID, settings.ChainConfigPath)
 	if err != nil {
 		return nil, err
 	}
-	sn.Transformer = eth.NewStateDiffTransformer(sn.ChainConfig, settings.DB)
-	sn.QuitChan = make(chan bool)
 	sn.Workers = settings.Workers
+	if settings.AutoTune {
+		sn.Tuner = shared.NewWorkerTuner(shared.WorkerTunerConfig{
+			Name:  "ethereum sync",
+			Min:   settings.MinWorkers,
+			Max:   settings.MaxWorkers,
+			Start: settings.Workers,
+			DB:    settings.DB,
+		})
+	}
+	events, err := eth.NewEventDecoder(settings.EventABIDir)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := eth.NewStorageDecoder(settings.StorageLayoutDir)
+	if err != nil {
+		return nil, err
+	}
+	sn.Transformer = eth.NewStateDiffTransformer(sn.ChainConfig, settings.DB, eth.TransformerConfig{
+		LatestState:    settings.LatestState,
+		Events:         events,
+		TokenTransfers: settings.TokenTransfers,
+		Storage:        storage,
+		Tuner:          sn.Tuner,
+	})
+	sn.Roller = eth.NewBlockStatsRoller(settings.DB, settings.RollupInterval)
+	sn.QuitChan = make(chan bool)
+	sn.MaxQueue = settings.MaxQueue
 	return sn, nil
 }
 
@@ -91,30 +121,36 @@ func (sap *Service) APIs() []rpc.API {
 // This continues on no matter if or how many subscribers there are
 func (sap *Service) Sync(wg *sync.WaitGroup) error {
 	sub, err := sap.Streamer.Stream(sap.PayloadChan)
+	// the payloads arrive over the subscription, so the subscribe call and the subscription errors are the only rpc outcomes
+	sap.Tuner.ObserveRPC(err)
 	if err != nil {
 		return err
 	}
 	// spin up publish worker goroutines
-	publishPayload := make(chan statediff.Payload, eth.PayloadChanBufferSize)
-	for i := 1; i <= int(sap.Workers); i++ {
+	if sap.MaxQueue == 0 {
+		sap.MaxQueue = shared.MBToBytes(shared.DefaultMaxQueueMB)
+	}
+	publishPayload := eth.NewPayloadQueue(sap.MaxQueue)
+	for i := 1; i <= int(sap.Tuner.PoolSize(sap.Workers)); i++ {
 		go sap.transform(wg, i, publishPayload)
 		log.Debugf("ethereum sync worker %d successfully spun up", i)
 	}
+	sap.Tuner.Start()
+	sap.Roller.Start(wg)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		defer sap.Tuner.Stop()
+		defer sap.Roller.Stop()
 		for {
 			select {
 			case diffPayload := <-sap.PayloadChan:
-				select {
-				case publishPayload <- diffPayload:
-				default:
-					<-publishPayload
-					publishPayload <- diffPayload
+				if dropped := publishPayload.Push(diffPayload); dropped > 0 {
+					log.Warnf("ethereum sync payload queue is full, dropped %d of the oldest payloads", dropped)
 				}
-				prom.SetLenPayloadChan(len(publishPayload))
 			case err := <-sub.Err():
 				log.Errorf("ethereumm sync subscription error: %v", err)
+				sap.Tuner.ObserveRPC(err)
 			case <-sap.QuitChan:
 				log.Info("quiting ethereum sync process")
 				return
@@ -127,16 +163,26 @@ func (sap *Service) Sync(wg *sync.WaitGroup) error {
 
 // transform is spun up by Sync and receives statediff payloads from it
 // it transforms this data into IPLD models and indexes their CIDs with useful metadata in Postgres
-func (sap *Service) transform(wg *sync.WaitGroup, id int, statediffChan <-chan statediff.Payload) {
+func (sap *Service) transform(wg *sync.WaitGroup, id int, queue *eth.PayloadQueue) {
 	wg.Add(1)
 	defer wg.Done()
 	for {
+		// wait until the tuner has this worker among the active ones
+		if !sap.Tuner.Wait(id, sap.QuitChan) {
+			log.Infof("ethereum sync worker %d shutting down", id)
+			return
+		}
 		select {
-		case diff := <-statediffChan:
-			prom.SetLenPayloadChan(len(statediffChan))
+		case <-queue.Ready():
+			diff, ok := queue.Pop()
+			if !ok {
+				continue
+			}
 			blockNumber, err := sap.Transformer.Transform(id, diff)
 			if err != nil {
 				log.Errorf("ethereum sync worker %d transformer error: %v", id, err)
+			} else {
+				sap.Tuner.ObserveProcessed()
 			}
 			log.Infof("ethereum sync worker %d transformed data at height %d", id, blockNumber)
 		case <-sap.QuitChan:
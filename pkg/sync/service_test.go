@@ -17,6 +17,7 @@
 package sync_test
 
 import (
+	"errors"
 	"sync"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/ipld-eth-indexer/pkg/eth/mocks"
+	"github.com/vulcanize/ipld-eth-indexer/pkg/shared"
 	s "github.com/vulcanize/ipld-eth-indexer/pkg/sync"
 )
 
@@ -61,5 +63,22 @@ var _ = Describe("Service", func() {
 			Expect(mockTransformer.PassedStateDiff).To(Equal(mocks.MockStateDiffPayload))
 			Expect(mockStreamer.PassedPayloadChan).To(Equal(payloadChan))
 		})
+
+		It("Backs the workers off when the subscribe call fails", func() {
+			tuner := shared.NewWorkerTuner(shared.WorkerTunerConfig{Name: "test", Min: 1, Max: 4, Start: 2})
+			processor := &s.Service{
+				Streamer: &mocks.PayloadStreamer{
+					ReturnErr: errors.New("subscribe error"),
+				},
+				Transformer: &mocks.Transformer{},
+				PayloadChan: make(chan statediff.Payload, 1),
+				QuitChan:    make(chan bool, 1),
+				Workers:     1,
+				Tuner:       tuner,
+			}
+			err := processor.Sync(new(sync.WaitGroup))
+			Expect(err).To(HaveOccurred())
+			Expect(tuner.Tune()).To(Equal(int64(1)))
+		})
 	})
 })